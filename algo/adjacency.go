@@ -2,16 +2,57 @@ package algo
 
 import (
 	htree "github.com/scisci/hambidgetree"
+	"sort"
 )
 
+// Maps each leaf to the leaves that touch it. The neighbors are in the order
+// FindNeighbors walks the tree, so attributors that visit them in order keep
+// their output for a seed.
 func BuildAdjacencyMatrix(tree htree.Tree, regionMap htree.RegionMap) map[htree.NodeID][]htree.Node {
 	leaves := FindLeaves(tree)
+	index := NewLeafSpatialIndex(leaves, regionMap)
 	matrix := make(map[htree.NodeID][]htree.Node)
 
+	epsilon := 0.0000001
+
 	for _, leaf := range leaves {
-		neighbors := FindNeighbors(tree, leaf, regionMap)
-		matrix[leaf.ID()] = neighbors
+		matrix[leaf.ID()] = walkOrder(tree, leaf, index.Neighbors(leaf, regionMap, epsilon))
 	}
 
 	return matrix
 }
+
+// Reorders neighbors given in tree order the way FindNeighbors finds them.
+// It visits the sibling of each ancestor of the leaf starting from the root,
+// and the leaves under each sibling right to left.
+func walkOrder(tree htree.Tree, leaf htree.Node, neighbors []htree.Node) []htree.Node {
+	var ancestors []htree.NodeID
+	for node := tree.Parent(leaf.ID()); node != nil; node = tree.Parent(node.ID()) {
+		ancestors = append(ancestors, node.ID())
+	}
+
+	depths := make(map[htree.NodeID]int, len(ancestors))
+	for i, id := range ancestors {
+		depths[id] = len(ancestors) - 1 - i
+	}
+
+	// The depth of the ancestor shared with the leaf picks the sibling the
+	// neighbor is under
+	shared := make(map[htree.NodeID]int, len(neighbors))
+	ordered := make([]htree.Node, len(neighbors))
+	for i, neighbor := range neighbors {
+		node := tree.Parent(neighbor.ID())
+		for ; node != nil; node = tree.Parent(node.ID()) {
+			if depth, ok := depths[node.ID()]; ok {
+				shared[neighbor.ID()] = depth
+				break
+			}
+		}
+		ordered[len(neighbors)-1-i] = neighbor
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return shared[ordered[i].ID()] < shared[ordered[j].ID()]
+	})
+	return ordered
+}
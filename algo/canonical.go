@@ -0,0 +1,93 @@
+package algo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/simple"
+)
+
+// Returns a copy of the tree whose nodes are numbered 1, 2, 3... in pre-order,
+// left before right. Two trees with the same structure have identical
+// canonical forms regardless of how their nodes were numbered.
+func Canonicalize(tree htree.Tree) *simple.Tree {
+	nodes := make(simple.NodeLookup)
+	parents := make(simple.ParentLookup)
+	nextID := htree.NodeID(0)
+
+	var visit func(node htree.Node) *simple.Node
+	visit = func(node htree.Node) *simple.Node {
+		nextID++
+		id := nextID
+
+		var simpleBranch *simple.Branch
+		if branch := node.Branch(); branch != nil {
+			left := visit(branch.Left())
+			right := visit(branch.Right())
+			parents[left.ID()] = id
+			parents[right.ID()] = id
+			simpleBranch = simple.NewBranch(branch.SplitType(), left, right, branch.LeftIndex(), branch.RightIndex())
+		}
+
+		nodes[id] = simple.NewNode(id, simpleBranch)
+		return nodes[id]
+	}
+
+	root := visit(tree.Root())
+	return simple.NewTree(tree.RatioSource(), tree.RatioIndexXY(), tree.RatioIndexZY(), root, nodes, parents)
+}
+
+// Version of the data covered by Hash, changing it changes every hash.
+const hashVersion = "htree-hash-1"
+
+// Returns a hash of the tree's ratio exprs, container ratio indexes and the
+// split type and ratio indexes of each branch in pre-order. Node ids are not
+// included so trees with the same structure have the same hash.
+func Hash(tree htree.Tree) [sha256.Size]byte {
+	h := sha256.New()
+	var scratch [binary.MaxVarintLen64]byte
+
+	putVarint := func(v int64) {
+		h.Write(scratch[:binary.PutVarint(scratch[:], v)])
+	}
+
+	h.Write([]byte(hashVersion))
+
+	exprs := tree.RatioSource().Exprs()
+	putVarint(int64(len(exprs)))
+	for _, expr := range exprs {
+		putVarint(int64(len(expr)))
+		h.Write([]byte(expr))
+	}
+
+	putVarint(int64(tree.RatioIndexXY()))
+	putVarint(int64(tree.RatioIndexZY()))
+
+	stack := []htree.Node{tree.Root()}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		branch := node.Branch()
+		if branch == nil {
+			putVarint(0)
+			continue
+		}
+
+		putVarint(int64(branch.SplitType()))
+		putVarint(int64(branch.LeftIndex()))
+		putVarint(int64(branch.RightIndex()))
+		stack = append(stack, branch.Right(), branch.Left())
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// Same as Hash but returned as a hex string.
+func HashString(tree htree.Tree) string {
+	sum := Hash(tree)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,88 @@
+package algo_test
+
+import (
+	"bytes"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/builder"
+	"github.com/scisci/hambidgetree/factory"
+	"github.com/scisci/hambidgetree/simple"
+	"testing"
+)
+
+// Builds a square split in two with the halves split again, splitting the
+// halves in the given order so the node ids differ.
+func buildHalves(t *testing.T, leftFirst bool, splitBoth bool) *simple.Tree {
+	ratioSource, err := htree.NewBasicRatioSource([]float64{0.5, 1, 2})
+	if err != nil {
+		t.Fatalf("Failed to create ratio source %v", err)
+	}
+
+	b := builder.New2D(ratioSource, 1)
+	left, right := b.Branch(b.Leaves()[0].ID(), htree.SplitTypeVertical, 0, 0)
+
+	splitLeft := func() {
+		b.Branch(left.ID(), htree.SplitTypeHorizontal, 1, 1)
+	}
+	splitRight := func() {
+		if splitBoth {
+			b.Branch(right.ID(), htree.SplitTypeHorizontal, 1, 1)
+		}
+	}
+
+	if leftFirst {
+		splitLeft()
+		splitRight()
+	} else {
+		splitRight()
+		splitLeft()
+	}
+
+	tree, _ := b.Build()
+	return tree
+}
+
+func TestCanonicalize(t *testing.T) {
+	tree1 := buildHalves(t, true, true)
+	tree2 := buildHalves(t, false, true)
+	tree3 := buildHalves(t, true, false)
+
+	data1, _ := factory.MarshalJSON(tree1)
+	data2, _ := factory.MarshalJSON(tree2)
+	if bytes.Equal(data1, data2) {
+		t.Fatalf("Expected trees to be numbered differently")
+	}
+
+	canon1, _ := factory.MarshalJSON(algo.Canonicalize(tree1))
+	canon2, _ := factory.MarshalJSON(algo.Canonicalize(tree2))
+	if !bytes.Equal(canon1, canon2) {
+		t.Errorf("Expected canonical forms to match\n%s\n%s", canon1, canon2)
+	}
+
+	// Canonicalizing twice changes nothing
+	canon3, _ := factory.MarshalJSON(algo.Canonicalize(algo.Canonicalize(tree1)))
+	if !bytes.Equal(canon1, canon3) {
+		t.Errorf("Expected canonicalize to be idempotent")
+	}
+
+	canonical := algo.Canonicalize(tree2)
+	if canonical.Root().ID() != 1 {
+		t.Errorf("Expected root to be 1, got %d", canonical.Root().ID())
+	}
+	if id := canonical.Root().Branch().Right().ID(); id != 5 {
+		t.Errorf("Expected right child of root to be 5, got %d", id)
+	}
+	if parent := canonical.Parent(5); parent == nil || parent.ID() != 1 {
+		t.Errorf("Expected parent of 5 to be the root")
+	}
+
+	if algo.Hash(tree1) != algo.Hash(tree2) {
+		t.Errorf("Expected equal hashes for equivalent trees")
+	}
+	if algo.Hash(tree1) == algo.Hash(tree3) {
+		t.Errorf("Expected different hashes for different trees")
+	}
+	if algo.HashString(tree1) != algo.HashString(algo.Canonicalize(tree1)) {
+		t.Errorf("Expected canonical form to have the same hash")
+	}
+}
@@ -0,0 +1,283 @@
+package algo
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"math"
+	"sort"
+)
+
+// The most leaves kept in a node of the hierarchy before it is split.
+const indexNodeSize = 4
+
+// A bounding volume hierarchy over the regions of a set of leaves, used to
+// find leaves by position without comparing against every leaf. Queries work
+// the same in 2D, where every region has no depth, and 3D. Results are always
+// in the order the leaves were given, which for NewSpatialIndex is tree order.
+type SpatialIndex struct {
+	items []indexItem
+	nodes []indexNode
+}
+
+type indexItem struct {
+	node   htree.Node
+	order  int
+	bounds bounds
+}
+
+// A node of the hierarchy is either a branch with two children or holds the
+// items from start to end.
+type indexNode struct {
+	bounds      bounds
+	left, right int
+	start, end  int
+}
+
+func (node *indexNode) isLeaf() bool {
+	return node.left < 0
+}
+
+type bounds struct {
+	min, max [3]float64
+}
+
+func boxBounds(box *htree.AlignedBox) bounds {
+	return bounds{
+		min: [3]float64{box.Left(), box.Top(), box.Front()},
+		max: [3]float64{box.Right(), box.Bottom(), box.Back()},
+	}
+}
+
+func (b bounds) union(other bounds) bounds {
+	for i := 0; i < 3; i++ {
+		b.min[i] = math.Min(b.min[i], other.min[i])
+		b.max[i] = math.Max(b.max[i], other.max[i])
+	}
+	return b
+}
+
+// Same as AlignedBox.DistanceSquared.
+func (b bounds) distanceSquared(other bounds) float64 {
+	dist := 0.0
+	for i := 0; i < 3; i++ {
+		d := math.Max(other.min[i]-b.max[i], b.min[i]-other.max[i])
+		if d > 0 {
+			dist += d * d
+		}
+	}
+	return dist
+}
+
+func (b bounds) distanceSquaredToPoint(p [3]float64) float64 {
+	return b.distanceSquared(bounds{min: p, max: p})
+}
+
+// Boxes overlap if they share more than epsilon on every axis where both have
+// size. Regions of a 2D tree have no depth so they aren't compared on z.
+func (b bounds) overlaps(other bounds, epsilon float64) bool {
+	if b.distanceSquared(other) > 0 {
+		return false
+	}
+
+	for i := 0; i < 3; i++ {
+		if b.max[i] <= b.min[i] || other.max[i] <= other.min[i] {
+			continue
+		}
+		if math.Min(b.max[i], other.max[i])-math.Max(b.min[i], other.min[i]) <= epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func (b bounds) center(axis int) float64 {
+	return (b.min[axis] + b.max[axis]) / 2
+}
+
+// Indexes the leaves of the tree.
+func NewSpatialIndex(tree htree.Tree, regionMap htree.RegionMap) *SpatialIndex {
+	return NewLeafSpatialIndex(FindLeaves(tree), regionMap)
+}
+
+// Indexes the given leaves, each of which must be in the region map.
+func NewLeafSpatialIndex(leaves []htree.Node, regionMap htree.RegionMap) *SpatialIndex {
+	index := &SpatialIndex{
+		items: make([]indexItem, len(leaves)),
+	}
+
+	for i, leaf := range leaves {
+		index.items[i] = indexItem{
+			node:   leaf,
+			order:  i,
+			bounds: boxBounds(regionMap[leaf.ID()].AlignedBox()),
+		}
+	}
+
+	if len(leaves) > 0 {
+		index.build(0, len(leaves))
+	}
+
+	return index
+}
+
+// Builds the node holding the items from start to end by splitting them at
+// the median of the longest axis, returns the index of the node.
+func (index *SpatialIndex) build(start, end int) int {
+	b := index.items[start].bounds
+	for _, item := range index.items[start+1 : end] {
+		b = b.union(item.bounds)
+	}
+
+	n := len(index.nodes)
+	index.nodes = append(index.nodes, indexNode{bounds: b, left: -1, right: -1, start: start, end: end})
+	if end-start <= indexNodeSize {
+		return n
+	}
+
+	axis := 0
+	for i := 1; i < 3; i++ {
+		if b.max[i]-b.min[i] > b.max[axis]-b.min[axis] {
+			axis = i
+		}
+	}
+
+	items := index.items[start:end]
+	sort.Slice(items, func(i, j int) bool {
+		ci, cj := items[i].bounds.center(axis), items[j].bounds.center(axis)
+		if ci != cj {
+			return ci < cj
+		}
+		return items[i].order < items[j].order
+	})
+
+	mid := start + (end-start)/2
+	left := index.build(start, mid)
+	right := index.build(mid, end)
+	index.nodes[n].left = left
+	index.nodes[n].right = right
+	return n
+}
+
+// Visits the items of every node that prune doesn't reject.
+func (index *SpatialIndex) visit(prune func(b bounds) bool, fn func(item *indexItem)) {
+	if len(index.nodes) == 0 {
+		return
+	}
+
+	stack := []int{0}
+	for len(stack) > 0 {
+		node := &index.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+
+		if prune(node.bounds) {
+			continue
+		}
+
+		if !node.isLeaf() {
+			stack = append(stack, node.left, node.right)
+			continue
+		}
+
+		for i := node.start; i < node.end; i++ {
+			fn(&index.items[i])
+		}
+	}
+}
+
+// Returns the nodes of the items in the order the leaves were given.
+func collect(items []*indexItem) []htree.Node {
+	sort.Slice(items, func(i, j int) bool { return items[i].order < items[j].order })
+	nodes := make([]htree.Node, len(items))
+	for i, item := range items {
+		nodes[i] = item.node
+	}
+	return nodes
+}
+
+// Returns the leaves that overlap or touch the box, i.e. whose squared distance
+// to it is at most epsilon.
+func (index *SpatialIndex) Within(box *htree.AlignedBox, epsilon float64) []htree.Node {
+	query := boxBounds(box)
+
+	var items []*indexItem
+	index.visit(func(b bounds) bool {
+		return b.distanceSquared(query) > epsilon
+	}, func(item *indexItem) {
+		if item.bounds.distanceSquared(query) <= epsilon {
+			items = append(items, item)
+		}
+	})
+
+	return collect(items)
+}
+
+// Returns the leaves that share more than epsilon of the box on every axis
+// where both have size, leaves that only touch it are excluded.
+func (index *SpatialIndex) Overlapping(box *htree.AlignedBox, epsilon float64) []htree.Node {
+	query := boxBounds(box)
+
+	var items []*indexItem
+	index.visit(func(b bounds) bool {
+		return b.distanceSquared(query) > 0
+	}, func(item *indexItem) {
+		if item.bounds.overlaps(query, epsilon) {
+			items = append(items, item)
+		}
+	})
+
+	return collect(items)
+}
+
+// Returns the other leaves that touch the leaf, its squared distance to each
+// is at most epsilon. This includes leaves that only touch at a corner.
+func (index *SpatialIndex) Neighbors(node htree.Node, regionMap htree.RegionMap, epsilon float64) []htree.Node {
+	var neighbors []htree.Node
+	for _, other := range index.Within(regionMap[node.ID()].AlignedBox(), epsilon) {
+		if other.ID() != node.ID() {
+			neighbors = append(neighbors, other)
+		}
+	}
+	return neighbors
+}
+
+// Returns the leaf containing the point, or nil if there isn't one. A point on
+// the boundary of several leaves returns the first of them.
+func (index *SpatialIndex) At(point *htree.Vector) htree.Node {
+	p := [3]float64{point.X(), point.Y(), point.Z()}
+
+	var found *indexItem
+	index.visit(func(b bounds) bool {
+		return b.distanceSquaredToPoint(p) > 0
+	}, func(item *indexItem) {
+		if item.bounds.distanceSquaredToPoint(p) == 0 && (found == nil || item.order < found.order) {
+			found = item
+		}
+	})
+
+	if found == nil {
+		return nil
+	}
+	return found.node
+}
+
+// Returns the leaf closest to the point, the first of them if several are the
+// same distance, or nil if the index is empty.
+func (index *SpatialIndex) Nearest(point *htree.Vector) htree.Node {
+	p := [3]float64{point.X(), point.Y(), point.Z()}
+
+	var found *indexItem
+	best := math.Inf(1)
+	index.visit(func(b bounds) bool {
+		return b.distanceSquaredToPoint(p) > best
+	}, func(item *indexItem) {
+		dist := item.bounds.distanceSquaredToPoint(p)
+		if dist < best || (dist == best && item.order < found.order) {
+			found = item
+			best = dist
+		}
+	})
+
+	if found == nil {
+		return nil
+	}
+	return found.node
+}
@@ -0,0 +1,149 @@
+package algo_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func nodeIDs(nodes []htree.Node) []htree.NodeID {
+	ids := make([]htree.NodeID, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ID()
+	}
+	return ids
+}
+
+func sameNodes(t *testing.T, what string, got, expected []htree.Node) {
+	g, e := nodeIDs(got), nodeIDs(expected)
+	if len(g) != len(e) {
+		t.Errorf("%s expected %v, got %v", what, e, g)
+		return
+	}
+	for i := range g {
+		if g[i] != e[i] {
+			t.Errorf("%s expected %v, got %v", what, e, g)
+			return
+		}
+	}
+}
+
+// Returns a random box within the tree, flat on z for 2D trees.
+func randomBox(rnd *rand.Rand, is3D bool) *htree.AlignedBox {
+	random := func() (float64, float64) {
+		a, b := rnd.Float64()*2, rnd.Float64()*2
+		return math.Min(a, b), math.Max(a, b)
+	}
+
+	left, right := random()
+	top, bottom := random()
+	front, back := 0.0, 0.0
+	if is3D {
+		front, back = random()
+	}
+	return htree.NewAlignedBox3D(left, top, front, right, bottom, back)
+}
+
+// Boxes overlap if they share more than epsilon on every axis where both have
+// size.
+func overlaps(a, b *htree.AlignedBox, epsilon float64) bool {
+	axes := [][4]float64{
+		{a.Left(), a.Right(), b.Left(), b.Right()},
+		{a.Top(), a.Bottom(), b.Top(), b.Bottom()},
+		{a.Front(), a.Back(), b.Front(), b.Back()},
+	}
+	for _, axis := range axes {
+		if axis[1] <= axis[0] || axis[3] <= axis[2] {
+			if axis[1] < axis[2] || axis[3] < axis[0] {
+				return false
+			}
+			continue
+		}
+		if math.Min(axis[1], axis[3])-math.Max(axis[0], axis[2]) <= epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSpatialIndex(t *testing.T) {
+	epsilon := 0.0000001
+
+	for _, is3D := range []bool{false, true} {
+		for seed := int64(1); seed <= 5; seed++ {
+			tree := generate(t, is3D, seed)
+			regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+			leaves := algo.FindLeaves(tree)
+			index := algo.NewSpatialIndex(tree, regionMap)
+			rnd := rand.New(rand.NewSource(seed))
+
+			// Neighbors match the ones found by walking the tree, in the same
+			// order
+			matrix := algo.BuildAdjacencyMatrix(tree, regionMap)
+			for _, leaf := range leaves {
+				sameNodes(t, "Neighbors", matrix[leaf.ID()], algo.FindNeighbors(tree, leaf, regionMap))
+			}
+
+			// Box queries match comparing every leaf
+			for i := 0; i < 20; i++ {
+				box := randomBox(rnd, is3D)
+
+				var within, overlapping []htree.Node
+				for _, leaf := range leaves {
+					dim := regionMap[leaf.ID()].AlignedBox()
+					if dim.DistanceSquared(box) <= epsilon {
+						within = append(within, leaf)
+					}
+
+					if overlaps(dim, box, epsilon) {
+						overlapping = append(overlapping, leaf)
+					}
+				}
+
+				sameNodes(t, "Within", index.Within(box, epsilon), within)
+				sameNodes(t, "Overlapping", index.Overlapping(box, epsilon), overlapping)
+			}
+
+			// Point queries match comparing every leaf
+			for i := 0; i < 20; i++ {
+				point := htree.NewVector(rnd.Float64()*2, rnd.Float64()*1.2, 0)
+				if is3D {
+					point = htree.NewVector(rnd.Float64()*1.2, rnd.Float64()*1.2, rnd.Float64()*1.2)
+				}
+				pointBox := htree.NewAlignedBox3DV(point, point)
+
+				var at, nearest htree.Node
+				best := math.Inf(1)
+				for _, leaf := range leaves {
+					dist := regionMap[leaf.ID()].AlignedBox().DistanceSquared(pointBox)
+					if dist == 0 && at == nil {
+						at = leaf
+					}
+					if dist < best {
+						nearest = leaf
+						best = dist
+					}
+				}
+
+				if got := index.At(point); got != at {
+					t.Errorf("Point %v expected leaf %v, got %v", point, at, got)
+				}
+				if got := index.Nearest(point); got != nearest {
+					t.Errorf("Point %v expected nearest leaf %v, got %v", point, nearest, got)
+				}
+			}
+		}
+	}
+}
+
+func TestSpatialIndexEmpty(t *testing.T) {
+	index := algo.NewLeafSpatialIndex(nil, nil)
+	if index.Nearest(htree.Origin) != nil || index.At(htree.Origin) != nil {
+		t.Errorf("Expected an empty index to find nothing")
+	}
+	if len(index.Within(htree.NewAlignedBox2D(0, 0, 1, 1), 0)) != 0 {
+		t.Errorf("Expected an empty index to find nothing")
+	}
+}
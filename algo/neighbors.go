@@ -6,6 +6,10 @@ import (
 
 // Go up the tree and select all 'other' leaves, then recursively visit any
 // branches that intersect our leaf until we find leaves that intersect
+//
+// Deprecated: Use BuildAdjacencyMatrix, which finds the neighbors of every
+// leaf in the same order with a spatial index instead of walking the tree for
+// each leaf.
 func FindNeighbors(tree htree.Tree, node htree.Node, regionMap htree.RegionMap) []htree.Node {
 	dim := regionMap[node.ID()].AlignedBox()
 
@@ -0,0 +1,300 @@
+package algo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/simple"
+	"math"
+	"sort"
+)
+
+var ErrRotationNotSupported = errors.New("Rotation is not supported by the ratio set")
+
+// Precision used when comparing layouts, coordinates are rounded to this.
+const layoutPrecision = 1000000
+
+// The new xy and zy ratio indexes of a region after a transform, ok is false
+// when the ratio set doesn't contain them.
+type ratioMapping func(ratioIndexXY, ratioIndexZY int) (int, int, bool)
+
+// The new split type of a branch after a transform, and whether its children
+// trade places.
+type splitMapping func(splitType htree.SplitType) (htree.SplitType, bool)
+
+// Rebuilds the tree with each region's ratios and each branch's split mapped.
+// Node ids are kept.
+func transform(tree htree.Tree, mapRatios ratioMapping, mapSplit splitMapping) (*simple.Tree, error) {
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+
+	newIndexes := func(node htree.Node) (int, int, error) {
+		region := regionMap[node.ID()]
+		xy, zy, ok := mapRatios(region.RatioIndexXY(), region.RatioIndexZY())
+		if !ok {
+			return 0, 0, ErrRotationNotSupported
+		}
+		return xy, zy, nil
+	}
+
+	nodes := make(simple.NodeLookup)
+	parents := make(simple.ParentLookup)
+
+	var visit func(node htree.Node) (*simple.Node, error)
+	visit = func(node htree.Node) (*simple.Node, error) {
+		branch := node.Branch()
+		if branch == nil {
+			nodes[node.ID()] = simple.NewNode(node.ID(), nil)
+			return nodes[node.ID()], nil
+		}
+
+		splitType, swap := mapSplit(branch.SplitType())
+		first, second := branch.Left(), branch.Right()
+		if swap {
+			first, second = second, first
+		}
+
+		left, err := visit(first)
+		if err != nil {
+			return nil, err
+		}
+		right, err := visit(second)
+		if err != nil {
+			return nil, err
+		}
+
+		leftXY, leftZY, err := newIndexes(first)
+		if err != nil {
+			return nil, err
+		}
+		rightXY, rightZY, err := newIndexes(second)
+		if err != nil {
+			return nil, err
+		}
+
+		leftIndex, rightIndex := leftXY, rightXY
+		if splitType == htree.SplitTypeDepth {
+			leftIndex, rightIndex = leftZY, rightZY
+		}
+
+		parents[left.ID()] = node.ID()
+		parents[right.ID()] = node.ID()
+		nodes[node.ID()] = simple.NewNode(node.ID(), simple.NewBranch(splitType, left, right, leftIndex, rightIndex))
+		return nodes[node.ID()], nil
+	}
+
+	root, err := visit(tree.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	ratioIndexXY, ratioIndexZY, err := newIndexes(tree.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	return simple.NewTree(tree.RatioSource(), ratioIndexXY, ratioIndexZY, root, nodes, parents), nil
+}
+
+func sameRatios(ratioIndexXY, ratioIndexZY int) (int, int, bool) {
+	return ratioIndexXY, ratioIndexZY, true
+}
+
+// Returns the tree mirrored along the axis, the children of every split along
+// that axis trade places.
+func Mirror(tree htree.Tree, axis htree.Axis) *simple.Tree {
+	mirrored := splitTypeForAxis(axis)
+	result, _ := transform(tree, sameRatios, func(splitType htree.SplitType) (htree.SplitType, bool) {
+		return splitType, splitType == mirrored
+	})
+	return result
+}
+
+func splitTypeForAxis(axis htree.Axis) htree.SplitType {
+	switch axis {
+	case htree.AxisX:
+		return htree.SplitTypeVertical
+	case htree.AxisY:
+		return htree.SplitTypeHorizontal
+	case htree.AxisZ:
+		return htree.SplitTypeDepth
+	}
+	panic("Unknown axis")
+}
+
+// Returns the tree rotated a quarter turn about the axis. About Z the front
+// face turns clockwise, about Y the top face turns clockwise and about X the
+// side face turns clockwise. Since the height of a tree is always unity the
+// ratios of every region change, ErrRotationNotSupported is returned if they
+// aren't all in the ratio set. 2D trees can only be rotated about Z.
+func Rotate90(tree htree.Tree, axis htree.Axis) (*simple.Tree, error) {
+	epsilon := 0.0000001
+	ratios := tree.RatioSource().Ratios()
+	is3D := htree.IsRatioIndexDefined(tree.RatioIndexZY())
+
+	find := func(ratio float64) (int, bool) {
+		index := htree.FindClosestIndexWithinRange(ratios, ratio, epsilon)
+		return index, htree.IsRatioIndexDefined(index)
+	}
+
+	var mapRatios ratioMapping
+	var fromType, toType htree.SplitType
+
+	switch axis {
+	case htree.AxisZ:
+		// Width and height trade places
+		fromType, toType = htree.SplitTypeVertical, htree.SplitTypeHorizontal
+		mapRatios = func(xy, zy int) (int, int, bool) {
+			newXY, ok := find(1 / ratios[xy])
+			if !ok || !is3D {
+				return newXY, zy, ok
+			}
+			newZY, ok := find(ratios[zy] / ratios[xy])
+			return newXY, newZY, ok
+		}
+	case htree.AxisY:
+		// Width and depth trade places
+		fromType, toType = htree.SplitTypeVertical, htree.SplitTypeDepth
+		mapRatios = func(xy, zy int) (int, int, bool) {
+			return zy, xy, true
+		}
+	case htree.AxisX:
+		// Height and depth trade places
+		fromType, toType = htree.SplitTypeHorizontal, htree.SplitTypeDepth
+		mapRatios = func(xy, zy int) (int, int, bool) {
+			newXY, ok := find(ratios[xy] / ratios[zy])
+			if !ok {
+				return newXY, 0, false
+			}
+			newZY, ok := find(1 / ratios[zy])
+			return newXY, newZY, ok
+		}
+	default:
+		panic("Unknown axis")
+	}
+
+	if !is3D && axis != htree.AxisZ {
+		return nil, ErrRotationNotSupported
+	}
+
+	// The first axis turns into the second, the second turns into the first
+	// reversed.
+	return transform(tree, mapRatios, func(splitType htree.SplitType) (htree.SplitType, bool) {
+		switch splitType {
+		case fromType:
+			return toType, false
+		case toType:
+			return fromType, true
+		}
+		return splitType, false
+	})
+}
+
+// Returns a signature of the geometry of the tree's leaves. Trees whose leaves
+// occupy the same boxes have the same signature regardless of how they are
+// structured, so a grid split vertically then horizontally has the same
+// signature as one split horizontally then vertically.
+func LayoutSignature(tree htree.Tree) string {
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	leaves := FindLeaves(tree)
+
+	quantize := func(v float64) int64 {
+		return int64(math.Round(v * layoutPrecision))
+	}
+
+	boxes := make([][6]int64, len(leaves))
+	for i, leaf := range leaves {
+		dim := regionMap[leaf.ID()].AlignedBox()
+		boxes[i] = [6]int64{
+			quantize(dim.Left()), quantize(dim.Top()), quantize(dim.Front()),
+			quantize(dim.Right()), quantize(dim.Bottom()), quantize(dim.Back()),
+		}
+	}
+
+	sort.Slice(boxes, func(i, j int) bool {
+		for k := range boxes[i] {
+			if boxes[i][k] != boxes[j][k] {
+				return boxes[i][k] < boxes[j][k]
+			}
+		}
+		return false
+	})
+
+	h := sha256.New()
+	var scratch [binary.MaxVarintLen64]byte
+	for _, box := range boxes {
+		for _, v := range box {
+			h.Write(scratch[:binary.PutVarint(scratch[:], v)])
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Returns every distinct layout that can be reached from the tree by
+// mirroring and rotating, including the tree itself, keyed by layout
+// signature. Rotations the ratio set doesn't support are skipped. Every mirror
+// is applied directly since without the rotations they can't be reached from
+// one another.
+func Symmetries(tree htree.Tree) map[string]*simple.Tree {
+	operations := []func(tree htree.Tree) (*simple.Tree, error){
+		func(tree htree.Tree) (*simple.Tree, error) { return Mirror(tree, htree.AxisX), nil },
+		func(tree htree.Tree) (*simple.Tree, error) { return Mirror(tree, htree.AxisY), nil },
+		func(tree htree.Tree) (*simple.Tree, error) { return Rotate90(tree, htree.AxisZ) },
+	}
+
+	if htree.IsRatioIndexDefined(tree.RatioIndexZY()) {
+		operations = append(operations,
+			func(tree htree.Tree) (*simple.Tree, error) { return Mirror(tree, htree.AxisZ), nil },
+			func(tree htree.Tree) (*simple.Tree, error) { return Rotate90(tree, htree.AxisY) },
+			func(tree htree.Tree) (*simple.Tree, error) { return Rotate90(tree, htree.AxisX) },
+		)
+	}
+
+	start := Canonicalize(tree)
+	result := map[string]*simple.Tree{LayoutSignature(start): start}
+	queue := []*simple.Tree{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, operation := range operations {
+			next, err := operation(current)
+			if err != nil {
+				continue
+			}
+
+			signature := LayoutSignature(next)
+			if _, ok := result[signature]; ok {
+				continue
+			}
+
+			result[signature] = next
+			queue = append(queue, next)
+		}
+	}
+
+	return result
+}
+
+// Returns the member of the tree's symmetry class with the smallest layout
+// signature, numbered canonically. Equivalent trees have representatives
+// with the same layout.
+func CanonicalRepresentative(tree htree.Tree) *simple.Tree {
+	var minSignature string
+	var representative *simple.Tree
+	for signature, variant := range Symmetries(tree) {
+		if representative == nil || signature < minSignature {
+			minSignature = signature
+			representative = variant
+		}
+	}
+	return Canonicalize(representative)
+}
+
+// Whether the trees have the same layout once mirrored and rotated.
+func Equivalent(a, b htree.Tree) bool {
+	_, ok := Symmetries(a)[LayoutSignature(b)]
+	return ok
+}
@@ -0,0 +1,154 @@
+package algo_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/generators/randombasic"
+	"github.com/scisci/hambidgetree/golden"
+	"math"
+	"testing"
+)
+
+func generate(t *testing.T, is3D bool, seed int64) htree.Tree {
+	var gen *randombasic.RandomBasicTreeGenerator
+	var err error
+	if is3D {
+		gen, err = randombasic.New3D(golden.RatioSource(), 1, 1, 12, seed)
+	} else {
+		gen, err = randombasic.New(golden.RatioSource(), 1.618, 12, seed)
+	}
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+	tree, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate tree %v", err)
+	}
+	return tree
+}
+
+func TestMirror(t *testing.T) {
+	tree := generate(t, true, 3)
+	signature := algo.LayoutSignature(tree)
+
+	for _, axis := range []htree.Axis{htree.AxisX, htree.AxisY, htree.AxisZ} {
+		mirrored := algo.Mirror(tree, axis)
+		if algo.LayoutSignature(mirrored) == signature {
+			t.Errorf("Expected mirroring along %d to change the layout", axis)
+		}
+		if algo.LayoutSignature(algo.Mirror(mirrored, axis)) != signature {
+			t.Errorf("Expected mirroring along %d twice to restore the layout", axis)
+		}
+		if !algo.Equivalent(tree, mirrored) {
+			t.Errorf("Expected mirror along %d to be equivalent", axis)
+		}
+	}
+}
+
+func TestRotate90(t *testing.T) {
+	tree := generate(t, false, 5)
+	rotated, err := algo.Rotate90(tree, htree.AxisZ)
+	if err != nil {
+		t.Fatalf("Failed to rotate %v", err)
+	}
+
+	// Every leaf of the rotated tree must be a rotated leaf of the original,
+	// scaled so the height is unity again
+	width := tree.RatioSource().Ratios()[tree.RatioIndexXY()]
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	rotatedMap := htree.NewTreeRegionMap(rotated, htree.Origin, htree.UnityScale)
+	for _, leaf := range algo.FindLeaves(tree) {
+		dim := regionMap[leaf.ID()].AlignedBox()
+		rotatedDim := rotatedMap[leaf.ID()].AlignedBox()
+
+		expected := []float64{(1 - dim.Bottom()) / width, dim.Left() / width, (1 - dim.Top()) / width, dim.Right() / width}
+		got := []float64{rotatedDim.Left(), rotatedDim.Top(), rotatedDim.Right(), rotatedDim.Bottom()}
+		for i := range expected {
+			if math.Abs(expected[i]-got[i]) > 0.000001 {
+				t.Errorf("Leaf %d expected %v, got %v", leaf.ID(), expected, got)
+				break
+			}
+		}
+	}
+
+	// Four turns is a full rotation
+	for _, is3D := range []bool{false, true} {
+		tree := generate(t, is3D, 7)
+		signature := algo.LayoutSignature(tree)
+		for _, axis := range []htree.Axis{htree.AxisX, htree.AxisY, htree.AxisZ} {
+			var current htree.Tree = tree
+			var err error
+			for i := 0; i < 4 && err == nil; i++ {
+				current, err = algo.Rotate90(current, axis)
+			}
+			if err == algo.ErrRotationNotSupported {
+				continue
+			}
+			if err != nil {
+				t.Fatalf("Failed to rotate %v", err)
+			}
+			if algo.LayoutSignature(current) != signature {
+				t.Errorf("Expected 4 rotations about %d to restore the layout", axis)
+			}
+		}
+	}
+
+	if _, err := algo.Rotate90(tree, htree.AxisY); err != algo.ErrRotationNotSupported {
+		t.Errorf("Expected 2D trees to only rotate about Z, got %v", err)
+	}
+}
+
+func TestCanonicalRepresentative(t *testing.T) {
+	tree := generate(t, false, 9)
+	rotated, _ := algo.Rotate90(tree, htree.AxisZ)
+	variants := []htree.Tree{
+		algo.Mirror(tree, htree.AxisX),
+		algo.Mirror(algo.Mirror(tree, htree.AxisX), htree.AxisY),
+		rotated,
+	}
+
+	symmetries := algo.Symmetries(tree)
+	if len(symmetries) != 8 {
+		t.Errorf("Expected 8 symmetries of an asymmetric 2D layout, got %d", len(symmetries))
+	}
+
+	representative := algo.LayoutSignature(algo.CanonicalRepresentative(tree))
+	for i, variant := range variants {
+		if !algo.Equivalent(tree, variant) {
+			t.Errorf("Variant %d expected to be equivalent", i)
+		}
+		if algo.LayoutSignature(algo.CanonicalRepresentative(variant)) != representative {
+			t.Errorf("Variant %d expected to have the same representative", i)
+		}
+	}
+
+	if algo.Equivalent(tree, generate(t, false, 10)) {
+		t.Errorf("Expected different layouts not to be equivalent")
+	}
+}
+
+func TestSymmetriesWithoutRotations(t *testing.T) {
+	// Neither the container or its regions can be rotated about Z or X
+	gen, err := randombasic.New3D(golden.RatioSource(), 0.125, 1.118033988749895, 12, 4)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+	tree, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate tree %v", err)
+	}
+
+	for _, axis := range []htree.Axis{htree.AxisZ, htree.AxisX} {
+		if _, err := algo.Rotate90(tree, axis); err != algo.ErrRotationNotSupported {
+			t.Fatalf("Expected the tree not to rotate about axis %d, got %v", axis, err)
+		}
+	}
+
+	// Mirrors are always possible even if rotations aren't
+	symmetries := algo.Symmetries(tree)
+	for _, axis := range []htree.Axis{htree.AxisX, htree.AxisY, htree.AxisZ} {
+		if _, ok := symmetries[algo.LayoutSignature(algo.Mirror(tree, axis))]; !ok {
+			t.Errorf("Expected the mirror about axis %d to be a symmetry", axis)
+		}
+	}
+}
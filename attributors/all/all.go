@@ -0,0 +1,11 @@
+// Package all registers every built in attributor with the attributors
+// registry, import it for its side effects:
+//
+//	import _ "github.com/scisci/hambidgetree/attributors/all"
+package all
+
+import (
+	_ "github.com/scisci/hambidgetree/attributors/coloring"
+	_ "github.com/scisci/hambidgetree/attributors/edgepath"
+	_ "github.com/scisci/hambidgetree/attributors/neighbor"
+)
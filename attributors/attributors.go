@@ -6,6 +6,7 @@ import (
 )
 
 var ErrNotFound = errors.New("Not Found")
+var ErrFilterUnsupported = errors.New("Attributor can't be limited to some of the nodes")
 
 type ParameterFormatType int
 
@@ -20,3 +21,26 @@ type TreeAttributor interface {
 	Parameters(f ParameterFormatType) map[string]interface{}
 	AddAttributes(tree htree.Tree, attrs *NodeAttributer) error
 }
+
+// An attributor that can be limited to some of the leaves of a tree, it acts
+// as though the leaves include rejects aren't there. A nil include keeps every
+// leaf.
+type FilteredTreeAttributor interface {
+	TreeAttributor
+	AddFilteredAttributes(tree htree.Tree, attrs *NodeAttributer, include func(id htree.NodeID) bool) error
+}
+
+// Returns the nodes include accepts, or all of them if include is nil.
+func FilterNodes(nodes []htree.Node, include func(id htree.NodeID) bool) []htree.Node {
+	if include == nil {
+		return nodes
+	}
+
+	var filtered []htree.Node
+	for _, node := range nodes {
+		if include(node.ID()) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,254 @@
+package coloring
+
+import (
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/attributors"
+	"math/rand"
+)
+
+var ErrInvalidColors = errors.New("Number of colors can't be negative")
+var ErrNotColorable = errors.New("Leaves can't be colored with the number of colors")
+var ErrMaxStepsReached = errors.New("Max steps reached before a coloring was found")
+
+// The attribute holds the index of the leaf's color, from 0 to Colors - 1.
+var ColorIndexAttr = "colorIndex"
+
+const defaultMaxSteps = 100000
+
+// Colors the leaves so that no two touching leaves have the same color.
+type ColoringAttributor struct {
+	Colors        int  // The number of colors to use, 0 uses as few as DSatur finds
+	IgnoreCorners bool // Leaves that only touch at a corner or edge can share a color
+	Seed          int64
+	MaxSteps      int // Limits the backtracking search
+}
+
+func New(colors int, ignoreCorners bool, seed int64) *ColoringAttributor {
+	return &ColoringAttributor{
+		Colors:        colors,
+		IgnoreCorners: ignoreCorners,
+		Seed:          seed,
+		MaxSteps:      defaultMaxSteps,
+	}
+}
+
+func (attributor *ColoringAttributor) AddAttributes(tree htree.Tree, attrs *attributors.NodeAttributer) error {
+	return attributor.AddFilteredAttributes(tree, attrs, nil)
+}
+
+// Colors the included leaves, only touching included leaves need different
+// colors.
+func (attributor *ColoringAttributor) AddFilteredAttributes(tree htree.Tree, attrs *attributors.NodeAttributer, include func(id htree.NodeID) bool) error {
+	if attributor.Colors < 0 {
+		return ErrInvalidColors
+	}
+
+	graph := newGraph(tree, attributor.IgnoreCorners, include, rand.New(rand.NewSource(attributor.Seed)))
+
+	colors := graph.dsatur()
+	if attributor.Colors > 0 && numColors(colors) > attributor.Colors {
+		var err error
+		if colors, err = graph.backtrack(attributor.Colors, attributor.MaxSteps); err != nil {
+			return err
+		}
+	}
+
+	for v, id := range graph.ids {
+		attrs.SetValue(id, ColorIndexAttr, attributors.IntValue(int64(colors[v])))
+	}
+
+	return nil
+}
+
+// The leaves of a tree as vertices, each with the list of the vertices it
+// touches. The vertices are shuffled by the seed so ties are broken
+// differently for each seed but always the same way for a given seed.
+type graph struct {
+	ids       []htree.NodeID
+	neighbors [][]int
+}
+
+func newGraph(tree htree.Tree, ignoreCorners bool, include func(id htree.NodeID) bool, rnd *rand.Rand) *graph {
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	matrix := algo.BuildAdjacencyMatrix(tree, regionMap)
+
+	leaves := attributors.FilterNodes(algo.FindLeaves(tree), include)
+	rnd.Shuffle(len(leaves), func(i, j int) { leaves[i], leaves[j] = leaves[j], leaves[i] })
+
+	g := &graph{
+		ids:       make([]htree.NodeID, len(leaves)),
+		neighbors: make([][]int, len(leaves)),
+	}
+
+	vertices := make(map[htree.NodeID]int, len(leaves))
+	for v, leaf := range leaves {
+		g.ids[v] = leaf.ID()
+		vertices[leaf.ID()] = v
+	}
+
+	for v, id := range g.ids {
+		dim := regionMap[id].AlignedBox()
+		for _, neighbor := range matrix[id] {
+			w, ok := vertices[neighbor.ID()]
+			if !ok || (ignoreCorners && !sharesFace(dim, regionMap[neighbor.ID()].AlignedBox())) {
+				continue
+			}
+			g.neighbors[v] = append(g.neighbors[v], w)
+		}
+	}
+
+	return g
+}
+
+// Touching boxes share a face if they overlap on every axis but one, otherwise
+// they only meet at a corner, or an edge in 3D.
+func sharesFace(a, b *htree.AlignedBox) bool {
+	epsilon := 0.0000001
+
+	axes := 2
+	overlaps := 0
+	if overlap(a.Left(), a.Right(), b.Left(), b.Right()) > epsilon {
+		overlaps++
+	}
+	if overlap(a.Top(), a.Bottom(), b.Top(), b.Bottom()) > epsilon {
+		overlaps++
+	}
+	if a.Is3D() {
+		axes = 3
+		if overlap(a.Front(), a.Back(), b.Front(), b.Back()) > epsilon {
+			overlaps++
+		}
+	}
+
+	return overlaps >= axes-1
+}
+
+func overlap(start1, end1, start2, end2 float64) float64 {
+	start, end := start1, end1
+	if start2 > start {
+		start = start2
+	}
+	if end2 < end {
+		end = end2
+	}
+	return end - start
+}
+
+// Returns the number of colors used by a coloring.
+func numColors(colors []int) int {
+	n := 0
+	for _, c := range colors {
+		if c+1 > n {
+			n = c + 1
+		}
+	}
+	return n
+}
+
+// Greedily colors the vertex with the most differently colored neighbors,
+// breaking ties by the most neighbors, with the lowest color available.
+func (g *graph) dsatur() []int {
+	colors := make([]int, len(g.ids))
+	for v := range colors {
+		colors[v] = -1
+	}
+
+	for range g.ids {
+		v := g.next(colors)
+		colors[v] = lowestColor(g.usedColors(colors, v))
+	}
+
+	return colors
+}
+
+// Returns the uncolored vertex with the highest saturation, then degree, then
+// the first in the shuffled order, or -1 if every vertex is colored.
+func (g *graph) next(colors []int) int {
+	best := -1
+	bestSaturation := -1
+	for v := range g.ids {
+		if colors[v] >= 0 {
+			continue
+		}
+
+		saturation := len(g.usedColors(colors, v))
+		if saturation > bestSaturation ||
+			(saturation == bestSaturation && len(g.neighbors[v]) > len(g.neighbors[best])) {
+			best = v
+			bestSaturation = saturation
+		}
+	}
+	return best
+}
+
+// Returns the colors of the colored neighbors of a vertex.
+func (g *graph) usedColors(colors []int, v int) map[int]bool {
+	used := make(map[int]bool)
+	for _, neighbor := range g.neighbors[v] {
+		if colors[neighbor] >= 0 {
+			used[colors[neighbor]] = true
+		}
+	}
+	return used
+}
+
+func lowestColor(used map[int]bool) int {
+	c := 0
+	for used[c] {
+		c++
+	}
+	return c
+}
+
+// Searches for a coloring with k colors, choosing vertices in DSatur order.
+func (g *graph) backtrack(k int, maxSteps int) ([]int, error) {
+	colors := make([]int, len(g.ids))
+	for v := range colors {
+		colors[v] = -1
+	}
+
+	steps := 0
+	var search func() (bool, error)
+	search = func() (bool, error) {
+		v := g.next(colors)
+		if v < 0 {
+			return true, nil
+		}
+
+		used := g.usedColors(colors, v)
+		if len(used) >= k {
+			return false, nil
+		}
+
+		for c := 0; c < k; c++ {
+			if used[c] {
+				continue
+			}
+
+			steps++
+			if maxSteps > 0 && steps > maxSteps {
+				return false, ErrMaxStepsReached
+			}
+
+			colors[v] = c
+			if ok, err := search(); ok || err != nil {
+				return ok, err
+			}
+		}
+
+		colors[v] = -1
+		return false, nil
+	}
+
+	ok, err := search()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotColorable
+	}
+
+	return colors, nil
+}
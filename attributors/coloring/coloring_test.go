@@ -0,0 +1,126 @@
+package coloring_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/attributors/coloring"
+	"github.com/scisci/hambidgetree/generators/grid"
+	"github.com/scisci/hambidgetree/generators/randombasic"
+	"github.com/scisci/hambidgetree/golden"
+	"reflect"
+	"testing"
+)
+
+// Returns the color of every leaf, failing if two touching leaves share one.
+// Touching at a corner only counts if corners is true.
+func checkColoring(t *testing.T, tree htree.Tree, attrs *attributors.NodeAttributer, corners bool) map[htree.NodeID]int64 {
+	colors := make(map[htree.NodeID]int64)
+	for _, leaf := range algo.FindLeaves(tree) {
+		c, err := attrs.Int(leaf.ID(), coloring.ColorIndexAttr)
+		if err != nil {
+			t.Fatalf("Leaf %d has no color %v", leaf.ID(), err)
+		}
+		colors[leaf.ID()] = c
+	}
+
+	epsilon := 0.0000001
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	for id, neighbors := range algo.BuildAdjacencyMatrix(tree, regionMap) {
+		dim := regionMap[id].AlignedBox()
+		for _, neighbor := range neighbors {
+			other := regionMap[neighbor.ID()].AlignedBox()
+			corner := (dim.Right() < other.Left()+epsilon || other.Right() < dim.Left()+epsilon) &&
+				(dim.Bottom() < other.Top()+epsilon || other.Bottom() < dim.Top()+epsilon)
+			if corner && !corners {
+				continue
+			}
+			if colors[id] == colors[neighbor.ID()] {
+				t.Errorf("Leaves %d and %d touch and share color %d", id, neighbor.ID(), colors[id])
+			}
+		}
+	}
+
+	return colors
+}
+
+func numColors(colors map[htree.NodeID]int64) int {
+	used := make(map[int64]bool)
+	for _, c := range colors {
+		used[c] = true
+	}
+	return len(used)
+}
+
+func TestColoringGrid(t *testing.T) {
+	tree := grid.New2D(3)
+
+	// Every 2x2 block touches at the center so needs 4 colors
+	attrs := attributors.NewNodeAttributer()
+	if err := coloring.New(0, false, 1).AddAttributes(tree, attrs); err != nil {
+		t.Fatalf("Failed to color %v", err)
+	}
+	if n := numColors(checkColoring(t, tree, attrs, true)); n != 4 {
+		t.Errorf("Expected 4 colors, got %d", n)
+	}
+
+	if err := coloring.New(3, false, 1).AddAttributes(tree, attributors.NewNodeAttributer()); err != coloring.ErrNotColorable {
+		t.Errorf("Expected 3 colors to fail, got %v", err)
+	}
+
+	// Without corners the grid is a checkerboard
+	attrs = attributors.NewNodeAttributer()
+	if err := coloring.New(2, true, 1).AddAttributes(tree, attrs); err != nil {
+		t.Fatalf("Failed to color %v", err)
+	}
+	if n := numColors(checkColoring(t, tree, attrs, false)); n != 2 {
+		t.Errorf("Expected 2 colors, got %d", n)
+	}
+}
+
+func TestColoringRandom(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		gen, err := randombasic.New(golden.RatioSource(), 1, 40, seed)
+		if err != nil {
+			t.Fatalf("Failed to create generator %v", err)
+		}
+
+		tree, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate tree %v", err)
+		}
+
+		// Leaves touching along edges form a planar graph so 4 colors are
+		// always enough
+		attrs := attributors.NewNodeAttributer()
+		if err := coloring.New(4, true, seed).AddAttributes(tree, attrs); err != nil {
+			t.Fatalf("Seed %d failed to color %v", seed, err)
+		}
+		colors := checkColoring(t, tree, attrs, false)
+		if n := numColors(colors); n > 4 {
+			t.Errorf("Seed %d expected at most 4 colors, got %d", seed, n)
+		}
+
+		// The same seed always gives the same coloring
+		again := attributors.NewNodeAttributer()
+		if err := coloring.New(4, true, seed).AddAttributes(tree, again); err != nil {
+			t.Fatalf("Seed %d failed to color %v", seed, err)
+		}
+		if !reflect.DeepEqual(checkColoring(t, tree, again, false), colors) {
+			t.Errorf("Seed %d colored differently the second time", seed)
+		}
+	}
+}
+
+func TestColoringErrors(t *testing.T) {
+	tree := grid.New2D(3)
+	if err := coloring.New(-1, false, 1).AddAttributes(tree, attributors.NewNodeAttributer()); err != coloring.ErrInvalidColors {
+		t.Errorf("Expected invalid colors, got %v", err)
+	}
+
+	attributor := coloring.New(3, false, 1)
+	attributor.MaxSteps = 1
+	if err := attributor.AddAttributes(tree, attributors.NewNodeAttributer()); err != coloring.ErrMaxStepsReached {
+		t.Errorf("Expected max steps, got %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package coloring
+
+import (
+	"github.com/scisci/hambidgetree/attributors"
+)
+
+func (attributor *ColoringAttributor) Name() string {
+	return "Coloring"
+}
+
+func (attributor *ColoringAttributor) Description() string {
+	return "This attributor colors the leaves so that no two touching leaves " +
+		"share a color, and marks each leaf with the index of its color."
+}
+
+func (attributor *ColoringAttributor) Parameters(f attributors.ParameterFormatType) map[string]interface{} {
+	return map[string]interface{}{
+		"Colors":         attributor.Colors,
+		"Ignore Corners": attributor.IgnoreCorners,
+		"Seed":           attributor.Seed,
+		"Max Steps":      attributor.MaxSteps,
+	}
+}
@@ -0,0 +1,41 @@
+package coloring
+
+import (
+	"github.com/scisci/hambidgetree/attributors"
+)
+
+// The parameters of a ColoringAttributor in a spec.
+type Params struct {
+	Colors        int   `json:"colors"`
+	IgnoreCorners bool  `json:"ignoreCorners"`
+	Seed          int64 `json:"seed"`
+	MaxSteps      int   `json:"maxSteps"`
+}
+
+func init() {
+	attributors.Register(&attributors.Registration{
+		Name: "Coloring",
+		Defaults: func() interface{} {
+			return &Params{Colors: 4, MaxSteps: defaultMaxSteps}
+		},
+		New: func(params interface{}) (attributors.TreeAttributor, error) {
+			p, ok := params.(*Params)
+			if !ok {
+				return nil, attributors.ErrInvalidParams
+			}
+			if p.Colors < 0 {
+				return nil, ErrInvalidColors
+			}
+			attributor := New(p.Colors, p.IgnoreCorners, p.Seed)
+			attributor.MaxSteps = p.MaxSteps
+			return attributor, nil
+		},
+		Params: func(attributor attributors.TreeAttributor) (interface{}, error) {
+			a, ok := attributor.(*ColoringAttributor)
+			if !ok {
+				return nil, attributors.ErrInvalidParams
+			}
+			return &Params{Colors: a.Colors, IgnoreCorners: a.IgnoreCorners, Seed: a.Seed, MaxSteps: a.MaxSteps}, nil
+		},
+	})
+}
@@ -2,7 +2,6 @@ package edgepath
 
 import (
 	"bytes"
-	"fmt"
 	htree "github.com/scisci/hambidgetree"
 	"github.com/scisci/hambidgetree/algo"
 	"github.com/scisci/hambidgetree/attributors"
@@ -70,7 +69,6 @@ func (name EdgeName) AlignedBox3D() *htree.AlignedBox {
 	}
 
 	panic("unknown edge name!")
-	return nil
 }
 
 type EdgePath struct {
@@ -125,6 +123,8 @@ type EdgePathAttributor struct {
 
 const edgePathMaxChaos = 100
 
+const edgePathTieBreak = 0.000001
+
 func New(paths []EdgePath, seed int64, chaos float64) *EdgePathAttributor {
 	return &EdgePathAttributor{
 		Paths:    paths,
@@ -135,14 +135,19 @@ func New(paths []EdgePath, seed int64, chaos float64) *EdgePathAttributor {
 }
 
 func (attributor *EdgePathAttributor) AddAttributes(tree htree.Tree, attrs *attributors.NodeAttributer) error {
-	rand.Seed(attributor.Seed)
+	return attributor.AddFilteredAttributes(tree, attrs, nil)
+}
+
+// Finds paths that only cross the included leaves, a path is left out if its
+// ends can't be joined by them.
+func (attributor *EdgePathAttributor) AddFilteredAttributes(tree htree.Tree, attrs *attributors.NodeAttributer, include func(id htree.NodeID) bool) error {
+	rnd := rand.New(rand.NewSource(attributor.Seed))
 	//epsilon := 0.0000001
 
 	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
 	matrix := algo.BuildAdjacencyMatrix(tree, regionMap)
 
 	numLeaves := len(matrix)
-	fmt.Printf("working with %d leaves\n", numLeaves)
 
 	maxID := int64(0)
 	for leafID, _ := range matrix {
@@ -158,27 +163,39 @@ func (attributor *EdgePathAttributor) AddAttributes(tree htree.Tree, attrs *attr
 	// Value 0 to 1, 0 is a shortest path (distance 1) 1, is complete noise (distance is rand * numleaves)
 	chaos := stepped.StepsToValue(attributor.Chaos, attributor.MaxChaos)
 
-	for leafID, neighbors := range matrix {
+	// Visit the leaves in tree order rather than map order so the weights are
+	// the same for every run with a seed
+	for _, leaf := range attributors.FilterNodes(algo.FindLeaves(tree), include) {
+		leafID := leaf.ID()
+		neighbors := matrix[leafID]
 		//fmt.Printf("leaf %d has %d neighbors\n", leafID, len(neighbors))
 
 		for _, neighbor := range neighbors {
+			if include != nil && !include(neighbor.ID()) {
+				continue
+			}
 			if graph.HasEdgeBetween(simple.Node(leafID), simple.Node(neighbor.ID())) {
 				continue
 			}
 
-			randWeight := 1 + chaos*rand.Float64()*float64(numLeaves)
+			randWeight := 1 + chaos*rnd.Float64()*float64(numLeaves)
+			if chaos == 0 {
+				// Without chaos every step weighs the same, a tiny bit of noise
+				// leaves only one shortest path, otherwise the path found would
+				// depend on the order the graph is searched
+				randWeight = 1 + edgePathTieBreak*rnd.Float64()
+			}
 			graph.SetWeightedEdge(&simple.WeightedEdge{F: simple.Node(leafID), T: simple.Node(neighbor.ID()), W: randWeight})
 			//fmt.Printf("%d to %d = %f\n", leafID, neighbor.ID(), randWeight)
 
 		}
+	}
 
-		// Also need to check this neighbor against the edges
-		dim := regionMap[leafID].AlignedBox()
-
-		for _, e := range edges {
-			if e.dim.DistanceSquared(dim) < 0.0000001 {
-				e.neighbors = append(e.neighbors, leafID)
-			}
+	// Find the leaves touching each edge
+	index := algo.NewSpatialIndex(tree, regionMap)
+	for _, e := range edges {
+		for _, leaf := range attributors.FilterNodes(index.Within(e.dim, 0.0000001), include) {
+			e.neighbors = append(e.neighbors, leaf.ID())
 		}
 	}
 
@@ -186,21 +203,24 @@ func (attributor *EdgePathAttributor) AddAttributes(tree htree.Tree, attrs *attr
 		fromNode := int64(-1)
 		toNode := int64(-1)
 		for _, edge := range edges {
+			if len(edge.neighbors) == 0 {
+				continue
+			}
 			if edge.name == path.From {
 				// Choose a random neighbor
-				fromNode = int64(edge.neighbors[rand.Intn(len(edge.neighbors))])
+				fromNode = int64(edge.neighbors[rnd.Intn(len(edge.neighbors))])
 			}
 			if edge.name == path.To {
-				toNode = int64(edge.neighbors[rand.Intn(len(edge.neighbors))])
+				toNode = int64(edge.neighbors[rnd.Intn(len(edge.neighbors))])
 			}
 		}
 		if fromNode < 0 || toNode < 0 {
-			panic("path ends not found")
+			// None of the included leaves touch one of the edges
+			continue
 		}
 
 		shortest := gpath.DijkstraFrom(simple.Node(fromNode), graph)
-		p, w := shortest.To(simple.Node(toNode))
-		fmt.Printf("path weight %f\n", w)
+		p, _ := shortest.To(simple.Node(toNode))
 
 		for _, graphNode := range p {
 			attrs.SetAttribute(htree.NodeID(graphNode.ID()), OnPathAttr, OnPathValue)
@@ -0,0 +1,121 @@
+package edgepath_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/attributors/edgepath"
+	"github.com/scisci/hambidgetree/generators/randombasic"
+	"github.com/scisci/hambidgetree/golden"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAddAttributesConcurrent(t *testing.T) {
+	gen, err := randombasic.New(golden.RatioSource(), 1, 40, 1)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	tree, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate tree %v", err)
+	}
+
+	leaves := algo.FindLeaves(tree)
+	paths := []edgepath.EdgePath{
+		{From: edgepath.EdgeNameLeft, To: edgepath.EdgeNameRight},
+		{From: edgepath.EdgeNameTop, To: edgepath.EdgeNameBottom},
+	}
+	numRuns := 32
+
+	attribute := func(seed int64) *attributors.NodeAttributer {
+		attrs := attributors.NewNodeAttributer()
+		// Without chaos every step weighs about the same
+		if err := edgepath.New(paths, seed, float64(seed%2)*0.5).AddAttributes(tree, attrs); err != nil {
+			t.Errorf("Failed to add attributes %v", err)
+		}
+		return attrs
+	}
+
+	expected := make([]*attributors.NodeAttributer, numRuns)
+	for i := range expected {
+		expected[i] = attribute(int64(i))
+	}
+
+	// Each seed must mark the same path no matter what else is running
+	results := make([]*attributors.NodeAttributer, numRuns)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = attribute(int64(i))
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		marked := 0
+		for _, leaf := range leaves {
+			attrs := results[i].Attributes(leaf.ID())
+			if !reflect.DeepEqual(attrs, expected[i].Attributes(leaf.ID())) {
+				t.Errorf("Seed %d marked leaf %d differently when run concurrently", i, leaf.ID())
+			}
+			if attrs[edgepath.OnPathAttr] == edgepath.OnPathValue {
+				marked++
+			}
+		}
+
+		if marked == 0 {
+			t.Errorf("Seed %d didn't mark a path", i)
+		}
+	}
+}
+
+func TestAddAttributesGolden(t *testing.T) {
+	gen, err := randombasic.New(golden.RatioSource(), 1, 40, 1)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	tree, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate tree %v", err)
+	}
+
+	paths := []edgepath.EdgePath{
+		{From: edgepath.EdgeNameLeft, To: edgepath.EdgeNameRight},
+		{From: edgepath.EdgeNameTop, To: edgepath.EdgeNameBottom},
+	}
+
+	// The leaves on the paths, in tree order, must not change for a seed
+	tests := []struct {
+		seed     int64
+		chaos    float64
+		expected []htree.NodeID
+	}{
+		{7, 0.5, []htree.NodeID{57, 74, 37, 35, 60, 51, 11, 41, 39, 58, 28, 78, 79}},
+		{3, 0, []htree.NodeID{57, 44, 72, 51, 40, 58, 59, 28, 78, 79, 47}},
+		{5, 1, []htree.NodeID{44, 45, 74, 75, 72, 66, 67, 55, 60, 51, 40, 59, 28}},
+	}
+
+	for _, test := range tests {
+		attrs := attributors.NewNodeAttributer()
+		if err := edgepath.New(paths, test.seed, test.chaos).AddAttributes(tree, attrs); err != nil {
+			t.Fatalf("Failed to add attributes %v", err)
+		}
+
+		var onPath []htree.NodeID
+		for _, leaf := range algo.FindLeaves(tree) {
+			if value, _ := attrs.Attribute(leaf.ID(), edgepath.OnPathAttr); value == edgepath.OnPathValue {
+				onPath = append(onPath, leaf.ID())
+			}
+		}
+
+		if !reflect.DeepEqual(onPath, test.expected) {
+			t.Errorf("Seed %d with chaos %v expected path %v, got %v", test.seed, test.chaos, test.expected, onPath)
+		}
+	}
+}
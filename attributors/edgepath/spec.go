@@ -0,0 +1,53 @@
+package edgepath
+
+import (
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/stepped"
+)
+
+// The parameters of an EdgePathAttributor in a spec. Chaos is from 0 to 1 and
+// is rounded to one of MaxChaos steps.
+type Params struct {
+	Paths    []EdgePath `json:"paths"`
+	Seed     int64      `json:"seed"`
+	Chaos    float64    `json:"chaos"`
+	MaxChaos int        `json:"maxChaos"`
+}
+
+func init() {
+	attributors.Register(&attributors.Registration{
+		Name: "EdgePath",
+		Defaults: func() interface{} {
+			return &Params{
+				Paths:    []EdgePath{{From: EdgeNameLeft, To: EdgeNameRight}},
+				MaxChaos: edgePathMaxChaos,
+			}
+		},
+		New: func(params interface{}) (attributors.TreeAttributor, error) {
+			p, ok := params.(*Params)
+			if !ok {
+				return nil, attributors.ErrInvalidParams
+			}
+
+			attributor := New(p.Paths, p.Seed, p.Chaos)
+			if p.MaxChaos > 0 {
+				attributor.MaxChaos = p.MaxChaos
+				attributor.Chaos = stepped.ValueToSteps(p.Chaos, p.MaxChaos)
+			}
+			return attributor, nil
+		},
+		Params: func(attributor attributors.TreeAttributor) (interface{}, error) {
+			a, ok := attributor.(*EdgePathAttributor)
+			if !ok {
+				return nil, attributors.ErrInvalidParams
+			}
+
+			return &Params{
+				Paths:    a.Paths,
+				Seed:     a.Seed,
+				Chaos:    stepped.StepsToValue(a.Chaos, a.MaxChaos),
+				MaxChaos: a.MaxChaos,
+			}, nil
+		},
+	})
+}
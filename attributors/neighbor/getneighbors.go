@@ -2,19 +2,20 @@ package neighbor
 
 import (
 	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
 )
 
 func getNeighbors(leaves []htree.Node, regionMap htree.RegionMap, epsilon float64) []htree.Node {
+	index := algo.NewLeafSpatialIndex(leaves, regionMap)
+
 	var candidates []htree.Node
 	for i := 0; i < len(leaves); i++ {
 		hasNeighbor := false
 		dim := regionMap[leaves[i].ID()].AlignedBox()
 
-		for j := 0; j < len(leaves); j++ {
-			if j == i {
-				continue
-			}
-			dim2 := regionMap[leaves[j].ID()].AlignedBox()
+		// Only the leaves touching this one can be beside it
+		for _, other := range index.Neighbors(leaves[i], regionMap, epsilon) {
+			dim2 := regionMap[other.ID()].AlignedBox()
 
 			leftExtent := dim.IntersectLeft(dim2, epsilon)
 			rightExtent := dim.IntersectRight(dim2, epsilon)
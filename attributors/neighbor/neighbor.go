@@ -25,17 +25,21 @@ func NewHasNeighborAttributor(maxMarks int, dimension int, seed int64) *HasNeigh
 }
 
 func (attributor *HasNeighborAttributor) AddAttributes(tree htree.Tree, attrs *attributors.NodeAttributer) error {
-	rand.Seed(attributor.Seed)
+	return attributor.AddFilteredAttributes(tree, attrs, nil)
+}
+
+// Marks up to MaxMarks of the included leaves, only included leaves count as
+// neighbors.
+func (attributor *HasNeighborAttributor) AddFilteredAttributes(tree htree.Tree, attrs *attributors.NodeAttributer, include func(id htree.NodeID) bool) error {
+	rnd := rand.New(rand.NewSource(attributor.Seed))
 	epsilon := 0.0000001
 
 	// Get a list of all the nodes
-	leaves := algo.FindLeaves(tree)
+	leaves := attributors.FilterNodes(algo.FindLeaves(tree), include)
 
 	// Get the dimension list
 	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
 
-	// Naive approach, just compare each leaf to each other leaf, could do better
-	// with some sorting
 	for count := 0; count < attributor.MaxMarks; count++ {
 		// Find all the remaining leaves that still have neighbors
 		leaves = getNeighbors(leaves, regionMap, epsilon)
@@ -43,7 +47,7 @@ func (attributor *HasNeighborAttributor) AddAttributes(tree htree.Tree, attrs *a
 			break
 		}
 
-		randomIndex := rand.Intn(len(leaves))
+		randomIndex := rnd.Intn(len(leaves))
 		attrs.SetAttribute(leaves[randomIndex].ID(), HasNeighborAttr, HasNeighborValue)
 		leaves = append(leaves[:randomIndex], leaves[randomIndex+1:]...)
 	}
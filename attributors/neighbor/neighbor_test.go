@@ -3,8 +3,11 @@ package neighbor
 import (
 	htree "github.com/scisci/hambidgetree"
 	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/attributors"
 	"github.com/scisci/hambidgetree/generators/grid"
+	"reflect"
 	"sort"
+	"sync"
 	"testing"
 )
 
@@ -52,3 +55,41 @@ func TestGetNeighbors(t *testing.T) {
 	}
 
 }
+
+func TestAddAttributesConcurrent(t *testing.T) {
+	tree := grid.New2D(6)
+	leaves := algo.FindLeaves(tree)
+	numRuns := 32
+
+	attribute := func(seed int64) *attributors.NodeAttributer {
+		attrs := attributors.NewNodeAttributer()
+		if err := NewHasNeighborAttributor(10, 2, seed).AddAttributes(tree, attrs); err != nil {
+			t.Errorf("Error adding attributes %v", err)
+		}
+		return attrs
+	}
+
+	expected := make([]*attributors.NodeAttributer, numRuns)
+	for i := range expected {
+		expected[i] = attribute(int64(i))
+	}
+
+	results := make([]*attributors.NodeAttributer, numRuns)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = attribute(int64(i))
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		for _, leaf := range leaves {
+			if !reflect.DeepEqual(results[i].Attributes(leaf.ID()), expected[i].Attributes(leaf.ID())) {
+				t.Errorf("Seed %d marked leaf %d differently when run concurrently", i, leaf.ID())
+			}
+		}
+	}
+}
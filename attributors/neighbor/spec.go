@@ -0,0 +1,36 @@
+package neighbor
+
+import (
+	"github.com/scisci/hambidgetree/attributors"
+)
+
+// The parameters of a HasNeighborAttributor in a spec.
+type Params struct {
+	MaxMarks  int   `json:"maxMarks"`
+	Dimension int   `json:"dimension"`
+	Seed      int64 `json:"seed"`
+}
+
+func init() {
+	attributors.Register(&attributors.Registration{
+		Name: "HasNeighbor",
+		Defaults: func() interface{} {
+			return &Params{MaxMarks: 10, Dimension: 2}
+		},
+		New: func(params interface{}) (attributors.TreeAttributor, error) {
+			p, ok := params.(*Params)
+			if !ok {
+				return nil, attributors.ErrInvalidParams
+			}
+			return NewHasNeighborAttributor(p.MaxMarks, p.Dimension, p.Seed), nil
+		},
+		Params: func(attributor attributors.TreeAttributor) (interface{}, error) {
+			a, ok := attributor.(*HasNeighborAttributor)
+			if !ok {
+				return nil, attributors.ErrInvalidParams
+			}
+			return &Params{MaxMarks: a.MaxMarks, Dimension: a.Dimension, Seed: a.Seed}, nil
+		},
+		Aliases: []string{"neighbor"},
+	})
+}
@@ -1,7 +1,10 @@
 package attributors
 
 import (
+	"encoding/json"
 	htree "github.com/scisci/hambidgetree"
+	"image/color"
+	"sort"
 )
 
 type NodeAttributes interface {
@@ -9,35 +12,235 @@ type NodeAttributes interface {
 }
 
 type NodeAttributer struct {
-	attrs map[htree.NodeID]map[string]string
+	attrs map[htree.NodeID]map[string]Value
 }
 
 func NewNodeAttributer() *NodeAttributer {
 	return &NodeAttributer{
-		attrs: make(map[htree.NodeID]map[string]string),
+		attrs: make(map[htree.NodeID]map[string]Value),
 	}
 }
 
+// Sets the attribute to a string value.
 func (attributer *NodeAttributer) SetAttribute(id htree.NodeID, key, value string) {
+	attributer.SetValue(id, key, StringValue(value))
+}
+
+func (attributer *NodeAttributer) SetValue(id htree.NodeID, key string, value Value) {
 	attrs, ok := attributer.attrs[id]
 	if !ok {
-		attrs = make(map[string]string)
+		attrs = make(map[string]Value)
 		attributer.attrs[id] = attrs
 	}
 
 	attrs[key] = value
 }
 
-func (attributer *NodeAttributer) Attribute(id htree.NodeID, key string) (string, error) {
+func (attributer *NodeAttributer) Value(id htree.NodeID, key string) (Value, error) {
 	attrs, ok := attributer.attrs[id]
 	if !ok {
-		return "", ErrNotFound
+		return Value{}, ErrNotFound
 	}
 
 	value, ok := attrs[key]
 	if !ok {
-		return "", ErrNotFound
+		return Value{}, ErrNotFound
 	}
 
 	return value, nil
 }
+
+// Returns the attribute as a string whatever its type.
+func (attributer *NodeAttributer) Attribute(id htree.NodeID, key string) (string, error) {
+	value, err := attributer.Value(id, key)
+	if err != nil {
+		return "", err
+	}
+
+	return value.String(), nil
+}
+
+// The typed getters return ErrNotFound if the node doesn't have the attribute
+// and ErrWrongType if it can't be read as the type.
+
+func (attributer *NodeAttributer) Bool(id htree.NodeID, key string) (bool, error) {
+	value, err := attributer.Value(id, key)
+	if err != nil {
+		return false, err
+	}
+	return value.Bool()
+}
+
+func (attributer *NodeAttributer) Int(id htree.NodeID, key string) (int64, error) {
+	value, err := attributer.Value(id, key)
+	if err != nil {
+		return 0, err
+	}
+	return value.Int()
+}
+
+func (attributer *NodeAttributer) Float(id htree.NodeID, key string) (float64, error) {
+	value, err := attributer.Value(id, key)
+	if err != nil {
+		return 0, err
+	}
+	return value.Float()
+}
+
+func (attributer *NodeAttributer) Color(id htree.NodeID, key string) (color.Color, error) {
+	value, err := attributer.Value(id, key)
+	if err != nil {
+		return nil, err
+	}
+	return value.Color()
+}
+
+func (attributer *NodeAttributer) NodeRef(id htree.NodeID, key string) (htree.NodeID, error) {
+	value, err := attributer.Value(id, key)
+	if err != nil {
+		return 0, err
+	}
+	return value.Node()
+}
+
+func (attributer *NodeAttributer) List(id htree.NodeID, key string) ([]Value, error) {
+	value, err := attributer.Value(id, key)
+	if err != nil {
+		return nil, err
+	}
+	return value.List()
+}
+
+// Returns a copy of all of the attributes of a node as strings, or nil if it
+// has none.
+func (attributer *NodeAttributer) Attributes(id htree.NodeID) map[string]string {
+	attrs, ok := attributer.attrs[id]
+	if !ok || len(attrs) == 0 {
+		return nil
+	}
+
+	copied := make(map[string]string, len(attrs))
+	for key, value := range attrs {
+		copied[key] = value.String()
+	}
+
+	return copied
+}
+
+// Returns a copy of all of the attributes of a node, or nil if it has none.
+func (attributer *NodeAttributer) Values(id htree.NodeID) map[string]Value {
+	attrs, ok := attributer.attrs[id]
+	if !ok || len(attrs) == 0 {
+		return nil
+	}
+
+	copied := make(map[string]Value, len(attrs))
+	for key, value := range attrs {
+		copied[key] = value
+	}
+
+	return copied
+}
+
+// Removes an attribute of a node, returns false if it didn't have it.
+func (attributer *NodeAttributer) Remove(id htree.NodeID, key string) bool {
+	attrs, ok := attributer.attrs[id]
+	if !ok {
+		return false
+	}
+
+	if _, ok := attrs[key]; !ok {
+		return false
+	}
+
+	delete(attrs, key)
+	if len(attrs) == 0 {
+		delete(attributer.attrs, id)
+	}
+	return true
+}
+
+// Removes all of the attributes of a node.
+func (attributer *NodeAttributer) RemoveNode(id htree.NodeID) {
+	delete(attributer.attrs, id)
+}
+
+// Copies every attribute of other, replacing any with the same node and key.
+func (attributer *NodeAttributer) Merge(other *NodeAttributer) {
+	for id, attrs := range other.attrs {
+		for key, value := range attrs {
+			attributer.SetValue(id, key, value)
+		}
+	}
+}
+
+// Returns the ids of the nodes that have attributes, in ascending order.
+func (attributer *NodeAttributer) Nodes() []htree.NodeID {
+	ids := make([]htree.NodeID, 0, len(attributer.attrs))
+	for id, attrs := range attributer.attrs {
+		if len(attrs) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sortNodeIDs(ids)
+	return ids
+}
+
+// Returns every key used by any node, sorted alphabetically.
+func (attributer *NodeAttributer) Keys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, attrs := range attributer.attrs {
+		for key := range attrs {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns the keys of the attributes of a node, sorted alphabetically.
+func (attributer *NodeAttributer) NodeKeys(id htree.NodeID) []string {
+	attrs := attributer.attrs[id]
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns the ids of the nodes that have the attribute, in ascending order.
+func (attributer *NodeAttributer) KeyNodes(key string) []htree.NodeID {
+	var ids []htree.NodeID
+	for id, attrs := range attributer.attrs {
+		if _, ok := attrs[key]; ok {
+			ids = append(ids, id)
+		}
+	}
+	sortNodeIDs(ids)
+	return ids
+}
+
+func sortNodeIDs(ids []htree.NodeID) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
+
+// Attributes are encoded as an object keyed by node id, each holding an
+// object of the node's typed values.
+func (attributer *NodeAttributer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(attributer.attrs)
+}
+
+func (attributer *NodeAttributer) UnmarshalJSON(data []byte) error {
+	attrs := make(map[htree.NodeID]map[string]Value)
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return err
+	}
+
+	attributer.attrs = attrs
+	return nil
+}
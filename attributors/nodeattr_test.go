@@ -0,0 +1,164 @@
+package attributors_test
+
+import (
+	"encoding/json"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/attributors"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestValueJSON(t *testing.T) {
+	values := []attributors.Value{
+		attributors.StringValue("12"),
+		attributors.BoolValue(true),
+		attributors.IntValue(-4),
+		attributors.FloatValue(0.25),
+		attributors.ColorValue(color.NRGBA{0xff, 0x80, 0x00, 0x40}),
+		attributors.NodeValue(7),
+		attributors.ListValue(attributors.NodeValue(3), attributors.StringValue("a")),
+	}
+
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("Failed to marshal %v %v", value.Type(), err)
+		}
+
+		var decoded attributors.Value
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Failed to unmarshal %s %v", data, err)
+		}
+
+		if !decoded.Equal(value) {
+			t.Errorf("Expected %s to decode as %v %v, got %v %v", data, value.Type(), value, decoded.Type(), decoded)
+		}
+	}
+
+	var value attributors.Value
+	for _, data := range []string{`{}`, `{"date":"today"}`, `{"int":1,"bool":true}`, `{"color":"red"}`} {
+		if err := json.Unmarshal([]byte(data), &value); err == nil {
+			t.Errorf("Expected %s to fail", data)
+		}
+	}
+}
+
+func TestValueGetters(t *testing.T) {
+	// Strings can be read as any type they parse as
+	if b, err := attributors.StringValue("true").Bool(); err != nil || !b {
+		t.Errorf("Expected string to read as bool, got %v %v", b, err)
+	}
+
+	if f, err := attributors.IntValue(3).Float(); err != nil || f != 3 {
+		t.Errorf("Expected int to read as float, got %v %v", f, err)
+	}
+
+	if _, err := attributors.FloatValue(3).Int(); err != attributors.ErrWrongType {
+		t.Errorf("Expected float not to read as int, got %v", err)
+	}
+
+	if _, err := attributors.StringValue("#12").Color(); err != attributors.ErrWrongType {
+		t.Errorf("Expected invalid color to fail, got %v", err)
+	}
+
+	c, err := attributors.StringValue("#f00").Color()
+	if err != nil || attributors.FormatColor(c) != "#ff0000" {
+		t.Errorf("Expected red, got %v %v", c, err)
+	}
+
+	list := attributors.ListValue(attributors.IntValue(1), attributors.BoolValue(false))
+	if list.String() != "1,false" {
+		t.Errorf("Expected list string 1,false, got %s", list.String())
+	}
+}
+
+func TestNodeAttributer(t *testing.T) {
+	attrs := attributors.NewNodeAttributer()
+	attrs.SetValue(1, "visible", attributors.BoolValue(true))
+	attrs.SetValue(1, "next", attributors.NodeValue(2))
+	attrs.SetValue(2, "visible", attributors.BoolValue(false))
+	attrs.SetAttribute(3, "name", "three")
+
+	if visible, err := attrs.Bool(2, "visible"); err != nil || visible {
+		t.Errorf("Expected node 2 not visible, got %v %v", visible, err)
+	}
+
+	if next, err := attrs.NodeRef(1, "next"); err != nil || next != 2 {
+		t.Errorf("Expected node 1 to refer to 2, got %v %v", next, err)
+	}
+
+	if _, err := attrs.Int(1, "visible"); err != attributors.ErrWrongType {
+		t.Errorf("Expected wrong type, got %v", err)
+	}
+
+	if _, err := attrs.Bool(4, "visible"); err != attributors.ErrNotFound {
+		t.Errorf("Expected not found, got %v", err)
+	}
+
+	if value, _ := attrs.Attribute(1, "visible"); value != "true" {
+		t.Errorf("Expected string true, got %s", value)
+	}
+
+	if keys := attrs.NodeKeys(1); !reflect.DeepEqual(keys, []string{"next", "visible"}) {
+		t.Errorf("Expected keys of node 1, got %v", keys)
+	}
+
+	if nodes := attrs.KeyNodes("visible"); !reflect.DeepEqual(nodes, []htree.NodeID{1, 2}) {
+		t.Errorf("Expected nodes with visible, got %v", nodes)
+	}
+
+	// Round trip keeps the types
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		t.Fatalf("Failed to marshal attributes %v", err)
+	}
+
+	decoded := attributors.NewNodeAttributer()
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Failed to unmarshal attributes %v", err)
+	}
+
+	for _, id := range attrs.Nodes() {
+		if !reflect.DeepEqual(decoded.Values(id), attrs.Values(id)) {
+			t.Errorf("Node %d expected %v, got %v", id, attrs.Values(id), decoded.Values(id))
+		}
+	}
+
+	if !attrs.Remove(3, "name") || attrs.Remove(3, "name") {
+		t.Errorf("Expected remove to succeed once")
+	}
+
+	if nodes := attrs.Nodes(); !reflect.DeepEqual(nodes, []htree.NodeID{1, 2}) {
+		t.Errorf("Expected node 3 to have no attributes, got %v", nodes)
+	}
+
+	attrs.RemoveNode(1)
+	if attrs.Values(1) != nil {
+		t.Errorf("Expected node 1 to be removed")
+	}
+}
+
+func TestNodeAttributerMerge(t *testing.T) {
+	a := attributors.NewNodeAttributer()
+	a.SetValue(1, "size", attributors.IntValue(1))
+	a.SetValue(1, "kept", attributors.IntValue(1))
+
+	b := attributors.NewNodeAttributer()
+	b.SetValue(1, "size", attributors.IntValue(2))
+	b.SetValue(2, "size", attributors.IntValue(3))
+
+	a.Merge(b)
+
+	if size, _ := a.Int(1, "size"); size != 2 {
+		t.Errorf("Expected merged value to win, got %d", size)
+	}
+
+	if kept, _ := a.Int(1, "kept"); kept != 1 {
+		t.Errorf("Expected existing value to be kept, got %d", kept)
+	}
+
+	if size, _ := a.Int(2, "size"); size != 3 {
+		t.Errorf("Expected new node to be added, got %d", size)
+	}
+}
@@ -0,0 +1,224 @@
+package attributors
+
+import (
+	"encoding/json"
+	htree "github.com/scisci/hambidgetree"
+)
+
+// A test of the attributes a node has been given by earlier stages of a
+// pipeline. An empty value matches any value.
+type Condition struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	Not   bool   `json:"not,omitempty"`
+}
+
+func (condition Condition) matches(attrs *NodeAttributer, id htree.NodeID) bool {
+	value, err := attrs.Attribute(id, condition.Key)
+	matched := err == nil && (condition.Value == "" || condition.Value == value)
+	return matched != condition.Not
+}
+
+// One step of a pipeline. If there are conditions the attributor only sees
+// the leaves matching all of them, so it has to be a FilteredTreeAttributor.
+// The conditions are tested against the attributes from before the stage.
+type Stage struct {
+	Attributor TreeAttributor
+	Where      []Condition
+}
+
+// Runs attributors in order over a tree, each adding to the same attributes.
+// A pipeline is an attributor itself so pipelines can be nested.
+type Pipeline struct {
+	Stages []*Stage
+}
+
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// Appends a stage to the pipeline, returns the pipeline so calls can be
+// chained.
+func (pipeline *Pipeline) Add(attributor TreeAttributor, where ...Condition) *Pipeline {
+	pipeline.Stages = append(pipeline.Stages, &Stage{
+		Attributor: attributor,
+		Where:      where,
+	})
+	return pipeline
+}
+
+func (pipeline *Pipeline) Name() string {
+	return "Pipeline"
+}
+
+func (pipeline *Pipeline) Description() string {
+	return "This attributor runs other attributors in order, later ones can be limited to nodes marked by earlier ones."
+}
+
+func (pipeline *Pipeline) Parameters(f ParameterFormatType) map[string]interface{} {
+	stages := make([]interface{}, len(pipeline.Stages))
+	for i, stage := range pipeline.Stages {
+		if f == ParameterFormatTypeConcise {
+			stages[i] = stage.Attributor.Name()
+			continue
+		}
+		stages[i] = map[string]interface{}{
+			"Name":       stage.Attributor.Name(),
+			"Parameters": stage.Attributor.Parameters(f),
+			"Where":      stage.Where,
+		}
+	}
+
+	return map[string]interface{}{
+		"Stages": stages,
+	}
+}
+
+func (pipeline *Pipeline) AddAttributes(tree htree.Tree, attrs *NodeAttributer) error {
+	return pipeline.AddFilteredAttributes(tree, attrs, nil)
+}
+
+// Runs every stage on the leaves include accepts, so every stage has to be
+// filtered.
+func (pipeline *Pipeline) AddFilteredAttributes(tree htree.Tree, attrs *NodeAttributer, include func(id htree.NodeID) bool) error {
+	for _, stage := range pipeline.Stages {
+		if include == nil && len(stage.Where) == 0 {
+			if err := stage.Attributor.AddAttributes(tree, attrs); err != nil {
+				return err
+			}
+			continue
+		}
+
+		filtered, ok := stage.Attributor.(FilteredTreeAttributor)
+		if !ok {
+			return ErrFilterUnsupported
+		}
+
+		matches := func(id htree.NodeID) bool {
+			return (include == nil || include(id)) && stage.matches(attrs, id)
+		}
+
+		// The stage writes to its own attributes so its conditions aren't
+		// affected by what it sets
+		staged := NewNodeAttributer()
+		if err := filtered.AddFilteredAttributes(tree, staged, matches); err != nil {
+			return err
+		}
+
+		for id, values := range staged.attrs {
+			if !matches(id) {
+				continue
+			}
+			for key, value := range values {
+				attrs.SetValue(id, key, value)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (stage *Stage) matches(attrs *NodeAttributer, id htree.NodeID) bool {
+	for _, condition := range stage.Where {
+		if !condition.matches(attrs, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// Runs the pipeline with new attributes.
+func (pipeline *Pipeline) Run(tree htree.Tree) (*NodeAttributer, error) {
+	attrs := NewNodeAttributer()
+	if err := pipeline.AddAttributes(tree, attrs); err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
+
+// The stored form of a stage.
+type StageSpec struct {
+	Spec
+	Where []Condition `json:"where,omitempty"`
+}
+
+// The stored form of a pipeline, running it again with the same tree gives
+// the same attributes.
+type PipelineSpec struct {
+	Stages []*StageSpec `json:"stages"`
+}
+
+// Creates the pipeline described by the spec.
+func NewPipelineFromSpec(spec *PipelineSpec) (*Pipeline, error) {
+	pipeline := NewPipeline()
+	for _, stageSpec := range spec.Stages {
+		attributor, err := NewFromSpec(&stageSpec.Spec)
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Add(attributor, stageSpec.Where...)
+	}
+	return pipeline, nil
+}
+
+// Returns the spec of the pipeline, every attributor must be registered.
+func (pipeline *Pipeline) Spec() (*PipelineSpec, error) {
+	spec := &PipelineSpec{Stages: []*StageSpec{}}
+	for _, stage := range pipeline.Stages {
+		attributorSpec, err := SpecFor(stage.Attributor)
+		if err != nil {
+			return nil, err
+		}
+		spec.Stages = append(spec.Stages, &StageSpec{
+			Spec:  *attributorSpec,
+			Where: stage.Where,
+		})
+	}
+	return spec, nil
+}
+
+func (pipeline *Pipeline) MarshalJSON() ([]byte, error) {
+	spec, err := pipeline.Spec()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(spec)
+}
+
+func (pipeline *Pipeline) UnmarshalJSON(data []byte) error {
+	spec := &PipelineSpec{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return err
+	}
+
+	decoded, err := NewPipelineFromSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	pipeline.Stages = decoded.Stages
+	return nil
+}
+
+func init() {
+	Register(&Registration{
+		Name: "Pipeline",
+		Defaults: func() interface{} {
+			return &PipelineSpec{}
+		},
+		New: func(params interface{}) (TreeAttributor, error) {
+			spec, ok := params.(*PipelineSpec)
+			if !ok {
+				return nil, ErrInvalidParams
+			}
+			return NewPipelineFromSpec(spec)
+		},
+		Params: func(attributor TreeAttributor) (interface{}, error) {
+			pipeline, ok := attributor.(*Pipeline)
+			if !ok {
+				return nil, ErrInvalidParams
+			}
+			return pipeline.Spec()
+		},
+	})
+}
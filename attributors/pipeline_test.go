@@ -0,0 +1,237 @@
+package attributors_test
+
+import (
+	"encoding/json"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/attributors"
+	_ "github.com/scisci/hambidgetree/attributors/all"
+	"github.com/scisci/hambidgetree/attributors/edgepath"
+	"github.com/scisci/hambidgetree/attributors/neighbor"
+	"github.com/scisci/hambidgetree/generators/grid"
+	"reflect"
+	"testing"
+)
+
+// Marks every leaf with the same attribute.
+type markLeaves struct {
+	key   string
+	value string
+}
+
+func (a *markLeaves) Name() string        { return "MarkLeaves" }
+func (a *markLeaves) Description() string { return "Marks every leaf" }
+func (a *markLeaves) Parameters(f attributors.ParameterFormatType) map[string]interface{} {
+	return map[string]interface{}{"Key": a.key, "Value": a.value}
+}
+
+func (a *markLeaves) AddAttributes(tree htree.Tree, attrs *attributors.NodeAttributer) error {
+	return a.AddFilteredAttributes(tree, attrs, nil)
+}
+
+func (a *markLeaves) AddFilteredAttributes(tree htree.Tree, attrs *attributors.NodeAttributer, include func(id htree.NodeID) bool) error {
+	for _, leaf := range attributors.FilterNodes(algo.FindLeaves(tree), include) {
+		attrs.SetAttribute(leaf.ID(), a.key, a.value)
+	}
+	return nil
+}
+
+// Marks the leaves in the left half of the tree.
+type markLeft struct{}
+
+func (a *markLeft) Name() string        { return "MarkLeft" }
+func (a *markLeft) Description() string { return "Marks the leaves on the left" }
+func (a *markLeft) Parameters(f attributors.ParameterFormatType) map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (a *markLeft) AddAttributes(tree htree.Tree, attrs *attributors.NodeAttributer) error {
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	container := regionMap[tree.Root().ID()].AlignedBox()
+	for _, leaf := range algo.FindLeaves(tree) {
+		if regionMap[leaf.ID()].AlignedBox().Right() <= container.Left()+container.Width()/2+0.0000001 {
+			attrs.SetValue(leaf.ID(), "left", attributors.BoolValue(true))
+		}
+	}
+	return nil
+}
+
+func TestPipeline(t *testing.T) {
+	tree := grid.New2D(6)
+	leaves := algo.FindLeaves(tree)
+
+	// Leaves without a neighbor mark become empty, the rest full
+	pipeline := attributors.NewPipeline().
+		Add(neighbor.NewHasNeighborAttributor(10, 2, 3)).
+		Add(&markLeaves{"state", "empty"}, attributors.Condition{Key: neighbor.HasNeighborAttr, Not: true}).
+		Add(&markLeaves{"state", "full"}, attributors.Condition{Key: neighbor.HasNeighborAttr, Value: neighbor.HasNeighborValue})
+
+	attrs, err := pipeline.Run(tree)
+	if err != nil {
+		t.Fatalf("Failed to run pipeline %v", err)
+	}
+
+	full := 0
+	for _, leaf := range leaves {
+		_, err := attrs.Attribute(leaf.ID(), neighbor.HasNeighborAttr)
+		state, _ := attrs.Attribute(leaf.ID(), "state")
+		if err == nil && state != "full" {
+			t.Errorf("Expected marked leaf %d to be full, got %s", leaf.ID(), state)
+		}
+		if err != nil && state != "empty" {
+			t.Errorf("Expected unmarked leaf %d to be empty, got %s", leaf.ID(), state)
+		}
+		if state == "full" {
+			full++
+		}
+	}
+
+	if full != 10 {
+		t.Errorf("Expected 10 full leaves, got %d", full)
+	}
+}
+
+func TestPipelineFilter(t *testing.T) {
+	tree := grid.New2D(6)
+
+	// The marks are all made on the left rather than dropped on the right
+	pipeline := attributors.NewPipeline().
+		Add(&markLeft{}).
+		Add(neighbor.NewHasNeighborAttributor(8, 2, 1), attributors.Condition{Key: "left"})
+
+	attrs, err := pipeline.Run(tree)
+	if err != nil {
+		t.Fatalf("Failed to run pipeline %v", err)
+	}
+
+	marked := attrs.KeyNodes(neighbor.HasNeighborAttr)
+	if len(marked) != 8 {
+		t.Errorf("Expected 8 marked leaves, got %d", len(marked))
+	}
+	for _, id := range marked {
+		if left, _ := attrs.Bool(id, "left"); !left {
+			t.Errorf("Expected marked leaf %d to be on the left", id)
+		}
+	}
+
+	// Attributors that can't be limited to some leaves can't have conditions
+	unfiltered := struct{ attributors.TreeAttributor }{&markLeaves{"state", "full"}}
+	pipeline = attributors.NewPipeline().
+		Add(&markLeft{}).
+		Add(unfiltered, attributors.Condition{Key: "left"})
+	if _, err := pipeline.Run(tree); err != attributors.ErrFilterUnsupported {
+		t.Errorf("Expected filter unsupported, got %v", err)
+	}
+}
+
+func TestPipelineSpec(t *testing.T) {
+	tree := grid.New2D(6)
+	leaves := algo.FindLeaves(tree)
+
+	paths := []edgepath.EdgePath{{From: edgepath.EdgeNameTop, To: edgepath.EdgeNameBottom}}
+	pipeline := attributors.NewPipeline().
+		Add(neighbor.NewHasNeighborAttributor(20, 2, 5)).
+		Add(edgepath.New(paths, 2, 0.37), attributors.Condition{Key: neighbor.HasNeighborAttr})
+
+	data, err := json.Marshal(pipeline)
+	if err != nil {
+		t.Fatalf("Failed to marshal pipeline %v", err)
+	}
+
+	replayed := &attributors.Pipeline{}
+	if err := json.Unmarshal(data, replayed); err != nil {
+		t.Fatalf("Failed to unmarshal pipeline %s %v", data, err)
+	}
+
+	// Nested in another pipeline by name
+	nested, err := attributors.NewFromSpec(&attributors.Spec{Attributor: "Pipeline", Params: data})
+	if err != nil {
+		t.Fatalf("Failed to create nested pipeline %v", err)
+	}
+
+	expected, err := pipeline.Run(tree)
+	if err != nil {
+		t.Fatalf("Failed to run pipeline %v", err)
+	}
+
+	for _, attributor := range []attributors.TreeAttributor{replayed, nested} {
+		attrs := attributors.NewNodeAttributer()
+		if err := attributor.AddAttributes(tree, attrs); err != nil {
+			t.Fatalf("Failed to run pipeline %v", err)
+		}
+
+		for _, leaf := range leaves {
+			if !reflect.DeepEqual(attrs.Attributes(leaf.ID()), expected.Attributes(leaf.ID())) {
+				t.Errorf("Leaf %d attributes differ after replaying %s", leaf.ID(), data)
+			}
+		}
+	}
+
+	// Only the marked leaves can be on the path
+	for _, leaf := range leaves {
+		if _, err := expected.Attribute(leaf.ID(), edgepath.OnPathAttr); err != nil {
+			continue
+		}
+		if _, err := expected.Attribute(leaf.ID(), neighbor.HasNeighborAttr); err != nil {
+			t.Errorf("Leaf %d is on the path without being marked", leaf.ID())
+		}
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	if _, err := attributors.NewFromSpec(&attributors.Spec{Attributor: "Missing"}); err != attributors.ErrUnknownAttributor {
+		t.Errorf("Expected unknown attributor, got %v", err)
+	}
+
+	if _, err := json.Marshal(attributors.NewPipeline().Add(&markLeaves{"a", "b"})); err == nil {
+		t.Errorf("Expected unregistered attributor to fail")
+	}
+
+	spec := `{"stages":[{"attributor":"HasNeighbor","params":{"marks":1}}]}`
+	if err := json.Unmarshal([]byte(spec), &attributors.Pipeline{}); err == nil {
+		t.Errorf("Expected unknown parameter to fail")
+	}
+}
+
+func TestNames(t *testing.T) {
+	expected := []string{"Coloring", "EdgePath", "HasNeighbor", "Pipeline"}
+	if names := attributors.Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected attributors %v, got %v", expected, names)
+	}
+}
+
+func TestNewSpec(t *testing.T) {
+	params := map[string]interface{}{
+		"maxMarks": 3,
+		"seed":     2,
+		"chaos":    0.5,
+	}
+
+	// Aliases resolve and parameters the attributor doesn't have are left out
+	spec, err := attributors.NewSpec("neighbor", params)
+	if err != nil {
+		t.Fatalf("Failed to create spec %v", err)
+	}
+	attributor, err := attributors.NewFromSpec(spec)
+	if err != nil {
+		t.Fatalf("Failed to create attributor %v", err)
+	}
+	if a := attributor.(*neighbor.HasNeighborAttributor); a.MaxMarks != 3 || a.Seed != 2 || a.Dimension != 2 {
+		t.Errorf("Expected 3 marks with seed 2 and the default dimension, got %d %d %d", a.MaxMarks, a.Seed, a.Dimension)
+	}
+
+	spec, err = attributors.NewSpec("edge path", params)
+	if err != nil {
+		t.Fatalf("Failed to create spec %v", err)
+	}
+	if spec.Attributor != "EdgePath" {
+		t.Errorf("Expected EdgePath, got %s", spec.Attributor)
+	}
+	if _, err := attributors.NewFromSpec(spec); err != nil {
+		t.Errorf("Failed to create attributor %v", err)
+	}
+
+	if _, err := attributors.NewSpec("bogus", params); err != attributors.ErrUnknownAttributor {
+		t.Errorf("Expected unknown attributor, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package attributors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownAttributor = errors.New("Unknown attributor")
+var ErrInvalidParams = errors.New("Params don't belong to the attributor")
+
+// Describes how to create an attributor from its parameters and how to get
+// the parameters back from an attributor.
+type Registration struct {
+	// The name of the attributor, the same as its Name method.
+	Name string
+	// Returns a pointer to a struct holding the default parameters, specs are
+	// decoded on top of it.
+	Defaults func() interface{}
+	// Creates an attributor from parameters of the type returned by Defaults.
+	New func(params interface{}) (TreeAttributor, error)
+	// Returns the parameters that create an identical attributor.
+	Params func(attributor TreeAttributor) (interface{}, error)
+	// Other names ResolveName finds the attributor by, optional.
+	Aliases []string
+}
+
+var registryMutex sync.RWMutex
+var registry = make(map[string]*Registration)
+
+// Makes an attributor available to specs, attributor packages register
+// themselves when they are imported. Panics if the name is taken.
+func Register(registration *Registration) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, ok := registry[registration.Name]; ok {
+		panic("Attributor " + registration.Name + " is already registered")
+	}
+	registry[registration.Name] = registration
+}
+
+func Lookup(name string) (*Registration, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	registration, ok := registry[name]
+	if !ok {
+		return nil, ErrUnknownAttributor
+	}
+	return registration, nil
+}
+
+// Returns the names of the registered attributors sorted alphabetically.
+func Names() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns the name of the registered attributor that matches the name or one
+// of its aliases when case and spaces are ignored, i.e. "edgepath" for
+// "EdgePath".
+func ResolveName(name string) (string, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	key := nameKey(name)
+	for _, registration := range registry {
+		for _, registered := range append([]string{registration.Name}, registration.Aliases...) {
+			if nameKey(registered) == key {
+				return registration.Name, nil
+			}
+		}
+	}
+	return "", ErrUnknownAttributor
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.Replace(name, " ", "", -1))
+}
+
+// Creates a spec for an attributor from a flat set of parameters that may be
+// shared by several attributors, the ones the attributor doesn't have are
+// left out. The name is resolved with ResolveName.
+func NewSpec(name string, params map[string]interface{}) (*Spec, error) {
+	name, err := ResolveName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	registration, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decoding ignores the parameters the attributor doesn't have
+	decoded := registration.Defaults()
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return nil, err
+	}
+
+	if data, err = json.Marshal(decoded); err != nil {
+		return nil, err
+	}
+
+	return &Spec{Attributor: name, Params: data}, nil
+}
+
+// An attributor and its parameters in a form that can be stored as JSON,
+// i.e. {"attributor":"HasNeighbor","params":{"maxMarks":5,"seed":1}}.
+// Parameters left out use their default values.
+type Spec struct {
+	Attributor string          `json:"attributor"`
+	Params     json.RawMessage `json:"params,omitempty"`
+}
+
+// Creates the attributor described by the spec.
+func NewFromSpec(spec *Spec) (TreeAttributor, error) {
+	registration, err := Lookup(spec.Attributor)
+	if err != nil {
+		return nil, err
+	}
+
+	params := registration.Defaults()
+	if len(spec.Params) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(spec.Params))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(params); err != nil {
+			return nil, err
+		}
+	}
+
+	return registration.New(params)
+}
+
+// Returns the spec that recreates the attributor, it must be registered.
+func SpecFor(attributor TreeAttributor) (*Spec, error) {
+	registration, err := Lookup(attributor.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := registration.Params(attributor)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Spec{
+		Attributor: registration.Name,
+		Params:     data,
+	}, nil
+}
@@ -0,0 +1,320 @@
+package attributors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	htree "github.com/scisci/hambidgetree"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+var ErrWrongType = errors.New("Attribute has a different type")
+var ErrInvalidColor = errors.New("Invalid color, expected #rgb, #rrggbb or #rrggbbaa")
+var ErrInvalidValue = errors.New("Invalid attribute value")
+
+type ValueType int
+
+const ValueTypeString ValueType = 1
+const ValueTypeBool ValueType = 2
+const ValueTypeInt ValueType = 3
+const ValueTypeFloat ValueType = 4
+const ValueTypeColor ValueType = 5
+const ValueTypeNode ValueType = 6 // A reference to another node of the tree
+const ValueTypeList ValueType = 7
+
+func (valueType ValueType) String() string {
+	switch valueType {
+	case ValueTypeString:
+		return "string"
+	case ValueTypeBool:
+		return "bool"
+	case ValueTypeInt:
+		return "int"
+	case ValueTypeFloat:
+		return "float"
+	case ValueTypeColor:
+		return "color"
+	case ValueTypeNode:
+		return "node"
+	case ValueTypeList:
+		return "list"
+	}
+
+	return "unknown"
+}
+
+// The value of an attribute. Values are immutable, create them with one of
+// the Value functions.
+type Value struct {
+	valueType ValueType
+	s         string
+	b         bool
+	i         int64
+	f         float64
+	c         color.NRGBA
+	list      []Value
+}
+
+func StringValue(s string) Value {
+	return Value{valueType: ValueTypeString, s: s}
+}
+
+func BoolValue(b bool) Value {
+	return Value{valueType: ValueTypeBool, b: b}
+}
+
+func IntValue(i int64) Value {
+	return Value{valueType: ValueTypeInt, i: i}
+}
+
+func FloatValue(f float64) Value {
+	return Value{valueType: ValueTypeFloat, f: f}
+}
+
+func ColorValue(c color.Color) Value {
+	return Value{valueType: ValueTypeColor, c: color.NRGBAModel.Convert(c).(color.NRGBA)}
+}
+
+func NodeValue(id htree.NodeID) Value {
+	return Value{valueType: ValueTypeNode, i: int64(id)}
+}
+
+func ListValue(values ...Value) Value {
+	return Value{valueType: ValueTypeList, list: append([]Value{}, values...)}
+}
+
+func (value Value) Type() ValueType {
+	return value.valueType
+}
+
+// Returns the value as it would have been stored before attributes were
+// typed, i.e. "true", "12" or "#ff0000". Lists are comma separated.
+func (value Value) String() string {
+	switch value.valueType {
+	case ValueTypeString:
+		return value.s
+	case ValueTypeBool:
+		return strconv.FormatBool(value.b)
+	case ValueTypeInt, ValueTypeNode:
+		return strconv.FormatInt(value.i, 10)
+	case ValueTypeFloat:
+		return strconv.FormatFloat(value.f, 'g', -1, 64)
+	case ValueTypeColor:
+		return FormatColor(value.c)
+	case ValueTypeList:
+		items := make([]string, len(value.list))
+		for i, item := range value.list {
+			items[i] = item.String()
+		}
+		return strings.Join(items, ",")
+	}
+
+	return ""
+}
+
+// The typed getters also accept strings that can be parsed as the type, so
+// attributes stored as strings can still be read.
+
+func (value Value) Bool() (bool, error) {
+	switch value.valueType {
+	case ValueTypeBool:
+		return value.b, nil
+	case ValueTypeString:
+		if b, err := strconv.ParseBool(value.s); err == nil {
+			return b, nil
+		}
+	}
+	return false, ErrWrongType
+}
+
+func (value Value) Int() (int64, error) {
+	switch value.valueType {
+	case ValueTypeInt:
+		return value.i, nil
+	case ValueTypeString:
+		if i, err := strconv.ParseInt(value.s, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	return 0, ErrWrongType
+}
+
+// Ints are also returned as floats.
+func (value Value) Float() (float64, error) {
+	switch value.valueType {
+	case ValueTypeFloat:
+		return value.f, nil
+	case ValueTypeInt:
+		return float64(value.i), nil
+	case ValueTypeString:
+		if f, err := strconv.ParseFloat(value.s, 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, ErrWrongType
+}
+
+func (value Value) Color() (color.Color, error) {
+	switch value.valueType {
+	case ValueTypeColor:
+		return value.c, nil
+	case ValueTypeString:
+		if c, err := ParseColor(value.s); err == nil {
+			return c, nil
+		}
+	}
+	return nil, ErrWrongType
+}
+
+func (value Value) Node() (htree.NodeID, error) {
+	switch value.valueType {
+	case ValueTypeNode:
+		return htree.NodeID(value.i), nil
+	case ValueTypeString:
+		if i, err := strconv.ParseInt(value.s, 10, 64); err == nil {
+			return htree.NodeID(i), nil
+		}
+	}
+	return 0, ErrWrongType
+}
+
+// Returns a copy of the items of a list.
+func (value Value) List() ([]Value, error) {
+	if value.valueType != ValueTypeList {
+		return nil, ErrWrongType
+	}
+	return append([]Value{}, value.list...), nil
+}
+
+func (value Value) Equal(other Value) bool {
+	if value.valueType != other.valueType {
+		return false
+	}
+
+	if value.valueType != ValueTypeList {
+		return value.s == other.s && value.b == other.b && value.i == other.i &&
+			value.f == other.f && value.c == other.c
+	}
+
+	if len(value.list) != len(other.list) {
+		return false
+	}
+	for i := range value.list {
+		if !value.list[i].Equal(other.list[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Values are encoded as an object with the type as its only key, i.e.
+// {"bool":true}, {"color":"#ff0000"} or {"list":[{"node":3},{"node":4}]}.
+func (value Value) MarshalJSON() ([]byte, error) {
+	var v interface{}
+	switch value.valueType {
+	case ValueTypeString:
+		v = value.s
+	case ValueTypeBool:
+		v = value.b
+	case ValueTypeInt, ValueTypeNode:
+		v = value.i
+	case ValueTypeFloat:
+		v = value.f
+	case ValueTypeColor:
+		v = FormatColor(value.c)
+	case ValueTypeList:
+		v = value.list
+	default:
+		return nil, ErrInvalidValue
+	}
+
+	return json.Marshal(map[string]interface{}{value.valueType.String(): v})
+}
+
+func (value *Value) UnmarshalJSON(data []byte) error {
+	var tagged map[string]json.RawMessage
+	if err := json.Unmarshal(data, &tagged); err != nil {
+		return err
+	}
+
+	if len(tagged) != 1 {
+		return ErrInvalidValue
+	}
+
+	for name, raw := range tagged {
+		var err error
+		switch name {
+		case "string":
+			var s string
+			err = json.Unmarshal(raw, &s)
+			*value = StringValue(s)
+		case "bool":
+			var b bool
+			err = json.Unmarshal(raw, &b)
+			*value = BoolValue(b)
+		case "int":
+			var i int64
+			err = json.Unmarshal(raw, &i)
+			*value = IntValue(i)
+		case "float":
+			var f float64
+			err = json.Unmarshal(raw, &f)
+			*value = FloatValue(f)
+		case "color":
+			var s string
+			if err = json.Unmarshal(raw, &s); err == nil {
+				var c color.Color
+				if c, err = ParseColor(s); err == nil {
+					*value = ColorValue(c)
+				}
+			}
+		case "node":
+			var i int64
+			err = json.Unmarshal(raw, &i)
+			*value = NodeValue(htree.NodeID(i))
+		case "list":
+			var list []Value
+			err = json.Unmarshal(raw, &list)
+			*value = ListValue(list...)
+		default:
+			return fmt.Errorf("%v: unknown type %s", ErrInvalidValue, name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Parses colors of the form #rgb, #rrggbb or #rrggbbaa.
+func ParseColor(s string) (color.Color, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) == 6 {
+		s = s + "ff"
+	}
+	if len(s) != 8 {
+		return nil, ErrInvalidColor
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, ErrInvalidColor
+	}
+
+	return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+// Formats a color as #rrggbb, or #rrggbbaa if it isn't opaque.
+func FormatColor(c color.Color) string {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B, nrgba.A)
+}
@@ -0,0 +1,160 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/generators"
+	"runtime"
+	"sync"
+)
+
+var ErrInvalidCount = errors.New("Count must not be negative")
+
+// Creates the generator used for a seed, each seed gets a generator of its own
+// so generators are never shared between workers.
+type GeneratorFactory func(seed int64) (generators.TreeGenerator, error)
+
+// Rates a generated tree after the attributors have run, higher is better.
+type ScoreFunc func(tree htree.Tree, attrs *attributors.NodeAttributer) (float64, error)
+
+type Options struct {
+	// The number of trees generated at once, defaults to the number of CPUs.
+	Workers int
+	// Run on every tree in order, they must be safe to use from more than one
+	// goroutine.
+	Attributors []attributors.TreeAttributor
+	// Scores each tree if set.
+	Score ScoreFunc
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		Workers: runtime.NumCPU(),
+	}
+}
+
+// The outcome of generating the tree for one seed. If Err is set the other
+// fields may not be.
+type Result struct {
+	Seed       int64
+	Tree       htree.Tree
+	Attributes *attributors.NodeAttributer
+	Score      float64
+	Err        error
+}
+
+// Generates a tree for each of count seeds starting at firstSeed. The results
+// are in seed order and hold the error of each seed, if any.
+//
+// If the context is done the seeds not yet generated get the context's error,
+// which is also returned.
+func Run(ctx context.Context, factory GeneratorFactory, firstSeed int64, count int, opts *Options) ([]*Result, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	if count < 0 {
+		return nil, ErrInvalidCount
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > count {
+		workers = count
+	}
+
+	results := make([]*Result, count)
+	indexes := make(chan int)
+
+	// The result of a seed skipped because the context is done
+	var mu sync.Mutex
+	var err error
+	cancel := func(seed int64) *Result {
+		mu.Lock()
+		defer mu.Unlock()
+		err = ctx.Err()
+		return &Result{Seed: seed, Err: err}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				seed := firstSeed + int64(index)
+				if ctx.Err() != nil {
+					results[index] = cancel(seed)
+					continue
+				}
+				results[index] = run(factory, seed, opts)
+			}
+		}()
+	}
+
+	// Select picks at random when both cases are ready so the context is
+	// checked before each send
+	for index := 0; index < count && ctx.Err() == nil; index++ {
+		select {
+		case indexes <- index:
+		case <-ctx.Done():
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for index, result := range results {
+		if result == nil {
+			results[index] = cancel(firstSeed + int64(index))
+		}
+	}
+
+	return results, err
+}
+
+func run(factory GeneratorFactory, seed int64, opts *Options) *Result {
+	result := &Result{Seed: seed}
+
+	gen, err := factory(seed)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Tree, result.Err = gen.Generate()
+	if result.Err != nil {
+		return result
+	}
+
+	result.Attributes = attributors.NewNodeAttributer()
+	for _, attributor := range opts.Attributors {
+		if result.Err = attributor.AddAttributes(result.Tree, result.Attributes); result.Err != nil {
+			return result
+		}
+	}
+
+	if opts.Score != nil {
+		result.Score, result.Err = opts.Score(result.Tree, result.Attributes)
+	}
+
+	return result
+}
+
+// Returns the result with the highest score that has no error, the earliest
+// seed wins a tie. Returns nil if every result has an error.
+func Best(results []*Result) *Result {
+	var best *Result
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		if best == nil || result.Score > best.Score {
+			best = result
+		}
+	}
+	return best
+}
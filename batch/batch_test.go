@@ -0,0 +1,164 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/attributors/neighbor"
+	"github.com/scisci/hambidgetree/batch"
+	"github.com/scisci/hambidgetree/generators"
+	"github.com/scisci/hambidgetree/generators/randombasic"
+	"github.com/scisci/hambidgetree/golden"
+	"testing"
+)
+
+var errOddSeed = errors.New("Odd seed")
+
+func factory(seed int64) (generators.TreeGenerator, error) {
+	return randombasic.New(golden.RatioSource(), 1, 10, seed)
+}
+
+func TestRun(t *testing.T) {
+	opts := &batch.Options{
+		Workers:     4,
+		Attributors: []attributors.TreeAttributor{neighbor.NewHasNeighborAttributor(3, 2, 1)},
+		Score: func(tree htree.Tree, attrs *attributors.NodeAttributer) (float64, error) {
+			return float64(len(algo.FindLeaves(tree))), nil
+		},
+	}
+
+	results, err := batch.Run(context.Background(), factory, 100, 20, opts)
+	if err != nil {
+		t.Fatalf("Failed to run batch %v", err)
+	}
+
+	if len(results) != 20 {
+		t.Fatalf("Expected 20 results, got %d", len(results))
+	}
+
+	for i, result := range results {
+		if result.Seed != int64(100+i) {
+			t.Errorf("Expected result %d to have seed %d, got %d", i, 100+i, result.Seed)
+		}
+		if result.Err != nil {
+			t.Errorf("Seed %d failed %v", result.Seed, result.Err)
+			continue
+		}
+		if result.Score != 10 {
+			t.Errorf("Expected score of 10, got %f", result.Score)
+		}
+
+		// The same tree as generating it alone
+		gen, _ := factory(result.Seed)
+		tree, _ := gen.Generate()
+		if algo.HashString(tree) != algo.HashString(result.Tree) {
+			t.Errorf("Seed %d generated a different tree in the batch", result.Seed)
+		}
+
+		marked := 0
+		for _, leaf := range algo.FindLeaves(result.Tree) {
+			if _, err := result.Attributes.Attribute(leaf.ID(), neighbor.HasNeighborAttr); err == nil {
+				marked++
+			}
+		}
+		if marked != 3 {
+			t.Errorf("Expected 3 marked leaves, got %d", marked)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	failOdd := func(seed int64) (generators.TreeGenerator, error) {
+		if seed%2 == 1 {
+			return nil, errOddSeed
+		}
+		return factory(seed)
+	}
+
+	opts := &batch.Options{
+		Workers: 3,
+		Score: func(tree htree.Tree, attrs *attributors.NodeAttributer) (float64, error) {
+			return float64(tree.RatioIndexXY()), nil
+		},
+	}
+
+	results, err := batch.Run(context.Background(), failOdd, 0, 10, opts)
+	if err != nil {
+		t.Fatalf("Failed to run batch %v", err)
+	}
+
+	for _, result := range results {
+		if result.Seed%2 == 1 && result.Err != errOddSeed {
+			t.Errorf("Expected seed %d to fail, got %v", result.Seed, result.Err)
+		}
+		if result.Seed%2 == 0 && result.Err != nil {
+			t.Errorf("Expected seed %d to succeed, got %v", result.Seed, result.Err)
+		}
+	}
+
+	if best := batch.Best(results); best == nil || best.Seed != 0 {
+		t.Errorf("Expected the first seed to be best of equal scores, got %v", best)
+	}
+
+	if _, err := batch.Run(context.Background(), factory, 0, -1, nil); err != batch.ErrInvalidCount {
+		t.Errorf("Expected invalid count, got %v", err)
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := batch.Run(ctx, factory, 0, 50, nil)
+	if err != context.Canceled {
+		t.Fatalf("Expected batch to be canceled, got %v", err)
+	}
+
+	if len(results) != 50 {
+		t.Fatalf("Expected 50 results, got %d", len(results))
+	}
+
+	// Nothing is generated once the context is done
+	for i, result := range results {
+		if result.Seed != int64(i) {
+			t.Errorf("Expected result %d to have seed %d, got %d", i, i, result.Seed)
+		}
+		if result.Err != context.Canceled || result.Tree != nil {
+			t.Errorf("Expected seed %d to be canceled, got %v", i, result.Err)
+		}
+	}
+}
+
+func TestRunCancelWhileRunning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel from inside the first seed, a seed handed out while it ran is
+	// skipped
+	generated := make(chan int64, 50)
+	cancelFirst := func(seed int64) (generators.TreeGenerator, error) {
+		if seed == 0 {
+			cancel()
+		}
+		generated <- seed
+		return factory(seed)
+	}
+
+	results, err := batch.Run(ctx, cancelFirst, 0, 50, &batch.Options{Workers: 1})
+	if err != context.Canceled {
+		t.Fatalf("Expected batch to be canceled, got %v", err)
+	}
+	close(generated)
+
+	if n := len(generated); n != 1 {
+		t.Errorf("Expected only the first seed to start, got %d", n)
+	}
+
+	for i, result := range results[1:] {
+		if result.Err != context.Canceled {
+			t.Errorf("Expected seed %d to be canceled, got %v", i+1, result.Err)
+		}
+	}
+}
@@ -16,6 +16,7 @@ func (u *uniqueIDGen) Next() htree.NodeID {
 
 type dBranch struct {
 	id         htree.NodeID
+	index      int
 	left       *dNode
 	right      *dNode
 	leftIndex  int
@@ -123,20 +124,8 @@ func (b *TreeBuilder) Branch(leafID htree.NodeID, splitType htree.SplitType, lef
 		panic("Leaf is not part of this builder")
 	}
 
-	ratios := b.ratioSource.Ratios()
-
-	var leftRegion, rightRegion *htree.Region
 	leaf := b.leaves[index]
-	switch splitType {
-	case htree.SplitTypeHorizontal:
-		leftRegion, rightRegion = htree.SplitRegionHorizontal(ratios, leaf.region, leftIndex, rightIndex)
-	case htree.SplitTypeVertical:
-		leftRegion, rightRegion = htree.SplitRegionVertical(ratios, leaf.region, leftIndex, rightIndex)
-	case htree.SplitTypeDepth:
-		leftRegion, rightRegion = htree.SplitRegionDepth(ratios, leaf.region, leftIndex, rightIndex)
-	default:
-		panic("Unknown split type")
-	}
+	leftRegion, rightRegion := htree.SplitRegion(b.ratioSource, leaf.region, splitType, leftIndex, rightIndex)
 
 	// Create a new node by
 	leftNode := &dNode{
@@ -151,13 +140,45 @@ func (b *TreeBuilder) Branch(leafID htree.NodeID, splitType htree.SplitType, lef
 
 	b.regions[leftNode.id] = leftNode
 	b.regions[rightNode.id] = rightNode
-	b.branches = append(b.branches, &dBranch{id: leafID, left: leftNode, right: rightNode, leftIndex: leftIndex, rightIndex: rightIndex, splitType: splitType})
+	b.branches = append(b.branches, &dBranch{id: leafID, index: index, left: leftNode, right: rightNode, leftIndex: leftIndex, rightIndex: rightIndex, splitType: splitType})
 
 	// Remove the parent from the leaves and add the two new leaves
 	b.leaves = append(b.leaves[:index], append(b.leaves[index+1:], leftNode, rightNode)...)
 	return leftNode, rightNode
 }
 
+// Returns the region of a node that is or was a leaf of this builder, nil if
+// there is no such node.
+func (b *TreeBuilder) Region(id htree.NodeID) *htree.Region {
+	node, ok := b.regions[id]
+	if !ok {
+		return nil
+	}
+	return node.region
+}
+
+// Reverts the last branch, the leaf that was split is put back where it was
+// and the ids of its children will be reused. Returns false if there are no
+// branches left to undo.
+func (b *TreeBuilder) Undo() bool {
+	if len(b.branches) == 0 {
+		return false
+	}
+
+	branch := b.branches[len(b.branches)-1]
+	b.branches = b.branches[:len(b.branches)-1]
+
+	delete(b.regions, branch.left.id)
+	delete(b.regions, branch.right.id)
+	b.idgen.id = b.idgen.id - 2
+
+	// The children of the last branch are always the last two leaves
+	parent := b.regions[branch.id]
+	leaves := b.leaves[:len(b.leaves)-2]
+	b.leaves = append(leaves[:branch.index], append([]*dNode{parent}, leaves[branch.index:]...)...)
+	return true
+}
+
 func (b *TreeBuilder) Build() (*simple.Tree, htree.RegionMap) {
 	simpleBranches := make(map[htree.NodeID]*simple.Branch)
 	simpleNodes := make(map[htree.NodeID]*simple.Node)
@@ -0,0 +1,55 @@
+package builder_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/builder"
+	"testing"
+)
+
+func TestUndo(t *testing.T) {
+	ratioSource, err := htree.NewBasicRatioSource([]float64{0.5, 1, 2})
+	if err != nil {
+		t.Fatalf("Failed to create ratio source %v", err)
+	}
+
+	b := builder.New2D(ratioSource, 1)
+	if b.Undo() {
+		t.Errorf("Expected nothing to undo")
+	}
+
+	left, right := b.Branch(1, htree.SplitTypeVertical, 0, 0)
+	b.Branch(left.ID(), htree.SplitTypeHorizontal, 1, 1)
+	expected, _ := b.Build()
+
+	b.Branch(right.ID(), htree.SplitTypeHorizontal, 1, 1)
+	if region := b.Region(6); region == nil || region.RatioIndexXY() != 1 {
+		t.Fatalf("Expected region of new leaf")
+	}
+
+	if !b.Undo() {
+		t.Fatalf("Expected branch to be undone")
+	}
+
+	if b.Region(6) != nil {
+		t.Errorf("Expected region of undone leaf to be removed")
+	}
+
+	leaves := b.Leaves()
+	if len(leaves) != 3 || leaves[0].ID() != right.ID() {
+		t.Errorf("Expected split leaf to be restored in place, got %v", leaves)
+	}
+
+	// The ids of the undone leaves are reused
+	b.Branch(right.ID(), htree.SplitTypeHorizontal, 1, 1)
+	b.Undo()
+	tree, _ := b.Build()
+	if algo.LayoutSignature(tree) != algo.LayoutSignature(expected) {
+		t.Errorf("Expected tree to match the tree before the undone branch")
+	}
+
+	next, _ := b.Branch(right.ID(), htree.SplitTypeHorizontal, 1, 1)
+	if next.ID() != 6 {
+		t.Errorf("Expected id 6 to be reused, got %d", next.ID())
+	}
+}
@@ -0,0 +1,390 @@
+package hambidgetree
+
+import (
+	"errors"
+	"fmt"
+	"github.com/scisci/hambidgetree/exact"
+	exprSolver "github.com/scisci/hambidgetree/expr"
+	"sort"
+	"strconv"
+)
+
+var ErrClosureTooLarge = errors.New("Ratio closure exceeds the maximum size")
+var ErrRatioOutOfBounds = errors.New("Ratio is outside of the closure bounds")
+
+// Why a ratio was added to a closure.
+type ClosureReason int
+
+const (
+	ClosureReasonInverse    ClosureReason = 1 // The inverse of a ratio was missing
+	ClosureReasonComplement ClosureReason = 2 // Needed so a ratio can be split
+	ClosureReasonHalf       ClosureReason = 3 // Half of a ratio, so it can be split in two
+	ClosureReasonSum        ClosureReason = 4 // The sum of two ratios
+)
+
+func (reason ClosureReason) String() string {
+	switch reason {
+	case ClosureReasonInverse:
+		return "inverse"
+	case ClosureReasonComplement:
+		return "complement"
+	case ClosureReasonHalf:
+		return "half"
+	case ClosureReasonSum:
+		return "sum"
+	}
+
+	return "unknown"
+}
+
+// The size of a closure when sums are added and there is no MaxSize.
+const defaultClosureSumsSize = 100
+
+// Bounds and limits used when computing a closure.
+type ClosureOptions struct {
+	MinRatio float64 // Ratios smaller than this are never added
+	MaxRatio float64 // Ratios larger than this are never added, 0 for no limit
+	MaxSize  int     // Maximum number of ratios in the closure, 0 for no limit
+	Sums     bool    // Whether to add the sum of every pair of ratios
+}
+
+// Returns the options used when none are provided.
+func DefaultClosureOptions() *ClosureOptions {
+	return &ClosureOptions{
+		MaxSize: defaultClosureSumsSize,
+		Sums:    true,
+	}
+}
+
+// A ratio that was added to a closure.
+type ClosureAddition struct {
+	Expr   string        // Expression of the added ratio
+	Value  float64       // Value of the added ratio
+	Reason ClosureReason // Why it was added
+	From   []string      // Expressions of the ratios that caused the addition
+}
+
+func (addition ClosureAddition) String() string {
+	value := strconv.FormatFloat(addition.Value, 'f', 4, 64)
+
+	switch addition.Reason {
+	case ClosureReasonInverse:
+		return fmt.Sprintf("%s (%s) inverse of %s", addition.Expr, value, addition.From[0])
+	case ClosureReasonComplement:
+		return fmt.Sprintf("%s (%s) complement of %s in %s", addition.Expr, value, addition.From[0], addition.From[1])
+	case ClosureReasonHalf:
+		return fmt.Sprintf("%s (%s) half of %s", addition.Expr, value, addition.From[0])
+	case ClosureReasonSum:
+		return fmt.Sprintf("%s (%s) sum of %s and %s", addition.Expr, value, addition.From[0], addition.From[1])
+	}
+
+	return addition.Expr + " (" + value + ")"
+}
+
+// The result of closing a set of ratios.
+type RatioClosure struct {
+	Exprs []string          // All of the ratios, seeds and additions, sorted ascending
+	Added []ClosureAddition // The ratios that were added in the order they were added
+}
+
+// Creates a ratio source from the closure.
+func (closure *RatioClosure) RatioSource() (ExactRatioSource, error) {
+	return NewExactRatioSource(closure.Exprs)
+}
+
+// Working state of a closure, the ratios are kept sorted.
+type closureSet struct {
+	values []exact.Number
+	exprs  []string
+	opts   ClosureOptions
+	added  []ClosureAddition
+}
+
+func (set *closureSet) index(value exact.Number) int {
+	return FindExactIndex(set.values, value)
+}
+
+func (set *closureSet) inBounds(value exact.Number) bool {
+	f := value.Float64()
+	return f >= set.opts.MinRatio && (set.opts.MaxRatio <= 0 || f <= set.opts.MaxRatio)
+}
+
+func (set *closureSet) insert(value exact.Number, expr string) error {
+	if set.opts.MaxSize > 0 && len(set.values) >= set.opts.MaxSize {
+		return ErrClosureTooLarge
+	}
+
+	i := sort.Search(len(set.values), func(i int) bool {
+		return set.values[i].Cmp(value) >= 0
+	})
+
+	set.values = append(set.values, exact.Number{})
+	copy(set.values[i+1:], set.values[i:])
+	set.values[i] = value
+
+	set.exprs = append(set.exprs, "")
+	copy(set.exprs[i+1:], set.exprs[i:])
+	set.exprs[i] = expr
+	return nil
+}
+
+func (set *closureSet) add(value exact.Number, reason ClosureReason, from ...string) error {
+	expr := value.String()
+	if err := set.insert(value, expr); err != nil {
+		return err
+	}
+
+	set.added = append(set.added, ClosureAddition{
+		Expr:   expr,
+		Value:  value.Float64(),
+		Reason: reason,
+		From:   from,
+	})
+	return nil
+}
+
+// Whether the ratio can be split vertically, or horizontally by splitting its
+// inverse vertically.
+func (set *closureSet) splittable(ratio exact.Number) bool {
+	inverse, err := ratio.Inverse()
+	if err != nil {
+		return false
+	}
+
+	for _, r := range []exact.Number{ratio, inverse} {
+		for j := 0; j < len(set.values) && set.values[j].Cmp(r) < 0; j++ {
+			if findExactComplement(set.values, r, j) >= 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// A set of ratios that would make a ratio splittable.
+type closureCandidate struct {
+	values []exact.Number
+	reason ClosureReason
+	from   []string
+}
+
+// Returns the candidate needing the fewest new ratios to make the ratio at
+// index i splittable, or nil if there isn't one within bounds.
+func (set *closureSet) bestCandidate(i int) *closureCandidate {
+	ratio := set.values[i]
+	expr := set.exprs[i]
+
+	// Returns the values, and their inverses, that are missing from the set
+	missing := func(values ...exact.Number) ([]exact.Number, bool) {
+		var result []exact.Number
+		for _, value := range values {
+			inverse, err := value.Inverse()
+			if err != nil || !set.inBounds(value) || !set.inBounds(inverse) {
+				return nil, false
+			}
+			for _, v := range []exact.Number{value, inverse} {
+				if set.index(v) < 0 && !containsNumber(result, v) {
+					result = append(result, v)
+				}
+			}
+		}
+		return result, true
+	}
+
+	var best *closureCandidate
+	consider := func(candidate *closureCandidate) {
+		if best == nil || len(candidate.values) < len(best.values) {
+			best = candidate
+		}
+	}
+
+	inverse, _ := ratio.Inverse()
+	for _, r := range []exact.Number{ratio, inverse} {
+		for j := 0; j < len(set.values) && set.values[j].Cmp(r) < 0; j++ {
+			rest, err := r.Sub(set.values[j])
+			if err != nil {
+				continue
+			}
+			if values, ok := missing(rest); ok {
+				consider(&closureCandidate{values, ClosureReasonComplement, []string{set.exprs[j], expr}})
+			}
+		}
+
+		half, err := r.Quo(exact.NewInt(2))
+		if err != nil {
+			continue
+		}
+		if values, ok := missing(half); ok {
+			consider(&closureCandidate{values, ClosureReasonHalf, []string{expr}})
+		}
+	}
+
+	return best
+}
+
+// Computes the closure of the seed expressions. Ratios are added until every
+// ratio has an inverse and at least one split, so that NewComplements succeeds
+// and no ratio is a dead end. When a ratio can't be split, the complement
+// requiring the fewest additions is added. If Sums is set, the sum of every
+// pair of ratios within bounds is then added along with its inverse until the
+// closure is full. Sums never end so without a MaxSize the closure stops at
+// 100 ratios. All seeds must be within bounds and belong to the same
+// quadratic field. opts may be nil.
+func CloseRatios(seeds []string, opts *ClosureOptions) (*RatioClosure, error) {
+	if opts == nil {
+		opts = DefaultClosureOptions()
+	}
+
+	set := &closureSet{opts: *opts}
+	if set.opts.Sums && set.opts.MaxSize <= 0 {
+		set.opts.MaxSize = defaultClosureSumsSize
+	}
+
+	var radicand int64
+	for _, seed := range seeds {
+		value, err := exprSolver.SolveExact(seed)
+		if err != nil {
+			return nil, err
+		}
+
+		if !value.IsRational() {
+			if radicand != 0 && radicand != value.Radicand() {
+				return nil, exact.ErrMixedRadicands
+			}
+			radicand = value.Radicand()
+		}
+
+		if !set.inBounds(value) {
+			return nil, fmt.Errorf("%v: %s", ErrRatioOutOfBounds, seed)
+		}
+
+		if set.index(value) >= 0 {
+			continue
+		}
+
+		if err := set.insert(value, seed); err != nil {
+			return nil, err
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		// Every ratio needs an inverse
+		for i := 0; i < len(set.values); i++ {
+			inverse, err := set.values[i].Inverse()
+			if err != nil {
+				return nil, err
+			}
+
+			if set.index(inverse) >= 0 {
+				continue
+			}
+
+			if !set.inBounds(inverse) {
+				return nil, fmt.Errorf("%v: inverse of %s", ErrRatioOutOfBounds, set.exprs[i])
+			}
+
+			if err := set.add(inverse, ClosureReasonInverse, set.exprs[i]); err != nil {
+				return nil, err
+			}
+			changed = true
+		}
+
+		// Every ratio needs at least one split
+		for i := 0; i < len(set.values); i++ {
+			if set.splittable(set.values[i]) {
+				continue
+			}
+
+			candidate := set.bestCandidate(i)
+			if candidate == nil {
+				return nil, fmt.Errorf("%v: no split of %s", ErrRatioOutOfBounds, set.exprs[i])
+			}
+
+			for _, value := range candidate.values {
+				reason, from := candidate.reason, candidate.from
+				if set.index(value) >= 0 {
+					continue
+				}
+				if !containsNumber(candidate.values[:1], value) {
+					// The inverse of the complement
+					reason, from = ClosureReasonInverse, []string{candidate.values[0].String()}
+				}
+				if err := set.add(value, reason, from...); err != nil {
+					return nil, err
+				}
+			}
+			changed = true
+		}
+
+		// Sums are optional so they are only added while there is room, since
+		// the sum and its inverse can be split by the pair nothing else is
+		// needed
+		if set.opts.Sums && !changed {
+			added, err := set.addSums()
+			if err != nil {
+				return nil, err
+			}
+			changed = added
+		}
+	}
+
+	return &RatioClosure{
+		Exprs: set.exprs,
+		Added: set.added,
+	}, nil
+}
+
+// Adds the sum of every pair of ratios, and its inverse, that is within bounds
+// and fits in the closure. Returns whether any were added.
+func (set *closureSet) addSums() (bool, error) {
+	added := false
+	for i := 0; i < len(set.values); i++ {
+		for j := i; j < len(set.values); j++ {
+			sum, err := set.values[i].Add(set.values[j])
+			if err != nil {
+				return false, err
+			}
+
+			inverse, err := sum.Inverse()
+			if err != nil {
+				return false, err
+			}
+
+			if !set.inBounds(sum) || !set.inBounds(inverse) || set.index(sum) >= 0 {
+				continue
+			}
+
+			needed := 1
+			if set.index(inverse) < 0 {
+				needed = 2
+			}
+			if len(set.values)+needed > set.opts.MaxSize {
+				continue
+			}
+
+			from := []string{set.exprs[i], set.exprs[j]}
+			if err := set.add(sum, ClosureReasonSum, from...); err != nil {
+				return false, err
+			}
+			if set.index(inverse) < 0 {
+				if err := set.add(inverse, ClosureReasonInverse, sum.String()); err != nil {
+					return false, err
+				}
+			}
+			added = true
+		}
+	}
+	return added, nil
+}
+
+func containsNumber(values []exact.Number, value exact.Number) bool {
+	for _, v := range values {
+		if v.Equal(value) {
+			return true
+		}
+	}
+	return false
+}
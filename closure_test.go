@@ -0,0 +1,109 @@
+package hambidgetree_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/golden"
+	"testing"
+)
+
+func TestCloseRatios(t *testing.T) {
+	opts := htree.DefaultClosureOptions()
+	opts.MinRatio = 0.1
+	opts.MaxRatio = 10
+
+	closure, err := htree.CloseRatios([]string{"1", "PHI"}, opts)
+	if err != nil {
+		t.Fatalf("Failed to close ratios %v", err)
+	}
+
+	if len(closure.Added) == 0 {
+		t.Fatalf("Expected ratios to be added")
+	}
+
+	if closure.Added[0].Reason != htree.ClosureReasonInverse {
+		t.Errorf("Expected inverse of PHI to be added first, got %v", closure.Added[0])
+	}
+
+	sums := 0
+	for _, addition := range closure.Added {
+		if addition.Value < opts.MinRatio || addition.Value > opts.MaxRatio {
+			t.Errorf("Addition out of bounds %v", addition)
+		}
+		if addition.Reason == htree.ClosureReasonSum {
+			sums++
+		}
+	}
+
+	// Sums are added by default until the closure is full
+	if sums == 0 || len(closure.Exprs) > opts.MaxSize {
+		t.Errorf("Expected sums up to %d ratios, got %d sums and %d ratios", opts.MaxSize, sums, len(closure.Exprs))
+	}
+
+	ratioSource, err := closure.RatioSource()
+	if err != nil {
+		t.Fatalf("Failed to create ratio source %v", err)
+	}
+
+	complements, err := htree.NewComplements(ratioSource.Ratios(), 0.0000001)
+	if err != nil {
+		t.Fatalf("Failed to create complements %v", err)
+	}
+
+	for i, splits := range complements {
+		if len(splits) == 0 {
+			t.Errorf("Ratio %s has no splits", closure.Exprs[i])
+		}
+	}
+}
+
+func TestCloseRatiosGolden(t *testing.T) {
+	closure, err := htree.CloseRatios(golden.Exprs, &htree.ClosureOptions{
+		MinRatio: 0.1,
+		MaxRatio: 10,
+	})
+	if err != nil {
+		t.Fatalf("Failed to close ratios %v", err)
+	}
+
+	if len(closure.Exprs) != len(closure.Added)+len(golden.Exprs) {
+		t.Errorf("Expected %d exprs, got %d", len(closure.Added)+len(golden.Exprs), len(closure.Exprs))
+	}
+}
+
+func TestCloseRatiosNoMaxRatio(t *testing.T) {
+	// Without sums or a max ratio only the inverse and the complements are
+	// needed
+	closure, err := htree.CloseRatios([]string{"1", "2"}, &htree.ClosureOptions{})
+	if err != nil {
+		t.Fatalf("Failed to close ratios %v", err)
+	}
+
+	if len(closure.Added) == 0 || closure.Added[0].Expr != "1/2" {
+		t.Errorf("Expected the inverse of 2 to be added first, got %v", closure.Added)
+	}
+
+	if _, err := closure.RatioSource(); err != nil {
+		t.Errorf("Failed to create ratio source %v", err)
+	}
+}
+
+func TestCloseRatiosErrors(t *testing.T) {
+	_, err := htree.CloseRatios([]string{"1", "PHI"}, &htree.ClosureOptions{
+		MinRatio: 0.1,
+		MaxRatio: 10,
+		MaxSize:  3,
+	})
+	if err != htree.ErrClosureTooLarge {
+		t.Errorf("Expected closure too large, got %v", err)
+	}
+
+	_, err = htree.CloseRatios([]string{"20"}, &htree.ClosureOptions{MinRatio: 0.1, MaxRatio: 10})
+	if err == nil {
+		t.Errorf("Expected out of bounds error")
+	}
+
+	_, err = htree.CloseRatios([]string{"PHI", "SQRT2"}, &htree.ClosureOptions{MinRatio: 0.1, MaxRatio: 10})
+	if err == nil {
+		t.Errorf("Expected mixed radicand error")
+	}
+}
@@ -0,0 +1,20 @@
+// Command htree-server serves the server package's JSON API over HTTP.
+package main
+
+import (
+	"flag"
+	"github.com/scisci/hambidgetree/server"
+	"log"
+	"net/http"
+)
+
+func main() {
+	opts := server.DefaultOptions()
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	flag.IntVar(&opts.MaxLeaves, "max-leaves", opts.MaxLeaves, "largest number of leaves a request may generate")
+	flag.Int64Var(&opts.MaxBodySize, "max-body", opts.MaxBodySize, "largest request body in bytes")
+	flag.Parse()
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server.New(opts)))
+}
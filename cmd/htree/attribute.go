@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/scisci/hambidgetree/attributors"
+	_ "github.com/scisci/hambidgetree/attributors/all"
+	"github.com/scisci/hambidgetree/attributors/edgepath"
+	"github.com/scisci/hambidgetree/factory"
+	"io"
+	"strings"
+)
+
+var ErrInvalidEdgePath = errors.New("Invalid edge path, expected From-To")
+
+var edgeNames = []edgepath.EdgeName{
+	edgepath.EdgeNameLeft,
+	edgepath.EdgeNameRight,
+	edgepath.EdgeNameTop,
+	edgepath.EdgeNameBottom,
+	edgepath.EdgeNameFront,
+	edgepath.EdgeNameBack,
+}
+
+func parseEdgeName(s string) (edgepath.EdgeName, bool) {
+	for _, name := range edgeNames {
+		if strings.EqualFold(name.String(), s) {
+			return name, true
+		}
+	}
+	return 0, false
+}
+
+// Parses a comma separated list of paths such as "left-right,top-bottom".
+func parseEdgePaths(s string) ([]edgepath.EdgePath, error) {
+	var paths []edgepath.EdgePath
+	for _, p := range strings.Split(s, ",") {
+		ends := strings.Split(strings.TrimSpace(p), "-")
+		if len(ends) != 2 {
+			return nil, fmt.Errorf("%v: %s", ErrInvalidEdgePath, p)
+		}
+		from, ok1 := parseEdgeName(ends[0])
+		to, ok2 := parseEdgeName(ends[1])
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%v: %s", ErrInvalidEdgePath, p)
+		}
+		paths = append(paths, edgepath.EdgePath{From: from, To: to})
+	}
+	return paths, nil
+}
+
+func runAttribute(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("attribute", flag.ContinueOnError)
+	in := fs.String("i", "-", "input factory JSON file, stdin if -")
+	attrsIn := fs.String("attrs", "", "existing attributes JSON file to add to")
+	name := fs.String("attributor", "neighbor", "attributor to run, one of "+strings.Join(attributors.Names(), ", ")+", case and spaces are ignored")
+	seed := fs.Int64("seed", 0, "random seed")
+	marks := fs.Int("marks", 10, "maximum number of leaves marked by neighbor")
+	dimension := fs.Int("dimension", 2, "dimension used by neighbor")
+	paths := fs.String("paths", "left-right", "comma separated edge paths used by edgepath")
+	chaos := fs.Float64("chaos", 0, "randomness of edgepath paths from 0 to 1")
+	pipeline := fs.String("pipeline", "", "pipeline spec JSON file, overrides the flags above")
+	embed := fs.Bool("embed", false, "write the tree with the attributes and attributors embedded instead of just the attributes")
+	out := fs.String("o", "", "output file, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	doc, err := readDocument(*in)
+	if err != nil {
+		return err
+	}
+
+	tree, attrs := doc.Tree, doc.Attributes
+	if err := readAttributes(*attrsIn, attrs); err != nil {
+		return err
+	}
+
+	var attributor attributors.TreeAttributor
+	if *pipeline != "" {
+		data, err := readInput(*pipeline)
+		if err != nil {
+			return err
+		}
+		p := attributors.NewPipeline()
+		if err := json.Unmarshal(data, p); err != nil {
+			return err
+		}
+		attributor = p
+	} else {
+		edgePaths, err := parseEdgePaths(*paths)
+		if err != nil {
+			return err
+		}
+
+		spec, err := attributors.NewSpec(*name, map[string]interface{}{
+			"seed":      *seed,
+			"maxMarks":  *marks,
+			"dimension": *dimension,
+			"paths":     edgePaths,
+			"chaos":     *chaos,
+		})
+		if err != nil {
+			return fmt.Errorf("%v %s", err, *name)
+		}
+
+		if attributor, err = attributors.NewFromSpec(spec); err != nil {
+			return err
+		}
+	}
+
+	if err := attributor.AddAttributes(tree, attrs); err != nil {
+		return err
+	}
+
+	if *embed {
+		spec, err := attributors.SpecFor(attributor)
+		if err != nil {
+			return err
+		}
+		doc.Attributors = append(doc.Attributors, &attributors.StageSpec{Spec: *spec})
+
+		data, err := factory.MarshalDocument(doc)
+		if err != nil {
+			return err
+		}
+
+		return writeOutput(*out, stdout, func(w io.Writer) error {
+			_, err := w.Write(append(data, '\n'))
+			return err
+		})
+	}
+
+	return writeOutput(*out, stdout, func(w io.Writer) error {
+		return writeAttributes(w, attrs)
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/scisci/hambidgetree/factory"
+	"github.com/scisci/hambidgetree/families"
+	"github.com/scisci/hambidgetree/generators"
+	_ "github.com/scisci/hambidgetree/generators/all"
+	"io"
+	"strings"
+)
+
+// Flags shared by all of the generators, each generator uses the ones that
+// are among its parameters.
+type generateParams struct {
+	family  string
+	exact   bool
+	leaves  int
+	seed    int64
+	ratioXY float64
+	ratioZY float64
+	is3D    bool
+	levels  int
+}
+
+// Returns the spec of the named generator with the flags as its parameters.
+func (params *generateParams) spec(name string) (*generators.Spec, error) {
+	ratioZY := params.ratioZY
+	if params.is3D && ratioZY <= 0 {
+		ratioZY = 1
+	}
+
+	spec, err := generators.NewSpec(name, map[string]interface{}{
+		"family":  params.family,
+		"exact":   params.exact,
+		"leaves":  params.leaves,
+		"seed":    params.seed,
+		"ratioXY": params.ratioXY,
+		"ratioZY": ratioZY,
+		"levels":  params.levels,
+		"is3D":    params.is3D,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v %s", err, name)
+	}
+	return spec, nil
+}
+
+func runGenerate(args []string, stdout io.Writer) error {
+	params := &generateParams{}
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	name := fs.String("generator", "randombasic", "generator to use, one of "+strings.Join(generators.Names(), ", ")+", case and spaces are ignored")
+	fs.StringVar(&params.family, "family", "golden", "ratio family, one of "+strings.Join(families.Names(), ", "))
+	fs.BoolVar(&params.exact, "exact", false, "evaluate the ratios exactly")
+	fs.IntVar(&params.leaves, "leaves", 20, "number of leaves")
+	fs.Int64Var(&params.seed, "seed", 0, "random seed")
+	fs.Float64Var(&params.ratioXY, "ratio", 1, "container ratio (XY)")
+	fs.Float64Var(&params.ratioZY, "zratio", 0, "container depth ratio (ZY), implies -3d")
+	fs.BoolVar(&params.is3D, "3d", false, "generate a 3D tree")
+	fs.IntVar(&params.levels, "levels", 2, "number of levels of the grid generator")
+	specPath := fs.String("spec", "", "generator spec JSON file, - for stdin, overrides the flags above")
+	out := fs.String("o", "", "output file, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if params.ratioZY > 0 {
+		params.is3D = true
+	}
+
+	var spec *generators.Spec
+	if *specPath != "" {
+		data, err := readInput(*specPath)
+		if err != nil {
+			return err
+		}
+
+		spec = &generators.Spec{}
+		if err := json.Unmarshal(data, spec); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if spec, err = params.spec(*name); err != nil {
+			return err
+		}
+	}
+
+	gen, err := generators.NewFromSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	tree, err := gen.Generate()
+	if err != nil {
+		return err
+	}
+
+	data, err := factory.MarshalJSON(tree)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*out, stdout, func(w io.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+}
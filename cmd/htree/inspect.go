@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/print"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Counts gathered by walking a tree.
+type treeStats struct {
+	nodes      int
+	leaves     int
+	depth      int
+	splits     map[htree.SplitType]int
+	leafRatios map[int]int // Number of leaves using each XY ratio index
+}
+
+func newTreeStats(tree htree.Tree) *treeStats {
+	stats := &treeStats{
+		splits:     make(map[htree.SplitType]int),
+		leafRatios: make(map[int]int),
+	}
+
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	depths := map[htree.NodeID]int{tree.Root().ID(): 0}
+
+	it := htree.NewNodeIterator(tree.Root())
+	for it.HasNext() {
+		node := it.Next()
+		depth := depths[node.ID()]
+		stats.nodes++
+		if depth > stats.depth {
+			stats.depth = depth
+		}
+
+		branch := node.Branch()
+		if branch == nil {
+			stats.leaves++
+			stats.leafRatios[regionMap[node.ID()].RatioIndexXY()]++
+			continue
+		}
+
+		stats.splits[branch.SplitType()]++
+		depths[branch.Left().ID()] = depth + 1
+		depths[branch.Right().ID()] = depth + 1
+	}
+
+	return stats
+}
+
+func formatRatio(ratioSource htree.RatioSource, index int) string {
+	if !htree.IsRatioIndexDefined(index) {
+		return "-"
+	}
+	value := strconv.FormatFloat(ratioSource.Ratios()[index], 'f', 4, 64)
+	return fmt.Sprintf("%d %s (%s)", index, ratioSource.Exprs()[index], value)
+}
+
+func runInspect(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	in := fs.String("i", "-", "input factory JSON file, stdin if -")
+	ratios := fs.Bool("ratios", false, "print the table of ratios")
+	complements := fs.Bool("complements", false, "print the splits of each ratio")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	tree, err := readTree(*in)
+	if err != nil {
+		return err
+	}
+
+	ratioSource := tree.RatioSource()
+	stats := newTreeStats(tree)
+
+	dimension := "2D"
+	if htree.IsRatioIndexDefined(tree.RatioIndexZY()) {
+		dimension = "3D"
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Dimension:\t%s\n", dimension)
+	fmt.Fprintf(w, "Container (XY):\t%s\n", formatRatio(ratioSource, tree.RatioIndexXY()))
+	fmt.Fprintf(w, "Container (ZY):\t%s\n", formatRatio(ratioSource, tree.RatioIndexZY()))
+	fmt.Fprintf(w, "Ratios:\t%d %s\n", len(ratioSource.Ratios()), print.PrintRatios(ratioSource))
+	fmt.Fprintf(w, "Nodes:\t%d\n", stats.nodes)
+	fmt.Fprintf(w, "Leaves:\t%d\n", stats.leaves)
+	fmt.Fprintf(w, "Depth:\t%d\n", stats.depth)
+	fmt.Fprintf(w, "Splits:\th %d, v %d, d %d\n",
+		stats.splits[htree.SplitTypeHorizontal],
+		stats.splits[htree.SplitTypeVertical],
+		stats.splits[htree.SplitTypeDepth])
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout)
+	w = tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Index\tExpr\tRatio\tLeaves")
+	for i, ratio := range ratioSource.Ratios() {
+		if !*ratios && stats.leafRatios[i] == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%.4f\t%d\n", i, ratioSource.Exprs()[i], ratio, stats.leafRatios[i])
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if *complements {
+		epsilon := 0.0000001
+		c, err := htree.NewRatioSourceComplements(ratioSource, epsilon)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, print.PrintComplements(c))
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/factory"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+// Reads the file at path, or stdin if the path is "-".
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// Reads a factory JSON tree from the path, or stdin if the path is "-".
+func readTree(path string) (htree.Tree, error) {
+	data, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return factory.UnmarshalJSON(data)
+}
+
+// Calls write with the file at path, or stdout if the path is empty or "-".
+func writeOutput(path string, stdout io.Writer, write func(w io.Writer) error) error {
+	if path == "" || path == "-" {
+		return write(stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Attributes are stored as a JSON object keyed by node id, each value being an
+// object of the node's typed attributes as encoded by NodeAttributer. Files
+// written before attributes were typed hold a string for each attribute.
+type stringAttributesJSON map[string]map[string]string
+
+// Reads a tree and any attributes stored with it.
+func readDocument(path string) (*factory.Document, error) {
+	data, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return factory.UnmarshalDocument(data)
+}
+
+// Adds the attributes in the file at path to attrs, does nothing if the path
+// is empty.
+func readAttributes(path string, attrs *attributors.NodeAttributer) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	typed := attributors.NewNodeAttributer()
+	if err := json.Unmarshal(data, typed); err == nil {
+		attrs.Merge(typed)
+		return nil
+	}
+
+	var nodes stringAttributesJSON
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return err
+	}
+
+	for key, values := range nodes {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid node id %s", key)
+		}
+		for k, v := range values {
+			attrs.SetAttribute(htree.NodeID(id), k, v)
+		}
+	}
+
+	return nil
+}
+
+func writeAttributes(w io.Writer, attrs *attributors.NodeAttributer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(attrs)
+}
+
+// Returns every value of the attribute that is a color, keyed by the value.
+func attributeColors(tree htree.Tree, attrs *attributors.NodeAttributer, key string) map[string]color.Color {
+	colors := make(map[string]color.Color)
+	it := htree.NewNodeIterator(tree.Root())
+	for it.HasNext() {
+		id := it.Next().ID()
+		if c, err := attrs.Color(id, key); err == nil {
+			value, _ := attrs.Attribute(id, key)
+			colors[value] = c
+		}
+	}
+	return colors
+}
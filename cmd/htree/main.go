@@ -0,0 +1,68 @@
+// Command htree generates, inspects, attributes and renders hambidge trees.
+//
+// Usage:
+//
+//	htree generate [flags]   generate a tree and write it as factory JSON
+//	htree inspect [flags]    print stats and ratio tables of a tree
+//	htree attribute [flags]  run an attributor and write the attributes as JSON
+//	htree render [flags]     render a tree to svg, png, obj, stl, gltf, glb or dxf
+//
+// Run htree <command> -h for the flags of each command.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrUnknownCommand = errors.New("Unknown command")
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{"generate", "generate a tree and write it as factory JSON", runGenerate},
+	{"inspect", "print stats and ratio tables of a tree", runInspect},
+	{"attribute", "run an attributor and write the attributes as JSON", runAttribute},
+	{"render", "render a tree to svg, png, obj, stl, gltf, glb or dxf", runRender},
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: htree <command> [flags]")
+	fmt.Fprintln(w)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return ErrUnknownCommand
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout)
+		}
+	}
+
+	return ErrUnknownCommand
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if err == ErrUnknownCommand {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "htree: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/attributors/coloring"
+	"github.com/scisci/hambidgetree/factory"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateRender(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htree")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	treePath := filepath.Join(dir, "tree.json")
+	attrsPath := filepath.Join(dir, "attrs.json")
+
+	if err := run([]string{"generate", "-leaves", "12", "-seed", "3", "-o", treePath}, ioutil.Discard); err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"inspect", "-i", treePath}, &out); err != nil {
+		t.Fatalf("Failed to inspect %v", err)
+	}
+	if !strings.Contains(out.String(), "Leaves:") || !strings.Contains(out.String(), "12") {
+		t.Errorf("Expected inspect to report 12 leaves, got\n%s", out.String())
+	}
+
+	if err := run([]string{"attribute", "-i", treePath, "-marks", "3", "-o", attrsPath}, ioutil.Discard); err != nil {
+		t.Fatalf("Failed to attribute %v", err)
+	}
+
+	for _, ext := range []string{"svg", "png", "obj", "stl", "gltf", "glb", "dxf"} {
+		path := filepath.Join(dir, "tree."+ext)
+		if err := run([]string{"render", "-i", treePath, "-attrs", attrsPath, "-width", "64", "-height", "64", "-o", path}, ioutil.Discard); err != nil {
+			t.Errorf("Failed to render %s %v", ext, err)
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("Expected %s output to be written", ext)
+		}
+	}
+}
+
+func TestGenerate3D(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"generate", "-family", "root2", "-zratio", "1", "-leaves", "8"}, &out); err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+	if !strings.Contains(out.String(), "SQRT(2)") {
+		t.Errorf("Expected root2 exprs in output")
+	}
+}
+
+func TestGenerateSpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htree")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	specPath := filepath.Join(dir, "spec.json")
+	spec := `{"generator": "Random Basic", "params": {"leaves": 12, "seed": 3}}`
+	if err := ioutil.WriteFile(specPath, []byte(spec), 0644); err != nil {
+		t.Fatalf("Failed to write spec %v", err)
+	}
+
+	// The spec matches the flags
+	var fromSpec, fromFlags bytes.Buffer
+	if err := run([]string{"generate", "-spec", specPath}, &fromSpec); err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+	if err := run([]string{"generate", "-leaves", "12", "-seed", "3"}, &fromFlags); err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+	if fromSpec.String() != fromFlags.String() {
+		t.Errorf("Expected spec to generate the same tree as the flags")
+	}
+
+	// Every registered generator can be chosen by name
+	var fromName bytes.Buffer
+	if err := run([]string{"generate", "-generator", "Random Basic", "-leaves", "12", "-seed", "3"}, &fromName); err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+	if fromName.String() != fromFlags.String() {
+		t.Errorf("Expected the registered name to generate the same tree")
+	}
+
+	for _, name := range []string{"constrained", "uniform", "Enumerate"} {
+		if err := run([]string{"generate", "-generator", name, "-leaves", "6"}, ioutil.Discard); err != nil {
+			t.Errorf("Failed to generate with %s %v", name, err)
+		}
+	}
+}
+
+func TestAttributeFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htree")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	treePath := filepath.Join(dir, "tree.json")
+	oldPath := filepath.Join(dir, "old.json")
+	attrsPath := filepath.Join(dir, "attrs.json")
+
+	if err := run([]string{"generate", "-generator", "grid", "-levels", "2", "-o", treePath}, ioutil.Discard); err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+
+	// Files with string attributes are still read
+	if err := ioutil.WriteFile(oldPath, []byte(`{"1": {"label": "7"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write attributes %v", err)
+	}
+
+	if err := run([]string{"attribute", "-i", treePath, "-attrs", oldPath, "-attributor", "coloring", "-o", attrsPath}, ioutil.Discard); err != nil {
+		t.Fatalf("Failed to attribute %v", err)
+	}
+
+	data, err := ioutil.ReadFile(attrsPath)
+	if err != nil {
+		t.Fatalf("Failed to read attributes %v", err)
+	}
+
+	// The types of the attributes are kept
+	attrs := attributors.NewNodeAttributer()
+	if err := json.Unmarshal(data, attrs); err != nil {
+		t.Fatalf("Failed to decode attributes %v\n%s", err, data)
+	}
+	if label, err := attrs.Value(1, "label"); err != nil || label.Type() != attributors.ValueTypeString || label.String() != "7" {
+		t.Errorf("Expected string label 7, got %v %v", label, err)
+	}
+	colored := attrs.KeyNodes(coloring.ColorIndexAttr)
+	if len(colored) != 4 {
+		t.Fatalf("Expected 4 colored leaves, got %d", len(colored))
+	}
+	if value, _ := attrs.Value(colored[0], coloring.ColorIndexAttr); value.Type() != attributors.ValueTypeInt {
+		t.Errorf("Expected colors to be ints, got %v", value.Type())
+	}
+
+	// Typed files are read back the same
+	var out bytes.Buffer
+	if err := run([]string{"attribute", "-i", treePath, "-attrs", attrsPath, "-attributor", "coloring"}, &out); err != nil {
+		t.Fatalf("Failed to attribute %v", err)
+	}
+	if out.String() != string(data) {
+		t.Errorf("Expected attributes to be the same after reading them back, got\n%s", out.String())
+	}
+}
+
+func TestAttributePipeline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htree")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	treePath := filepath.Join(dir, "tree.json")
+	pipelinePath := filepath.Join(dir, "pipeline.json")
+
+	if err := run([]string{"generate", "-generator", "grid", "-levels", "4", "-o", treePath}, ioutil.Discard); err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+
+	pipeline := `{"stages": [
+		{"attributor": "HasNeighbor", "params": {"maxMarks": 3}},
+		{"attributor": "EdgePath", "where": [{"key": "hasNeighbor", "not": true}]}
+	]}`
+	if err := ioutil.WriteFile(pipelinePath, []byte(pipeline), 0644); err != nil {
+		t.Fatalf("Failed to write pipeline %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"attribute", "-i", treePath, "-pipeline", pipelinePath}, &out); err != nil {
+		t.Fatalf("Failed to attribute %v", err)
+	}
+	if !strings.Contains(out.String(), "hasNeighbor") || !strings.Contains(out.String(), "onPath") {
+		t.Errorf("Expected attributes of both stages, got\n%s", out.String())
+	}
+}
+
+func TestAttributeEmbed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htree")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	treePath := filepath.Join(dir, "tree.json")
+	docPath := filepath.Join(dir, "doc.json")
+
+	if err := run([]string{"generate", "-generator", "grid", "-levels", "4", "-o", treePath}, ioutil.Discard); err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+
+	if err := run([]string{"attribute", "-i", treePath, "-marks", "3", "-embed", "-o", docPath}, ioutil.Discard); err != nil {
+		t.Fatalf("Failed to attribute %v", err)
+	}
+
+	// Every registered attributor can be chosen by name
+	var colors bytes.Buffer
+	if err := run([]string{"attribute", "-i", treePath, "-attributor", "Coloring"}, &colors); err != nil {
+		t.Fatalf("Failed to attribute %v", err)
+	}
+	if !strings.Contains(colors.String(), "colorIndex") {
+		t.Errorf("Expected colors, got\n%s", colors.String())
+	}
+	if err := run([]string{"attribute", "-i", treePath, "-attributor", "bogus"}, ioutil.Discard); err == nil {
+		t.Errorf("Expected unknown attributor error")
+	}
+
+	// Attributes are added to the ones already embedded
+	var out bytes.Buffer
+	if err := run([]string{"attribute", "-i", docPath, "-attributor", "edgepath", "-embed"}, &out); err != nil {
+		t.Fatalf("Failed to attribute %v", err)
+	}
+
+	doc, err := factory.UnmarshalDocument(out.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to read document %v", err)
+	}
+
+	if keys := doc.Attributes.Keys(); len(keys) != 2 {
+		t.Errorf("Expected attributes of both attributors, got %v", keys)
+	}
+	if len(doc.Attributors) != 2 || doc.Attributors[0].Attributor != "HasNeighbor" || doc.Attributors[1].Attributor != "EdgePath" {
+		t.Errorf("Expected both attributors to be stored in order")
+	}
+
+	if err := run([]string{"render", "-i", docPath, "-fill", "hasNeighbor", "-o", filepath.Join(dir, "doc.svg")}, ioutil.Discard); err != nil {
+		t.Errorf("Failed to render document %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if err := run([]string{"bogus"}, ioutil.Discard); err != ErrUnknownCommand {
+		t.Errorf("Expected unknown command, got %v", err)
+	}
+	if err := run([]string{"generate", "-generator", "bogus"}, ioutil.Discard); err == nil {
+		t.Errorf("Expected unknown generator error")
+	}
+	if err := run([]string{"render", "-o", "tree.bogus"}, ioutil.Discard); err == nil {
+		t.Errorf("Expected unknown format error")
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/dxf"
+	"github.com/scisci/hambidgetree/gltf"
+	"github.com/scisci/hambidgetree/mesh"
+	"github.com/scisci/hambidgetree/render"
+	"github.com/scisci/hambidgetree/render/raster"
+	"github.com/scisci/hambidgetree/render/svg"
+	"image/color"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var ErrUnknownFormat = errors.New("Unknown output format")
+var ErrUnknownProjection = errors.New("Unknown projection")
+
+// Flags shared by all of the output formats.
+type renderParams struct {
+	width      float64
+	height     float64
+	margin     float64
+	projection render.Projection
+	fillKey    string
+	scale      float64
+	gap        float64
+	thickness  float64
+	mtl        string
+}
+
+type renderFunc func(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, params *renderParams) error
+
+// The output formats by file extension.
+var renderers = map[string]renderFunc{
+	"svg":  renderSVG,
+	"png":  renderPNG,
+	"obj":  renderOBJ,
+	"stl":  renderSTL,
+	"gltf": renderGLTF,
+	"glb":  renderGLB,
+	"dxf":  renderDXF,
+}
+
+func renderSVG(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, params *renderParams) error {
+	opts := svg.DefaultOptions()
+	opts.Width = params.width
+	opts.Height = params.height
+	opts.Margin = params.margin
+	opts.Projection = params.projection
+	opts.FillKey = params.fillKey
+	return svg.Render(w, tree, attrs, opts)
+}
+
+func renderPNG(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, params *renderParams) error {
+	opts := raster.DefaultOptions()
+	opts.Width = int(params.width)
+	opts.Height = int(params.height)
+	opts.Margin = params.margin
+	opts.Projection = params.projection
+	if params.fillKey != "" {
+		opts.Fill = func(id htree.NodeID, nodeAttrs attributors.NodeAttributes) color.Color {
+			if value, err := nodeAttrs.Attribute(id, params.fillKey); err == nil {
+				if c, err := attributors.ParseColor(value); err == nil {
+					return c
+				}
+			}
+			return color.White
+		}
+	}
+	return raster.WritePNG(w, tree, attrs, opts)
+}
+
+func meshOptions(params *renderParams) *mesh.Options {
+	opts := mesh.DefaultOptions()
+	opts.Scale = params.scale
+	opts.Gap = params.gap
+	opts.Thickness = params.thickness
+	return opts
+}
+
+func renderOBJ(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, params *renderParams) error {
+	opts := &mesh.OBJOptions{MaterialKey: params.fillKey}
+	if params.mtl != "" {
+		opts.MaterialLib = filepath.Base(params.mtl)
+	}
+	return mesh.WriteOBJ(w, mesh.NewBoxes(tree, meshOptions(params)), attrs, opts)
+}
+
+func renderSTL(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, params *renderParams) error {
+	return mesh.WriteSTLBinary(w, mesh.NewBoxes(tree, meshOptions(params)))
+}
+
+func gltfOptions(tree htree.Tree, attrs *attributors.NodeAttributer, params *renderParams) *gltf.Options {
+	opts := gltf.DefaultOptions()
+	opts.Scale = params.scale
+	opts.Thickness = params.thickness
+	if params.fillKey != "" {
+		opts.MaterialKey = params.fillKey
+		opts.Colors = attributeColors(tree, attrs, params.fillKey)
+	}
+	return opts
+}
+
+func renderGLTF(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, params *renderParams) error {
+	return gltf.WriteGLTF(w, tree, attrs, gltfOptions(tree, attrs, params))
+}
+
+func renderGLB(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, params *renderParams) error {
+	return gltf.WriteGLB(w, tree, attrs, gltfOptions(tree, attrs, params))
+}
+
+func renderDXF(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, params *renderParams) error {
+	opts := dxf.DefaultOptions()
+	opts.Scale = params.scale
+	return dxf.Write(w, tree, opts)
+}
+
+func runRender(args []string, stdout io.Writer) error {
+	params := &renderParams{}
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	in := fs.String("i", "-", "input factory JSON file, stdin if -")
+	attrsIn := fs.String("attrs", "", "attributes JSON file")
+	out := fs.String("o", "", "output file, its extension selects the format")
+	format := fs.String("format", "", "output format when writing to stdout, one of svg, png, obj, stl, gltf, glb, dxf")
+	projection := fs.String("projection", "front", "face drawn for 3D trees, one of front, top, side")
+	fs.Float64Var(&params.width, "width", 800, "image width in pixels")
+	fs.Float64Var(&params.height, "height", 800, "image height in pixels")
+	fs.Float64Var(&params.margin, "margin", 0, "image margin in pixels")
+	fs.StringVar(&params.fillKey, "fill", "", "attribute holding each leaf's color or material")
+	fs.Float64Var(&params.scale, "scale", htree.UnityScale, "height of the container for obj, stl, gltf, glb and dxf")
+	fs.Float64Var(&params.gap, "gap", 0, "space between boxes for obj and stl")
+	fs.Float64Var(&params.thickness, "thickness", mesh.DefaultOptions().Thickness, "depth given to 2D trees for obj, stl, gltf and glb")
+	fs.StringVar(&params.mtl, "mtl", "", "material library written alongside obj output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var ok bool
+	if params.projection, ok = render.ProjectionForName(*projection); !ok {
+		return fmt.Errorf("%v %s", ErrUnknownProjection, *projection)
+	}
+
+	ext := *format
+	if ext == "" {
+		ext = strings.TrimPrefix(strings.ToLower(filepath.Ext(*out)), ".")
+	}
+	renderer, ok := renderers[ext]
+	if !ok {
+		return fmt.Errorf("%v %q", ErrUnknownFormat, ext)
+	}
+
+	doc, err := readDocument(*in)
+	if err != nil {
+		return err
+	}
+
+	tree, attrs := doc.Tree, doc.Attributes
+	if err := readAttributes(*attrsIn, attrs); err != nil {
+		return err
+	}
+
+	if params.mtl != "" && params.fillKey != "" {
+		err := writeOutput(params.mtl, stdout, func(w io.Writer) error {
+			return mesh.WriteMTL(w, attributeColors(tree, attrs, params.fillKey))
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeOutput(*out, stdout, func(w io.Writer) error {
+		return renderer(w, tree, attrs, params)
+	})
+}
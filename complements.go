@@ -73,3 +73,88 @@ func NewComplements(ratios Ratios, epsilon float64) (Complements, error) {
 
 	return Complements(complements), nil
 }
+
+// Returns the splits that can be applied to a region with the given ratio
+// indexes. For 2D regions (ratioIndexZY undefined) these are simply the
+// complements of the xy ratio. For 3D regions horizontal and vertical splits
+// are only kept if they also produce valid ratios in the zy and zx planes, and
+// vertical splits of the zy plane are added as depth splits.
+func AvailableSplits(ratios Ratios, complements Complements, ratioIndexXY, ratioIndexZY int, epsilon float64) []Split {
+	if !IsRatioIndexDefined(ratioIndexZY) {
+		return complements[ratioIndexXY]
+	}
+
+	// Any horizontal cut on the xy axis, affects the zy axis:
+	//   XYCutHeight = XYRatio / XYRatioTop
+	//   ZYRatioTop = ZYRatio / XYCutHeight
+	//   Compatible if ZYRatioTop can be found in the index
+	// Any vertical cut on the xy axis, affects the zx axis:
+	//   XYCutWidth = XYRatioLeft / XYRatio
+	//   XZRatioTop = XZRatio / XYCutWidth
+	// Any vertical cut on the zy axis, affects the zx axis
+	//   ZYCutWidth = ZYRatioLeft / ZYRatio
+	//   XZRatioLeft = ZYCutWidth / XZRatio
+	xyRatio := ratios[ratioIndexXY]
+	zyRatio := ratios[ratioIndexZY]
+	zxRatio := zyRatio / xyRatio
+
+	var splits []Split
+
+	for _, xySplit := range complements[ratioIndexXY] {
+		var first, second float64
+		switch xySplit.Type() {
+		case SplitTypeHorizontal:
+			first = zyRatio / RatioNormalHeight(xyRatio, ratios[xySplit.LeftIndex()])
+			second = zyRatio / RatioNormalHeight(xyRatio, ratios[xySplit.RightIndex()])
+		case SplitTypeVertical:
+			first = zxRatio / RatioNormalWidth(xyRatio, ratios[xySplit.LeftIndex()])
+			second = zxRatio / RatioNormalWidth(xyRatio, ratios[xySplit.RightIndex()])
+		default:
+			panic("What type?")
+		}
+
+		if FindClosestIndexWithinRange(ratios, first, epsilon) < 0 {
+			continue
+		}
+
+		if FindClosestIndexWithinRange(ratios, second, epsilon) < 0 {
+			continue
+		}
+
+		splits = append(splits, xySplit)
+	}
+
+	// Take each vertical split possible for the zy ratio and check it against
+	// the zx plane. If good, then add these to the possibilities as a depth
+	// split (instead of a vertical split)
+	for _, zySplit := range complements[ratioIndexZY] {
+		if zySplit.Type() != SplitTypeVertical {
+			continue
+		}
+
+		zxRatioLeft := RatioNormalWidth(zyRatio, ratios[zySplit.LeftIndex()]) * zxRatio
+		if FindClosestIndexWithinRange(ratios, zxRatioLeft, epsilon) < 0 {
+			continue
+		}
+
+		zxRatioRight := RatioNormalWidth(zyRatio, ratios[zySplit.RightIndex()]) * zxRatio
+		if FindClosestIndexWithinRange(ratios, zxRatioRight, epsilon) < 0 {
+			continue
+		}
+
+		splits = append(splits, NewDepthSplit(zySplit.LeftIndex(), zySplit.RightIndex()))
+	}
+
+	return splits
+}
+
+// Whether the split, or its inverse, is one of the available splits.
+func ContainsSplit(splits []Split, split Split) bool {
+	inverted := NewInvertedSplit(split)
+	for _, s := range splits {
+		if s == split || s == inverted {
+			return true
+		}
+	}
+	return false
+}
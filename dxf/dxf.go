@@ -0,0 +1,250 @@
+package dxf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	htree "github.com/scisci/hambidgetree"
+	"io"
+	"sort"
+	"strconv"
+)
+
+var ErrNot2D = errors.New("DXF export only supports 2D trees")
+
+// Drawing units. R12 drawings have no header variable for units so they are
+// only recorded in a comment, the reader has to be told the units. The values
+// match $INSUNITS of later versions.
+type Units int
+
+const (
+	UnitsUnitless    Units = 0
+	UnitsInches      Units = 1
+	UnitsFeet        Units = 2
+	UnitsMillimeters Units = 4
+	UnitsCentimeters Units = 5
+	UnitsMeters      Units = 6
+)
+
+func (units Units) String() string {
+	switch units {
+	case UnitsUnitless:
+		return "unitless"
+	case UnitsInches:
+		return "inches"
+	case UnitsFeet:
+		return "feet"
+	case UnitsMillimeters:
+		return "millimeters"
+	case UnitsCentimeters:
+		return "centimeters"
+	case UnitsMeters:
+		return "meters"
+	}
+
+	return "unknown"
+}
+
+// Selects how split lines are assigned to layers.
+type LayerMode int
+
+const (
+	LayerModeSplitType  LayerMode = 0 // HORIZONTAL and VERTICAL layers
+	LayerModeSplitDepth LayerMode = 1 // DEPTH_0, DEPTH_1, ... by depth of the branch
+)
+
+const OutlineLayer = "OUTLINE"
+
+// Options used when writing a tree as DXF.
+type Options struct {
+	Scale   float64   // Height of the container in drawing units
+	Units   Units     // Units of the drawing
+	Layers  LayerMode // How lines are assigned to layers
+	Outline bool      // Whether to include the container outline
+}
+
+// Returns the options used when none are provided.
+func DefaultOptions() *Options {
+	return &Options{
+		Scale:   htree.UnityScale,
+		Units:   UnitsUnitless,
+		Layers:  LayerModeSplitType,
+		Outline: true,
+	}
+}
+
+// A line entity. DXF has y pointing up so coordinates are flipped vertically
+// compared to the tree.
+type Line struct {
+	Layer  string
+	X1, Y1 float64
+	X2, Y2 float64
+}
+
+type nodeDepth struct {
+	node  htree.Node
+	depth int
+}
+
+// Returns one line per branch split of the tree, plus the outline if
+// requested. Since each split is only drawn once, the edges shared by
+// neighboring leaves are never duplicated.
+func NewLines(tree htree.Tree, opts *Options) ([]Line, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	if htree.IsRatioIndexDefined(tree.RatioIndexZY()) {
+		return nil, ErrNot2D
+	}
+
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, opts.Scale)
+	container := regionMap[tree.Root().ID()].AlignedBox()
+	flip := func(y float64) float64 {
+		return container.Bottom() - y
+	}
+
+	var lines []Line
+	if opts.Outline {
+		l, t, r, b := container.Left(), flip(container.Top()), container.Right(), flip(container.Bottom())
+		lines = append(lines,
+			Line{OutlineLayer, l, t, r, t},
+			Line{OutlineLayer, r, t, r, b},
+			Line{OutlineLayer, r, b, l, b},
+			Line{OutlineLayer, l, b, l, t},
+		)
+	}
+
+	stack := []nodeDepth{{tree.Root(), 0}}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		branch := current.node.Branch()
+		if branch == nil {
+			continue
+		}
+
+		dim := regionMap[current.node.ID()].AlignedBox()
+		left := regionMap[branch.Left().ID()].AlignedBox()
+
+		line := Line{Layer: layerName(branch.SplitType(), current.depth, opts.Layers)}
+		switch branch.SplitType() {
+		case htree.SplitTypeVertical:
+			line.X1, line.Y1, line.X2, line.Y2 = left.Right(), flip(dim.Top()), left.Right(), flip(dim.Bottom())
+		case htree.SplitTypeHorizontal:
+			line.X1, line.Y1, line.X2, line.Y2 = dim.Left(), flip(left.Bottom()), dim.Right(), flip(left.Bottom())
+		default:
+			return nil, ErrNot2D
+		}
+
+		lines = append(lines, line)
+		stack = append(stack,
+			nodeDepth{branch.Right(), current.depth + 1},
+			nodeDepth{branch.Left(), current.depth + 1})
+	}
+
+	return lines, nil
+}
+
+func layerName(splitType htree.SplitType, depth int, mode LayerMode) string {
+	if mode == LayerModeSplitDepth {
+		return "DEPTH_" + strconv.Itoa(depth)
+	}
+
+	if splitType == htree.SplitTypeHorizontal {
+		return "HORIZONTAL"
+	}
+
+	return "VERTICAL"
+}
+
+// Writes the split lines of a 2D tree as an R12 DXF drawing. opts may be nil.
+func Write(w io.Writer, tree htree.Tree, opts *Options) error {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	lines, err := NewLines(tree, opts)
+	if err != nil {
+		return err
+	}
+
+	return WriteLines(w, lines, opts.Units)
+}
+
+// Writes the lines as an R12 DXF drawing with a layer table covering every
+// layer used.
+func WriteLines(w io.Writer, lines []Line, units Units) error {
+	layerSet := make(map[string]bool)
+	for _, line := range lines {
+		layerSet[line.Layer] = true
+	}
+
+	layers := make([]string, 0, len(layerSet))
+	for layer := range layerSet {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+
+	buf := bufio.NewWriter(w)
+	group := func(code int, value string) {
+		fmt.Fprintf(buf, "%d\n%s\n", code, value)
+	}
+
+	group(999, "Units: "+units.String())
+
+	group(0, "SECTION")
+	group(2, "HEADER")
+	group(9, "$ACADVER")
+	group(1, "AC1009")
+	group(0, "ENDSEC")
+
+	// Every layer uses the CONTINUOUS line type so it has to be defined
+	group(0, "SECTION")
+	group(2, "TABLES")
+	group(0, "TABLE")
+	group(2, "LTYPE")
+	group(70, "1")
+	group(0, "LTYPE")
+	group(2, "CONTINUOUS")
+	group(70, "0")
+	group(3, "Solid line")
+	group(72, "65")
+	group(73, "0")
+	group(40, "0.0")
+	group(0, "ENDTAB")
+	group(0, "TABLE")
+	group(2, "LAYER")
+	group(70, strconv.Itoa(len(layers)))
+	for i, layer := range layers {
+		group(0, "LAYER")
+		group(2, layer)
+		group(70, "0")
+		group(62, strconv.Itoa(i%7+1)) // Cycle through the standard colors
+		group(6, "CONTINUOUS")
+	}
+	group(0, "ENDTAB")
+	group(0, "ENDSEC")
+
+	group(0, "SECTION")
+	group(2, "ENTITIES")
+	for _, line := range lines {
+		group(0, "LINE")
+		group(8, line.Layer)
+		group(10, formatFloat(line.X1))
+		group(20, formatFloat(line.Y1))
+		group(30, "0.0")
+		group(11, formatFloat(line.X2))
+		group(21, formatFloat(line.Y2))
+		group(31, "0.0")
+	}
+	group(0, "ENDSEC")
+	group(0, "EOF")
+
+	return buf.Flush()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,85 @@
+package dxf_test
+
+import (
+	"bytes"
+	"github.com/scisci/hambidgetree/dxf"
+	"github.com/scisci/hambidgetree/generators/grid"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tree := grid.New2D(2)
+
+	opts := dxf.DefaultOptions()
+	opts.Scale = 100
+	opts.Outline = false
+
+	lines, err := dxf.NewLines(tree, opts)
+	if err != nil {
+		t.Fatalf("Failed to create lines %v", err)
+	}
+
+	// One vertical split down the middle then a horizontal split of each half
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(lines))
+	}
+
+	if lines[0] != (dxf.Line{Layer: "VERTICAL", X1: 50, Y1: 100, X2: 50, Y2: 0}) {
+		t.Errorf("Unexpected vertical line %v", lines[0])
+	}
+
+	for _, line := range lines[1:] {
+		if line.Layer != "HORIZONTAL" || line.Y1 != 50 || line.Y2 != 50 || line.X2-line.X1 != 50 {
+			t.Errorf("Unexpected horizontal line %v", line)
+		}
+	}
+
+	opts.Layers = dxf.LayerModeSplitDepth
+	opts.Outline = true
+	lines, err = dxf.NewLines(tree, opts)
+	if err != nil {
+		t.Fatalf("Failed to create lines %v", err)
+	}
+
+	layers := make(map[string]int)
+	for _, line := range lines {
+		layers[line.Layer]++
+	}
+
+	if layers[dxf.OutlineLayer] != 4 || layers["DEPTH_0"] != 1 || layers["DEPTH_1"] != 2 {
+		t.Errorf("Unexpected layers %v", layers)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	opts := dxf.DefaultOptions()
+	opts.Units = dxf.UnitsMillimeters
+
+	buf := bytes.NewBuffer(nil)
+	if err := dxf.Write(buf, grid.New2D(2), opts); err != nil {
+		t.Fatalf("Failed to write dxf %v", err)
+	}
+
+	doc := buf.String()
+	if n := strings.Count(doc, "0\nLINE\n"); n != 7 {
+		t.Errorf("Expected 7 lines, got %d", n)
+	}
+
+	// R12 has no $INSUNITS so the units are only a comment
+	if strings.Contains(doc, "$INSUNITS") || !strings.HasPrefix(doc, "999\nUnits: millimeters\n") {
+		t.Errorf("Expected millimeter units in a comment")
+	}
+
+	if !strings.Contains(doc, "0\nTABLE\n2\nLTYPE\n70\n1\n0\nLTYPE\n2\nCONTINUOUS\n") {
+		t.Errorf("Expected the CONTINUOUS line type to be defined")
+	}
+
+	if !strings.HasSuffix(doc, "0\nEOF\n") {
+		t.Errorf("Expected dxf to end with EOF")
+	}
+
+	if err := dxf.Write(buf, grid.New3D(3), nil); err != dxf.ErrNot2D {
+		t.Errorf("Expected 3D tree to fail, got %v", err)
+	}
+}
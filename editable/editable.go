@@ -0,0 +1,371 @@
+package editable
+
+import (
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/simple"
+)
+
+const defaultEpsilon = 0.0000001
+
+var ErrNodeNotFound = errors.New("Node not found")
+var ErrNotLeaf = errors.New("Node is not a leaf")
+var ErrNotBranch = errors.New("Node is not a branch")
+var ErrInvalidSplit = errors.New("Split is not one of the complements of the node")
+var ErrRatioSourceMismatch = errors.New("Ratio sources don't match")
+var ErrRatioMismatch = errors.New("Subtree ratio doesn't match node")
+var ErrNothingToUndo = errors.New("Nothing to undo")
+var ErrNothingToRedo = errors.New("Nothing to redo")
+
+// A tree that can be modified after it is created. Every modification is
+// validated against the complements of the ratio source and recorded so it
+// can be undone and redone.
+type Tree struct {
+	ratioSource  htree.RatioSource
+	complements  htree.Complements
+	ratioIndexXY int
+	ratioIndexZY int
+	state        *state
+	undo         []*state
+	redo         []*state
+}
+
+// Creates a tree with a single leaf.
+func New(ratioSource htree.RatioSource, ratioIndexXY, ratioIndexZY int) (*Tree, error) {
+	complements, err := htree.NewRatioSourceComplements(ratioSource, defaultEpsilon)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &Node{id: 1}
+
+	return &Tree{
+		ratioSource:  ratioSource,
+		complements:  complements,
+		ratioIndexXY: ratioIndexXY,
+		ratioIndexZY: ratioIndexZY,
+		state: &state{
+			root:    root,
+			nodes:   map[htree.NodeID]*Node{root.id: root},
+			parents: make(map[htree.NodeID]htree.NodeID),
+			nextID:  root.id,
+		},
+	}, nil
+}
+
+// Creates an editable copy of the tree, node ids are preserved.
+func FromTree(tree htree.Tree) (*Tree, error) {
+	t, err := New(tree.RatioSource(), tree.RatioIndexXY(), tree.RatioIndexZY())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &state{
+		nodes:   make(map[htree.NodeID]*Node),
+		parents: make(map[htree.NodeID]htree.NodeID),
+	}
+	s.root = s.copyNodes(tree.Root(), func(id htree.NodeID) htree.NodeID { return id })
+
+	for id := range s.nodes {
+		if id > s.nextID {
+			s.nextID = id
+		}
+	}
+
+	t.state = s
+	return t, nil
+}
+
+// Copies the subtree starting at src into the state, assigning ids using
+// idFn and returns the copy of src.
+func (s *state) copyNodes(src htree.Node, idFn func(id htree.NodeID) htree.NodeID) *Node {
+	root := &Node{id: idFn(src.ID())}
+	s.nodes[root.id] = root
+
+	type pair struct {
+		src  htree.Node
+		node *Node
+	}
+
+	stack := []pair{{src, root}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		branch := p.src.Branch()
+		if branch == nil {
+			continue
+		}
+
+		left := &Node{id: idFn(branch.Left().ID())}
+		right := &Node{id: idFn(branch.Right().ID())}
+		s.nodes[left.id] = left
+		s.nodes[right.id] = right
+		s.parents[left.id] = p.node.id
+		s.parents[right.id] = p.node.id
+
+		p.node.branch = &Branch{
+			splitType:  branch.SplitType(),
+			left:       left,
+			right:      right,
+			leftIndex:  branch.LeftIndex(),
+			rightIndex: branch.RightIndex(),
+		}
+
+		stack = append(stack, pair{branch.Right(), right}, pair{branch.Left(), left})
+	}
+
+	return root
+}
+
+func (t *Tree) RatioSource() htree.RatioSource {
+	return t.ratioSource
+}
+
+func (t *Tree) Complements() htree.Complements {
+	return t.complements
+}
+
+func (t *Tree) Node(id htree.NodeID) htree.Node {
+	if node, ok := t.state.nodes[id]; ok {
+		return node
+	}
+
+	return nil
+}
+
+func (t *Tree) Parent(id htree.NodeID) htree.Node {
+	if parentID, ok := t.state.parents[id]; ok {
+		return t.state.nodes[parentID]
+	}
+
+	return nil
+}
+
+func (t *Tree) Root() htree.Node {
+	return t.state.root
+}
+
+func (t *Tree) RatioIndexXY() int {
+	return t.ratioIndexXY
+}
+
+func (t *Tree) RatioIndexZY() int {
+	return t.ratioIndexZY
+}
+
+// Returns the region of the node in unity scale.
+func (t *Tree) Region(id htree.NodeID) (*htree.Region, error) {
+	if _, ok := t.state.nodes[id]; !ok {
+		return nil, ErrNodeNotFound
+	}
+
+	return htree.NewTreeRegionMap(t, htree.Origin, htree.UnityScale)[id], nil
+}
+
+// Returns the splits that can be applied to the leaf.
+func (t *Tree) AvailableSplits(id htree.NodeID) ([]htree.Split, error) {
+	region, err := t.Region(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return htree.AvailableRatioSourceSplits(t.ratioSource, t.complements,
+		region.RatioIndexXY(), region.RatioIndexZY(), defaultEpsilon), nil
+}
+
+// Splits a leaf into two new leaves, the split (or its inverse) must be one of
+// the available splits of the leaf.
+func (t *Tree) Split(id htree.NodeID, split htree.Split) (left, right htree.NodeID, err error) {
+	node, ok := t.state.nodes[id]
+	if !ok {
+		return 0, 0, ErrNodeNotFound
+	}
+
+	if node.branch != nil {
+		return 0, 0, ErrNotLeaf
+	}
+
+	splits, err := t.AvailableSplits(id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !htree.ContainsSplit(splits, split) {
+		return 0, 0, ErrInvalidSplit
+	}
+
+	node = t.edit().nodes[id]
+
+	leftNode := t.state.newNode()
+	rightNode := t.state.newNode()
+	t.state.parents[leftNode.id] = id
+	t.state.parents[rightNode.id] = id
+
+	node.branch = &Branch{
+		splitType:  split.Type(),
+		left:       leftNode,
+		right:      rightNode,
+		leftIndex:  split.LeftIndex(),
+		rightIndex: split.RightIndex(),
+	}
+
+	return leftNode.id, rightNode.id, nil
+}
+
+// Removes all of the descendants of a branch, turning it back into a leaf.
+func (t *Tree) Collapse(id htree.NodeID) error {
+	node, ok := t.state.nodes[id]
+	if !ok {
+		return ErrNodeNotFound
+	}
+
+	if node.branch == nil {
+		return ErrNotBranch
+	}
+
+	node = t.edit().nodes[id]
+	t.state.removeDescendants(node)
+	node.branch = nil
+	return nil
+}
+
+// Swaps the children of a branch, along with their ratios, mirroring the
+// branch along its split axis.
+func (t *Tree) Swap(id htree.NodeID) error {
+	node, ok := t.state.nodes[id]
+	if !ok {
+		return ErrNodeNotFound
+	}
+
+	if node.branch == nil {
+		return ErrNotBranch
+	}
+
+	branch := t.edit().nodes[id].branch
+	branch.left, branch.right = branch.right, branch.left
+	branch.leftIndex, branch.rightIndex = branch.rightIndex, branch.leftIndex
+	return nil
+}
+
+// Replaces the node and its descendants with a copy of the subtree. The
+// subtree must use the same ratios and its container must have the same
+// ratios as the node. The copied nodes are given new ids, except the root
+// which keeps the id of the replaced node.
+func (t *Tree) Replace(id htree.NodeID, subtree htree.Tree) error {
+	if _, ok := t.state.nodes[id]; !ok {
+		return ErrNodeNotFound
+	}
+
+	if !exprsEqual(t.ratioSource.Exprs(), subtree.RatioSource().Exprs()) {
+		return ErrRatioSourceMismatch
+	}
+
+	region, err := t.Region(id)
+	if err != nil {
+		return err
+	}
+
+	if region.RatioIndexXY() != subtree.RatioIndexXY() || region.RatioIndexZY() != subtree.RatioIndexZY() {
+		return ErrRatioMismatch
+	}
+
+	s := t.edit()
+	node := s.nodes[id]
+	s.removeDescendants(node)
+
+	subtreeRootID := subtree.Root().ID()
+	copied := s.copyNodes(subtree.Root(), func(srcID htree.NodeID) htree.NodeID {
+		if srcID == subtreeRootID {
+			return id
+		}
+		s.nextID++
+		return s.nextID
+	})
+
+	// copyNodes replaced the node in the lookup, make sure the parent still
+	// points at it.
+	node.branch = copied.branch
+	s.nodes[id] = node
+	return nil
+}
+
+// Whether there is an edit that can be undone.
+func (t *Tree) CanUndo() bool {
+	return len(t.undo) > 0
+}
+
+// Whether there is an edit that can be redone.
+func (t *Tree) CanRedo() bool {
+	return len(t.redo) > 0
+}
+
+// Reverts the last edit.
+func (t *Tree) Undo() error {
+	if len(t.undo) == 0 {
+		return ErrNothingToUndo
+	}
+
+	t.redo = append(t.redo, t.state)
+	t.state = t.undo[len(t.undo)-1]
+	t.undo = t.undo[:len(t.undo)-1]
+	return nil
+}
+
+// Reapplies the last edit that was undone.
+func (t *Tree) Redo() error {
+	if len(t.redo) == 0 {
+		return ErrNothingToRedo
+	}
+
+	t.undo = append(t.undo, t.state)
+	t.state = t.redo[len(t.redo)-1]
+	t.redo = t.redo[:len(t.redo)-1]
+	return nil
+}
+
+// Creates an immutable copy of the current tree.
+func (t *Tree) Build() *simple.Tree {
+	nodes := make(simple.NodeLookup)
+	parents := make(simple.ParentLookup)
+
+	var build func(node *Node) *simple.Node
+	build = func(node *Node) *simple.Node {
+		var branch *simple.Branch
+		if node.branch != nil {
+			b := node.branch
+			branch = simple.NewBranch(b.splitType, build(b.left), build(b.right), b.leftIndex, b.rightIndex)
+			parents[b.left.id] = node.id
+			parents[b.right.id] = node.id
+		}
+		n := simple.NewNode(node.id, branch)
+		nodes[node.id] = n
+		return n
+	}
+
+	root := build(t.state.root)
+	return simple.NewTree(t.ratioSource, t.ratioIndexXY, t.ratioIndexZY, root, nodes, parents)
+}
+
+// Records the current state in the undo history and returns a copy to be
+// modified. Any edits that were undone are forgotten.
+func (t *Tree) edit() *state {
+	t.undo = append(t.undo, t.state)
+	t.redo = nil
+	t.state = t.state.clone()
+	return t.state
+}
+
+func exprsEqual(a, b htree.Exprs) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,255 @@
+package editable_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/editable"
+	"github.com/scisci/hambidgetree/generators/grid"
+	"github.com/scisci/hambidgetree/generators/randombasic"
+	"github.com/scisci/hambidgetree/golden"
+	"testing"
+)
+
+// Verifies that every node can be found and its parent points back at it.
+func checkConsistent(t *testing.T, tree htree.Tree) {
+	count := 0
+	it := htree.NewNodeIterator(tree.Root())
+	for it.HasNext() {
+		node := it.Next()
+		count++
+
+		if tree.Node(node.ID()) != node {
+			t.Errorf("Node %d lookup doesn't match", node.ID())
+		}
+
+		if branch := node.Branch(); branch != nil {
+			for _, child := range []htree.Node{branch.Left(), branch.Right()} {
+				if parent := tree.Parent(child.ID()); parent == nil || parent.ID() != node.ID() {
+					t.Errorf("Node %d parent should be %d", child.ID(), node.ID())
+				}
+			}
+		}
+	}
+
+	if tree.Parent(tree.Root().ID()) != nil {
+		t.Errorf("Root should not have a parent")
+	}
+
+	// All the regions must be computable
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	if len(regionMap) != count {
+		t.Errorf("Expected %d regions, got %d", count, len(regionMap))
+	}
+}
+
+func TestSplitCollapse(t *testing.T) {
+	ratioSource := golden.RatioSource()
+	squareIndex := htree.FindClosestIndex(ratioSource.Ratios(), 1, 0.0000001)
+
+	tree, err := editable.New(ratioSource, squareIndex, htree.RatioIndexUndefined)
+	if err != nil {
+		t.Fatalf("Failed to create tree %v", err)
+	}
+
+	splits, err := tree.AvailableSplits(tree.Root().ID())
+	if err != nil || len(splits) == 0 {
+		t.Fatalf("Expected splits for the square, got %v", err)
+	}
+
+	left, right, err := tree.Split(tree.Root().ID(), splits[0])
+	if err != nil {
+		t.Fatalf("Failed to split %v", err)
+	}
+
+	if _, _, err := tree.Split(tree.Root().ID(), splits[0]); err != editable.ErrNotLeaf {
+		t.Errorf("Expected splitting a branch to fail, got %v", err)
+	}
+
+	if _, _, err := tree.Split(left, htree.NewVerticalSplit(0, 0)); err != editable.ErrInvalidSplit {
+		t.Errorf("Expected invalid split to fail, got %v", err)
+	}
+
+	leftSplits, _ := tree.AvailableSplits(left)
+	if _, _, err := tree.Split(left, htree.NewInvertedSplit(leftSplits[0])); err != nil {
+		t.Errorf("Expected inverted split to succeed, got %v", err)
+	}
+
+	checkConsistent(t, tree)
+	if n := len(algo.FindLeaves(tree)); n != 3 {
+		t.Errorf("Expected 3 leaves, got %d", n)
+	}
+
+	if err := tree.Collapse(right); err != editable.ErrNotBranch {
+		t.Errorf("Expected collapsing a leaf to fail, got %v", err)
+	}
+
+	if err := tree.Collapse(left); err != nil {
+		t.Errorf("Failed to collapse %v", err)
+	}
+
+	checkConsistent(t, tree)
+	if n := len(algo.FindLeaves(tree)); n != 2 {
+		t.Errorf("Expected 2 leaves after collapse, got %d", n)
+	}
+}
+
+// Creates a square split into a 2x2 grid after levels 2.
+func newGrid(t *testing.T, levels int) *editable.Tree {
+	ratioSource, err := htree.NewBasicRatioSource([]float64{0.5, 1.0, 2.0})
+	if err != nil {
+		t.Fatalf("Failed to create ratio source %v", err)
+	}
+
+	tree, err := editable.New(ratioSource, 1, htree.RatioIndexUndefined)
+	if err != nil {
+		t.Fatalf("Failed to create tree %v", err)
+	}
+
+	for i := 0; i < levels; i++ {
+		for _, leaf := range algo.FindLeaves(tree) {
+			split := htree.NewVerticalSplit(0, 0)
+			if i&1 == 1 {
+				split = htree.NewHorizontalSplit(1, 1)
+			}
+			if _, _, err := tree.Split(leaf.ID(), split); err != nil {
+				t.Fatalf("Failed to split %v", err)
+			}
+		}
+	}
+
+	return tree
+}
+
+func TestSwap(t *testing.T) {
+	tree := newGrid(t, 1)
+
+	root := tree.Root()
+	left := root.Branch().Left().ID()
+	if err := tree.Swap(root.ID()); err != nil {
+		t.Fatalf("Failed to swap %v", err)
+	}
+
+	if tree.Root().Branch().Right().ID() != left {
+		t.Errorf("Expected left child to move right")
+	}
+
+	checkConsistent(t, tree)
+
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	if regionMap[left].AlignedBox().Left() != 0.5 {
+		t.Errorf("Expected swapped child to be on the right, got %v", regionMap[left].AlignedBox())
+	}
+}
+
+func TestReplace(t *testing.T) {
+	ratioSource := golden.RatioSource()
+	gen, err := randombasic.New3D(ratioSource, 1, 1, 10, 7)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+	subtree, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+
+	gen.NumLeaves = 4
+	gen.Seed = 8
+	base, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+
+	tree, err := editable.FromTree(base)
+	if err != nil {
+		t.Fatalf("Failed to create tree %v", err)
+	}
+
+	if err := tree.Replace(tree.Root().ID(), subtree); err != nil {
+		t.Fatalf("Failed to replace %v", err)
+	}
+
+	checkConsistent(t, tree)
+	if n := len(algo.FindLeaves(tree)); n != 10 {
+		t.Errorf("Expected 10 leaves, got %d", n)
+	}
+
+	if err := tree.Replace(tree.Root().ID(), grid.New2D(2)); err != editable.ErrRatioSourceMismatch {
+		t.Errorf("Expected mismatched ratio source to fail, got %v", err)
+	}
+
+	leaf := algo.FindLeaves(tree)[0]
+	region, _ := tree.Region(leaf.ID())
+	if region.RatioIndexXY() != subtree.RatioIndexXY() || region.RatioIndexZY() != subtree.RatioIndexZY() {
+		if err := tree.Replace(leaf.ID(), subtree); err != editable.ErrRatioMismatch {
+			t.Errorf("Expected mismatched ratio to fail, got %v", err)
+		}
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	tree := newGrid(t, 2)
+
+	// Each split of the grid is part of the history
+	undos := 0
+	for tree.CanUndo() {
+		tree.Undo()
+		undos++
+	}
+	if undos != 3 {
+		t.Errorf("Expected 3 splits in the history, got %d", undos)
+	}
+
+	// Start over with a fresh history
+	tree, err := editable.FromTree(newGrid(t, 2).Build())
+	if err != nil {
+		t.Fatalf("Failed to create tree %v", err)
+	}
+
+	if err := tree.Undo(); err != editable.ErrNothingToUndo {
+		t.Errorf("Expected nothing to undo, got %v", err)
+	}
+
+	left := tree.Root().Branch().Left().ID()
+	if err := tree.Collapse(left); err != nil {
+		t.Fatalf("Failed to collapse %v", err)
+	}
+	if err := tree.Swap(tree.Root().ID()); err != nil {
+		t.Fatalf("Failed to swap %v", err)
+	}
+
+	if n := len(algo.FindLeaves(tree)); n != 3 {
+		t.Errorf("Expected 3 leaves, got %d", n)
+	}
+
+	tree.Undo()
+	tree.Undo()
+	if tree.CanUndo() || !tree.CanRedo() {
+		t.Errorf("Expected to be at the start of the history")
+	}
+
+	checkConsistent(t, tree)
+	if n := len(algo.FindLeaves(tree)); n != 4 {
+		t.Errorf("Expected 4 leaves after undo, got %d", n)
+	}
+
+	if err := tree.Redo(); err != nil {
+		t.Errorf("Failed to redo %v", err)
+	}
+	checkConsistent(t, tree)
+	if n := len(algo.FindLeaves(tree)); n != 3 {
+		t.Errorf("Expected 3 leaves after redo, got %d", n)
+	}
+
+	// A new edit clears the redo history
+	if err := tree.Collapse(tree.Root().ID()); err != nil {
+		t.Fatalf("Failed to collapse %v", err)
+	}
+	if tree.CanRedo() {
+		t.Errorf("Expected redo history to be cleared")
+	}
+
+	built := tree.Build()
+	if built.Root().Branch() != nil {
+		t.Errorf("Expected built tree to be a single leaf")
+	}
+}
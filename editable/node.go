@@ -0,0 +1,120 @@
+package editable
+
+import (
+	htree "github.com/scisci/hambidgetree"
+)
+
+type Node struct {
+	id     htree.NodeID
+	branch *Branch
+}
+
+func (n *Node) ID() htree.NodeID {
+	return n.id
+}
+
+func (n *Node) Branch() htree.Branch {
+	if n.branch == nil {
+		return nil
+	}
+
+	return n.branch
+}
+
+type Branch struct {
+	splitType  htree.SplitType
+	left       *Node
+	right      *Node
+	leftIndex  int
+	rightIndex int
+}
+
+func (b *Branch) SplitType() htree.SplitType {
+	return b.splitType
+}
+
+func (b *Branch) Left() htree.Node {
+	return b.left
+}
+
+func (b *Branch) Right() htree.Node {
+	return b.right
+}
+
+func (b *Branch) LeftIndex() int {
+	return b.leftIndex
+}
+
+func (b *Branch) RightIndex() int {
+	return b.rightIndex
+}
+
+// The structure of the tree at a point in time, the history is a stack of
+// these.
+type state struct {
+	root    *Node
+	nodes   map[htree.NodeID]*Node
+	parents map[htree.NodeID]htree.NodeID
+	nextID  htree.NodeID
+}
+
+// Deep copies the state so that edits to the copy don't affect the original.
+func (s *state) clone() *state {
+	c := &state{
+		nodes:   make(map[htree.NodeID]*Node, len(s.nodes)),
+		parents: make(map[htree.NodeID]htree.NodeID, len(s.parents)),
+		nextID:  s.nextID,
+	}
+
+	for id := range s.nodes {
+		c.nodes[id] = &Node{id: id}
+	}
+
+	for id, node := range s.nodes {
+		if node.branch == nil {
+			continue
+		}
+
+		c.nodes[id].branch = &Branch{
+			splitType:  node.branch.splitType,
+			left:       c.nodes[node.branch.left.id],
+			right:      c.nodes[node.branch.right.id],
+			leftIndex:  node.branch.leftIndex,
+			rightIndex: node.branch.rightIndex,
+		}
+	}
+
+	for id, parentID := range s.parents {
+		c.parents[id] = parentID
+	}
+
+	c.root = c.nodes[s.root.id]
+	return c
+}
+
+func (s *state) newNode() *Node {
+	s.nextID++
+	node := &Node{id: s.nextID}
+	s.nodes[node.id] = node
+	return node
+}
+
+// Removes all of the descendants of the node from the lookups.
+func (s *state) removeDescendants(node *Node) {
+	if node.branch == nil {
+		return
+	}
+
+	stack := []*Node{node.branch.left, node.branch.right}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		delete(s.nodes, n.id)
+		delete(s.parents, n.id)
+
+		if n.branch != nil {
+			stack = append(stack, n.branch.left, n.branch.right)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package exact
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+var ErrMixedRadicands = errors.New("Numbers belong to different quadratic fields")
+var ErrDivideByZero = errors.New("Divide by zero")
+var ErrNegativeSqrt = errors.New("Square root of a negative number")
+var ErrIrrationalSqrt = errors.New("Square root of an irrational number")
+var ErrRadicandTooLarge = errors.New("Radicand too large to factor")
+
+// Largest radicand that will be reduced to its square free form.
+const maxRadicand = 1 << 40
+
+// A number in a quadratic field, a + b√n, where a and b are rational and n is
+// a square free integer greater than 1. Rational numbers have b = 0 and
+// n = 0, which lets them combine with numbers of any field.
+//
+// The zero value is 0. Numbers are immutable, every operation returns a new
+// number.
+type Number struct {
+	a *big.Rat
+	b *big.Rat
+	n int64
+}
+
+var ratZero = new(big.Rat)
+
+func rat(r *big.Rat) *big.Rat {
+	if r == nil {
+		return ratZero
+	}
+	return r
+}
+
+// Creates a normalized number, dropping the radicand when b is 0.
+func newNumber(a, b *big.Rat, n int64) Number {
+	if b.Sign() == 0 || n == 0 {
+		return Number{a: a}
+	}
+	return Number{a: a, b: b, n: n}
+}
+
+// Creates a rational number.
+func NewRat(r *big.Rat) Number {
+	return Number{a: new(big.Rat).Set(r)}
+}
+
+// Creates an integer.
+func NewInt(x int64) Number {
+	return Number{a: new(big.Rat).SetInt64(x)}
+}
+
+// Creates a number from a decimal or fraction string such as "0.125" or
+// "1/8".
+func Parse(s string) (Number, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Number{}, errors.New("Invalid number " + s)
+	}
+	return Number{a: r}, nil
+}
+
+// Creates a + b√n, n must be greater than 0. The radicand is reduced so that
+// it is square free.
+func New(a, b *big.Rat, n int64) (Number, error) {
+	root, err := Sqrt(NewInt(n))
+	if err != nil {
+		return Number{}, err
+	}
+
+	irrational, _ := NewRat(b).Mul(root) // Rationals combine with any field
+	return NewRat(a).Add(irrational)
+}
+
+// Returns the rational part a.
+func (x Number) Rational() *big.Rat {
+	return new(big.Rat).Set(rat(x.a))
+}
+
+// Returns the coefficient b of the square root.
+func (x Number) Irrational() *big.Rat {
+	return new(big.Rat).Set(rat(x.b))
+}
+
+// Returns the radicand n, or 0 if the number is rational.
+func (x Number) Radicand() int64 {
+	return x.n
+}
+
+func (x Number) IsRational() bool {
+	return x.n == 0
+}
+
+func (x Number) IsZero() bool {
+	return x.n == 0 && rat(x.a).Sign() == 0
+}
+
+// Returns the field shared by x and y.
+func commonRadicand(x, y Number) (int64, error) {
+	if x.n == 0 {
+		return y.n, nil
+	}
+	if y.n == 0 || x.n == y.n {
+		return x.n, nil
+	}
+	return 0, ErrMixedRadicands
+}
+
+func (x Number) Neg() Number {
+	return newNumber(new(big.Rat).Neg(rat(x.a)), new(big.Rat).Neg(rat(x.b)), x.n)
+}
+
+func (x Number) Add(y Number) (Number, error) {
+	n, err := commonRadicand(x, y)
+	if err != nil {
+		return Number{}, err
+	}
+
+	return newNumber(
+		new(big.Rat).Add(rat(x.a), rat(y.a)),
+		new(big.Rat).Add(rat(x.b), rat(y.b)),
+		n), nil
+}
+
+func (x Number) Sub(y Number) (Number, error) {
+	return x.Add(y.Neg())
+}
+
+func (x Number) Mul(y Number) (Number, error) {
+	n, err := commonRadicand(x, y)
+	if err != nil {
+		return Number{}, err
+	}
+
+	// (a + b√n)(c + d√n) = ac + bdn + (ad + bc)√n
+	a, b := rat(x.a), rat(x.b)
+	c, d := rat(y.a), rat(y.b)
+
+	bdn := new(big.Rat).Mul(b, d)
+	bdn.Mul(bdn, new(big.Rat).SetInt64(n))
+
+	rational := new(big.Rat).Mul(a, c)
+	rational.Add(rational, bdn)
+
+	irrational := new(big.Rat).Mul(a, d)
+	irrational.Add(irrational, new(big.Rat).Mul(b, c))
+
+	return newNumber(rational, irrational, n), nil
+}
+
+// Returns 1 / x.
+func (x Number) Inverse() (Number, error) {
+	if x.IsZero() {
+		return Number{}, ErrDivideByZero
+	}
+
+	// 1 / (a + b√n) = (a - b√n) / (a² - b²n)
+	a, b := rat(x.a), rat(x.b)
+
+	denom := new(big.Rat).Mul(b, b)
+	denom.Mul(denom, new(big.Rat).SetInt64(x.n))
+	denom.Sub(new(big.Rat).Mul(a, a), denom)
+
+	return newNumber(
+		new(big.Rat).Quo(a, denom),
+		new(big.Rat).Neg(new(big.Rat).Quo(b, denom)),
+		x.n), nil
+}
+
+func (x Number) Quo(y Number) (Number, error) {
+	if _, err := commonRadicand(x, y); err != nil {
+		return Number{}, err
+	}
+
+	inv, err := y.Inverse()
+	if err != nil {
+		return Number{}, err
+	}
+
+	return x.Mul(inv)
+}
+
+// Returns x raised to an integer power.
+func (x Number) Pow(exp int) (Number, error) {
+	if exp < 0 {
+		inv, err := x.Inverse()
+		if err != nil {
+			return Number{}, err
+		}
+		return inv.Pow(-exp)
+	}
+
+	result := NewInt(1)
+	for i := 0; i < exp; i++ {
+		result, _ = result.Mul(x) // Same field, can't fail
+	}
+	return result, nil
+}
+
+// Returns -1, 0 or +1 depending on the sign of x.
+func (x Number) Sign() int {
+	sa, sb := rat(x.a).Sign(), rat(x.b).Sign()
+	if sb == 0 {
+		return sa
+	}
+	if sa == 0 || sa == sb {
+		return sb
+	}
+
+	// The parts have opposite signs, compare a² with b²n
+	a2 := new(big.Rat).Mul(rat(x.a), rat(x.a))
+	b2n := new(big.Rat).Mul(rat(x.b), rat(x.b))
+	b2n.Mul(b2n, new(big.Rat).SetInt64(x.n))
+	return sa * a2.Cmp(b2n)
+}
+
+// Compares x and y returning -1, 0 or +1. Numbers of different fields can't
+// be compared exactly, they fall back to comparing their float values.
+func (x Number) Cmp(y Number) int {
+	diff, err := x.Sub(y)
+	if err != nil {
+		fx, fy := x.Float64(), y.Float64()
+		if fx < fy {
+			return -1
+		} else if fx > fy {
+			return 1
+		}
+		return 0
+	}
+	return diff.Sign()
+}
+
+func (x Number) Equal(y Number) bool {
+	return x.n == y.n && rat(x.a).Cmp(rat(y.a)) == 0 && rat(x.b).Cmp(rat(y.b)) == 0
+}
+
+func (x Number) Float64() float64 {
+	a, _ := rat(x.a).Float64()
+	if x.n == 0 {
+		return a
+	}
+	b, _ := rat(x.b).Float64()
+	return a + b*math.Sqrt(float64(x.n))
+}
+
+// Returns the number as an expression that can be solved by the expr
+// package, e.g. "1/2+1/2*SQRT(5)".
+func (x Number) String() string {
+	a, b := rat(x.a), rat(x.b)
+
+	if x.n == 0 {
+		return a.RatString()
+	}
+
+	root := "SQRT(" + strconv.FormatInt(x.n, 10) + ")"
+	var irrational string
+	switch {
+	case b.Cmp(big.NewRat(1, 1)) == 0:
+		irrational = root
+	case b.Cmp(big.NewRat(-1, 1)) == 0:
+		irrational = "-1*" + root
+	default:
+		irrational = b.RatString() + "*" + root
+	}
+
+	if a.Sign() == 0 {
+		return irrational
+	}
+
+	if b.Sign() < 0 {
+		return a.RatString() + irrational
+	}
+
+	return a.RatString() + "+" + irrational
+}
+
+// Returns the square root of a non-negative rational number.
+func Sqrt(x Number) (Number, error) {
+	if !x.IsRational() {
+		return Number{}, ErrIrrationalSqrt
+	}
+
+	r := rat(x.a)
+	if r.Sign() < 0 {
+		return Number{}, ErrNegativeSqrt
+	}
+
+	if r.Sign() == 0 {
+		return Number{}, nil
+	}
+
+	// √(p/q) = √(pq)/q, then pull the square factors out of pq
+	pq := new(big.Int).Mul(r.Num(), r.Denom())
+	if !pq.IsInt64() || pq.Int64() > maxRadicand {
+		return Number{}, ErrRadicandTooLarge
+	}
+
+	square, free := squareFree(pq.Int64())
+	coefficient := new(big.Rat).SetFrac(big.NewInt(square), r.Denom())
+
+	if free == 1 {
+		return Number{a: coefficient}, nil
+	}
+
+	return newNumber(new(big.Rat), coefficient, free), nil
+}
+
+// Splits n into s²f where f is square free, returning s and f.
+func squareFree(n int64) (s, f int64) {
+	s, f = 1, 1
+	for p := int64(2); p*p <= n; p++ {
+		for n%(p*p) == 0 {
+			n /= p * p
+			s *= p
+		}
+		if n%p == 0 {
+			n /= p
+			f *= p
+		}
+	}
+	return s, f * n
+}
@@ -0,0 +1,87 @@
+package exact
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func mustSqrt(t *testing.T, n int64) Number {
+	root, err := Sqrt(NewInt(n))
+	if err != nil {
+		t.Fatalf("Failed to take square root of %d: %v", n, err)
+	}
+	return root
+}
+
+func TestSqrt(t *testing.T) {
+	var tests = []struct {
+		n        int64
+		expected string
+	}{
+		{4, "2"},
+		{5, "SQRT(5)"},
+		{20, "2*SQRT(5)"},
+		{72, "6*SQRT(2)"},
+		{0, "0"},
+	}
+
+	for _, test := range tests {
+		if got := mustSqrt(t, test.n).String(); got != test.expected {
+			t.Errorf("Sqrt(%d) expected %s, got %s", test.n, test.expected, got)
+		}
+	}
+
+	quarter, _ := Sqrt(NewRat(big.NewRat(5, 4)))
+	if quarter.String() != "1/2*SQRT(5)" {
+		t.Errorf("Sqrt(5/4) expected 1/2*SQRT(5), got %s", quarter)
+	}
+
+	if _, err := Sqrt(NewInt(-1)); err != ErrNegativeSqrt {
+		t.Errorf("Expected negative sqrt error, got %v", err)
+	}
+}
+
+func TestGoldenIdentities(t *testing.T) {
+	// φ = (1 + √5) / 2
+	phi, _ := NewInt(1).Add(mustSqrt(t, 5))
+	phi, _ = phi.Quo(NewInt(2))
+
+	if math.Abs(phi.Float64()-math.Phi) > 1e-15 {
+		t.Errorf("Expected phi, got %f", phi.Float64())
+	}
+
+	// φ² = φ + 1
+	phi2, _ := phi.Mul(phi)
+	phiPlusOne, _ := phi.Add(NewInt(1))
+	if !phi2.Equal(phiPlusOne) {
+		t.Errorf("Expected φ² = φ + 1, got %s and %s", phi2, phiPlusOne)
+	}
+
+	// 1 / φ = φ - 1
+	inv, _ := phi.Inverse()
+	phiMinusOne, _ := phi.Sub(NewInt(1))
+	if !inv.Equal(phiMinusOne) {
+		t.Errorf("Expected 1/φ = φ - 1, got %s and %s", inv, phiMinusOne)
+	}
+
+	if phi.Cmp(NewInt(2)) != -1 || phi.Cmp(NewRat(big.NewRat(3, 2))) != 1 {
+		t.Errorf("Expected 1.5 < φ < 2")
+	}
+
+	if _, err := phi.Add(mustSqrt(t, 2)); err != ErrMixedRadicands {
+		t.Errorf("Expected mixed radicands to fail, got %v", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// 2 - √5 < 0, 3 - √5 > 0
+	root5 := mustSqrt(t, 5)
+	a, _ := NewInt(2).Sub(root5)
+	b, _ := NewInt(3).Sub(root5)
+	c, _ := root5.Sub(NewInt(2))
+
+	if a.Sign() != -1 || b.Sign() != 1 || c.Sign() != 1 {
+		t.Errorf("Unexpected signs %d %d %d", a.Sign(), b.Sign(), c.Sign())
+	}
+}
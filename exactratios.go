@@ -0,0 +1,321 @@
+package hambidgetree
+
+import (
+	"github.com/scisci/hambidgetree/exact"
+	exprSolver "github.com/scisci/hambidgetree/expr"
+	"math/big"
+	"sort"
+)
+
+// A ratio source that also knows the exact value of each ratio. Exact ratio
+// sources let complements, inverses and splits be found by comparing values
+// exactly rather than within an epsilon.
+type ExactRatioSource interface {
+	RatioSource
+	ExactRatios() []exact.Number
+}
+
+// Creates a ratio source based on a list of expressions that are evaluated
+// exactly. All of the irrational ratios must belong to the same quadratic
+// field, i.e. only use the square root of one number.
+func NewExactRatioSource(exprs []string) (ExactRatioSource, error) {
+	var tmp exactExprValues
+	var radicand int64
+	for _, expr := range exprs {
+		value, err := exprSolver.SolveExact(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		if !value.IsRational() {
+			if radicand != 0 && radicand != value.Radicand() {
+				return nil, exact.ErrMixedRadicands
+			}
+			radicand = value.Radicand()
+		}
+
+		tmp = append(tmp, exactExprValue{expr: expr, value: value})
+	}
+	sort.Sort(tmp)
+
+	exactValues := make([]exact.Number, len(tmp))
+	sortedValues := make([]float64, len(tmp))
+	sortedExprs := make([]string, len(tmp))
+	for i, exprValue := range tmp {
+		if i > 0 && exprValue.value.Equal(exactValues[i-1]) {
+			return nil, ErrRatiosContainsDuplicates
+		}
+		exactValues[i] = exprValue.value
+		sortedValues[i] = exprValue.value.Float64()
+		sortedExprs[i] = exprValue.expr
+	}
+
+	ratios, err := NewRatios(sortedValues)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exactRatioSource{
+		basicRatioSource: basicRatioSource{
+			ratios: ratios,
+			exprs:  Exprs(sortedExprs),
+		},
+		exact: exactValues,
+	}, nil
+}
+
+type exactRatioSource struct {
+	basicRatioSource
+	exact []exact.Number
+}
+
+func (ratioSource *exactRatioSource) ExactRatios() []exact.Number {
+	return ratioSource.exact
+}
+
+type exactExprValue struct {
+	value exact.Number
+	expr  string
+}
+
+type exactExprValues []exactExprValue
+
+func (a exactExprValues) Len() int           { return len(a) }
+func (a exactExprValues) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a exactExprValues) Less(i, j int) bool { return a[i].value.Cmp(a[j].value) < 0 }
+
+// Binary search for the index of the value, returns RatioIndexUndefined if the
+// value is not one of the ratios.
+func FindExactIndex(ratios []exact.Number, value exact.Number) int {
+	i := sort.Search(len(ratios), func(i int) bool {
+		return ratios[i].Cmp(value) >= 0
+	})
+
+	if i < len(ratios) && ratios[i].Equal(value) {
+		return i
+	}
+
+	return RatioIndexUndefined
+}
+
+// Same as FindClosestIndex but compares the value exactly against the ratios,
+// if it is the same distance from two of them the smaller index wins.
+func FindClosestExactIndex(ratios []exact.Number, ratio float64) int {
+	r := new(big.Rat)
+	if len(ratios) == 0 || r.SetFloat64(ratio) == nil {
+		return RatioIndexUndefined
+	}
+	value := exact.NewRat(r)
+
+	i := sort.Search(len(ratios), func(i int) bool {
+		return ratios[i].Cmp(value) >= 0
+	})
+
+	if i == len(ratios) {
+		return i - 1
+	}
+	if i == 0 {
+		return 0
+	}
+
+	above, err := ratios[i].Sub(value)
+	if err != nil {
+		return RatioIndexUndefined
+	}
+	below, err := value.Sub(ratios[i-1])
+	if err != nil {
+		return RatioIndexUndefined
+	}
+
+	if below.Cmp(above) <= 0 {
+		return i - 1
+	}
+	return i
+}
+
+// Finds the index of the ratio closest to the value, comparing exactly if the
+// ratio source is an ExactRatioSource and within epsilon otherwise.
+func FindClosestRatioSourceIndex(ratioSource RatioSource, ratio, epsilon float64) int {
+	if exactSource, ok := ratioSource.(ExactRatioSource); ok {
+		return FindClosestExactIndex(exactSource.ExactRatios(), ratio)
+	}
+
+	return FindClosestIndex(ratioSource.Ratios(), ratio, epsilon)
+}
+
+func FindExactInverseIndex(ratios []exact.Number, index int) int {
+	inverse, err := ratios[index].Inverse()
+	if err != nil {
+		return RatioIndexUndefined
+	}
+
+	return FindExactIndex(ratios, inverse)
+}
+
+// Same as NewComplements but compares ratios exactly.
+func NewExactComplements(ratios []exact.Number) (Complements, error) {
+	n := len(ratios)
+	inverses := make([]int, n)
+	for i := 0; i < n; i++ {
+		inverses[i] = FindExactInverseIndex(ratios, i)
+		if inverses[i] == RatioIndexUndefined {
+			return nil, ErrMissingInverse
+		}
+	}
+
+	complements := make([][]Split, n)
+
+	for i := 0; i < n; i++ {
+		// Split the width, the height is always considered to be unity
+		ratio := ratios[i]
+		for j := 0; j < n && ratios[j].Cmp(ratio) < 0; j++ {
+			if k := findExactComplement(ratios, ratio, j); k >= 0 {
+				complements[i] = append(complements[i], NewVerticalSplit(j, k))
+			}
+		}
+
+		// Split the height by splitting the width of the inverse, the resulting
+		// ratios are the inverses since they are stacked vertically
+		ratio = ratios[inverses[i]]
+		for j := 0; j < n && ratios[j].Cmp(ratio) < 0; j++ {
+			if k := findExactComplement(ratios, ratio, j); k >= 0 {
+				top, bot := inverses[j], inverses[k]
+				if top > bot {
+					top, bot = bot, top
+				}
+				complements[i] = append(complements[i], NewHorizontalSplit(top, bot))
+			}
+		}
+	}
+
+	return Complements(complements), nil
+}
+
+// Returns the index k >= j such that ratios[j] + ratios[k] == ratio.
+func findExactComplement(ratios []exact.Number, ratio exact.Number, j int) int {
+	rest, err := ratio.Sub(ratios[j])
+	if err != nil {
+		return RatioIndexUndefined
+	}
+
+	k := FindExactIndex(ratios, rest)
+	if k < j {
+		return RatioIndexUndefined
+	}
+
+	return k
+}
+
+// Creates the complements of the ratio source, comparing exactly if it is an
+// ExactRatioSource and within epsilon otherwise.
+func NewRatioSourceComplements(ratioSource RatioSource, epsilon float64) (Complements, error) {
+	if exactSource, ok := ratioSource.(ExactRatioSource); ok {
+		return NewExactComplements(exactSource.ExactRatios())
+	}
+
+	return NewComplements(ratioSource.Ratios(), epsilon)
+}
+
+// Same as SplitRegionHorizontal but finds the zy ratios of the children
+// exactly.
+func SplitRegionHorizontalExact(ratios Ratios, exactRatios []exact.Number, region *Region, leftIndex, rightIndex int) (left, right *Region) {
+	ratioIndexXY := region.RatioIndexXY()
+	ratioIndexZY := region.RatioIndexZY()
+
+	leftRatioIndexZY := ratioIndexZY
+	rightRatioIndexZY := ratioIndexZY
+
+	if IsRatioIndexDefined(ratioIndexZY) {
+		leftRatioIndexZY = exactSplitRatioIndexZY(exactRatios, ratioIndexXY, ratioIndexZY, leftIndex)
+		rightRatioIndexZY = exactSplitRatioIndexZY(exactRatios, ratioIndexXY, ratioIndexZY, rightIndex)
+		if leftRatioIndexZY < 0 || rightRatioIndexZY < 0 {
+			panic("ZY Ratio is not one of the supported ratios!")
+		}
+	}
+
+	return splitRegionHorizontal(ratios, region, leftIndex, rightIndex, leftRatioIndexZY, rightRatioIndexZY)
+}
+
+// A horizontal split child with ratio xy' has a height of xy / xy', so its zy
+// ratio is zy * xy' / xy.
+func exactSplitRatioIndexZY(ratios []exact.Number, ratioIndexXY, ratioIndexZY, childIndex int) int {
+	zy, err := ratios[ratioIndexZY].Mul(ratios[childIndex])
+	if err != nil {
+		return RatioIndexUndefined
+	}
+
+	zy, err = zy.Quo(ratios[ratioIndexXY])
+	if err != nil {
+		return RatioIndexUndefined
+	}
+
+	return FindExactIndex(ratios, zy)
+}
+
+// Returns the index of a / b, or RatioIndexUndefined if it isn't a ratio.
+func findExactQuoIndex(ratios []exact.Number, a, b exact.Number) int {
+	value, err := a.Quo(b)
+	if err != nil {
+		return RatioIndexUndefined
+	}
+
+	return FindExactIndex(ratios, value)
+}
+
+// Same as AvailableSplits but checks the ratios of the children exactly.
+func AvailableExactSplits(ratios []exact.Number, complements Complements, ratioIndexXY, ratioIndexZY int) []Split {
+	if !IsRatioIndexDefined(ratioIndexZY) {
+		return complements[ratioIndexXY]
+	}
+
+	// A horizontal child's zy ratio is zy * xy' / xy, a vertical child's zx ratio
+	// is zy / xy' and a depth child's zx ratio is zy' / xy.
+	xyRatio := ratios[ratioIndexXY]
+	zyRatio := ratios[ratioIndexZY]
+
+	fits := func(split Split, index int) bool {
+		switch split.Type() {
+		case SplitTypeHorizontal:
+			return IsRatioIndexDefined(exactSplitRatioIndexZY(ratios, ratioIndexXY, ratioIndexZY, index))
+		case SplitTypeVertical:
+			return IsRatioIndexDefined(findExactQuoIndex(ratios, zyRatio, ratios[index]))
+		}
+		panic("What type?")
+	}
+
+	var splits []Split
+
+	for _, xySplit := range complements[ratioIndexXY] {
+		if fits(xySplit, xySplit.LeftIndex()) && fits(xySplit, xySplit.RightIndex()) {
+			splits = append(splits, xySplit)
+		}
+	}
+
+	for _, zySplit := range complements[ratioIndexZY] {
+		if zySplit.Type() != SplitTypeVertical {
+			continue
+		}
+
+		if !IsRatioIndexDefined(findExactQuoIndex(ratios, ratios[zySplit.LeftIndex()], xyRatio)) {
+			continue
+		}
+
+		if !IsRatioIndexDefined(findExactQuoIndex(ratios, ratios[zySplit.RightIndex()], xyRatio)) {
+			continue
+		}
+
+		splits = append(splits, NewDepthSplit(zySplit.LeftIndex(), zySplit.RightIndex()))
+	}
+
+	return splits
+}
+
+// Returns the splits available to a region, comparing exactly if the ratio
+// source is an ExactRatioSource and within epsilon otherwise.
+func AvailableRatioSourceSplits(ratioSource RatioSource, complements Complements, ratioIndexXY, ratioIndexZY int, epsilon float64) []Split {
+	if exactSource, ok := ratioSource.(ExactRatioSource); ok {
+		return AvailableExactSplits(exactSource.ExactRatios(), complements, ratioIndexXY, ratioIndexZY)
+	}
+
+	return AvailableSplits(ratioSource.Ratios(), complements, ratioIndexXY, ratioIndexZY, epsilon)
+}
@@ -0,0 +1,155 @@
+package hambidgetree_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/builder"
+	"github.com/scisci/hambidgetree/golden"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestExactRatioSource(t *testing.T) {
+	exactSource, err := htree.NewExactRatioSource(golden.Exprs)
+	if err != nil {
+		t.Fatalf("Failed to create exact ratio source %v", err)
+	}
+
+	floatSource := golden.RatioSource()
+	exactRatios := exactSource.Ratios()
+	floatRatios := floatSource.Ratios()
+	if len(exactRatios) != len(floatRatios) {
+		t.Fatalf("Expected %d ratios, got %d", len(floatRatios), len(exactRatios))
+	}
+
+	for i := range floatRatios {
+		if math.Abs(exactRatios[i]-floatRatios[i]) > 0.000000001 {
+			t.Errorf("Ratio %d expected %f, got %f", i, floatRatios[i], exactRatios[i])
+		}
+		if exactSource.Exprs()[i] != floatSource.Exprs()[i] {
+			t.Errorf("Expr %d expected %s, got %s", i, floatSource.Exprs()[i], exactSource.Exprs()[i])
+		}
+		if inv := htree.FindExactInverseIndex(exactSource.ExactRatios(), i); inv != htree.FindInverseRatioIndex(floatRatios, i, 0.0000001) {
+			t.Errorf("Ratio %d inverse doesn't match float inverse, got %d", i, inv)
+		}
+	}
+
+	// Exact complements must match the ones found with an epsilon
+	exactComplements, err := htree.NewRatioSourceComplements(exactSource, 0)
+	if err != nil {
+		t.Fatalf("Failed to create exact complements %v", err)
+	}
+
+	floatComplements, err := htree.NewComplements(floatRatios, 0.0000001)
+	if err != nil {
+		t.Fatalf("Failed to create complements %v", err)
+	}
+
+	for i := range floatComplements {
+		if len(exactComplements[i]) != len(floatComplements[i]) {
+			t.Errorf("Ratio %d expected %d complements, got %d", i, len(floatComplements[i]), len(exactComplements[i]))
+			continue
+		}
+		for j := range floatComplements[i] {
+			if exactComplements[i][j] != floatComplements[i][j] {
+				t.Errorf("Ratio %d complement %d expected %v, got %v", i, j, floatComplements[i][j], exactComplements[i][j])
+			}
+		}
+	}
+}
+
+func TestExactRatioSourceErrors(t *testing.T) {
+	if _, err := htree.NewExactRatioSource([]string{"1", "SQRT(2)", "SQRT(5)"}); err == nil {
+		t.Errorf("Expected mixed fields to fail")
+	}
+
+	if _, err := htree.NewExactRatioSource([]string{"1/2", "0.5"}); err != htree.ErrRatiosContainsDuplicates {
+		t.Errorf("Expected duplicates to fail, got %v", err)
+	}
+}
+
+func TestExactSplit3D(t *testing.T) {
+	exactSource, err := htree.NewExactRatioSource(golden.Exprs)
+	if err != nil {
+		t.Fatalf("Failed to create exact ratio source %v", err)
+	}
+
+	complements, err := htree.NewRatioSourceComplements(exactSource, 0)
+	if err != nil {
+		t.Fatalf("Failed to create complements %v", err)
+	}
+
+	ratios := exactSource.Ratios()
+	square := htree.FindClosestIndex(ratios, 1, 0.0000001)
+
+	// Split a cube with every available horizontal split and verify the
+	// exact zy ratios match the geometry.
+	count := 0
+	for _, split := range htree.AvailableSplits(ratios, complements, square, square, 0.0000001) {
+		if split.Type() != htree.SplitTypeHorizontal {
+			continue
+		}
+
+		count++
+		b := builder.New3D(exactSource, square, square)
+		left, right := b.Branch(b.Leaves()[0].ID(), split.Type(), split.LeftIndex(), split.RightIndex())
+		_, regionMap := b.Build()
+
+		for _, leaf := range []htree.Leaf{left, right} {
+			dim := regionMap[leaf.ID()].AlignedBox()
+			zy := dim.Depth() / dim.Height()
+			if math.Abs(zy-ratios[leaf.RatioIndexZY()]) > 0.0000001 {
+				t.Errorf("Leaf zy ratio %f doesn't match ratio %f", zy, ratios[leaf.RatioIndexZY()])
+			}
+		}
+	}
+
+	if count == 0 {
+		t.Errorf("Expected the cube to have horizontal splits")
+	}
+}
+
+func TestExactAvailableSplits(t *testing.T) {
+	exactSource, err := htree.NewExactRatioSource(golden.Exprs)
+	if err != nil {
+		t.Fatalf("Failed to create exact ratio source %v", err)
+	}
+
+	complements, err := htree.NewRatioSourceComplements(exactSource, 0)
+	if err != nil {
+		t.Fatalf("Failed to create complements %v", err)
+	}
+
+	// On the golden set the exact splits match the ones found with an epsilon
+	ratios := exactSource.Ratios()
+	count := 0
+	for xy := range ratios {
+		for zy := range ratios {
+			exactSplits := htree.AvailableRatioSourceSplits(exactSource, complements, xy, zy, 0)
+			floatSplits := htree.AvailableSplits(ratios, complements, xy, zy, 0.0000001)
+			if !reflect.DeepEqual(exactSplits, floatSplits) {
+				t.Errorf("Ratios %d, %d expected splits %v, got %v", xy, zy, floatSplits, exactSplits)
+			}
+			count += len(exactSplits)
+		}
+	}
+
+	if count == 0 {
+		t.Errorf("Expected some 3D splits")
+	}
+}
+
+func TestFindClosestExactIndex(t *testing.T) {
+	exactSource, err := htree.NewExactRatioSource(golden.Exprs)
+	if err != nil {
+		t.Fatalf("Failed to create exact ratio source %v", err)
+	}
+
+	ratios := exactSource.Ratios()
+	for _, value := range []float64{0, 0.5, 1, 1.618, 1.6180339887, 2.5, 100} {
+		expected := htree.FindClosestIndex(ratios, value, htree.CalculateRatiosEpsilon(ratios))
+		if index := htree.FindClosestRatioSourceIndex(exactSource, value, 0); index != expected {
+			t.Errorf("Value %f expected index %d, got %d", value, expected, index)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package expr
+
+import (
+	"errors"
+	"github.com/scisci/hambidgetree/exact"
+	"strings"
+)
+
+var ErrNotExact = errors.New("Expression can't be evaluated exactly")
+
+// Constants that have an exact representation in a quadratic field.
+var exactConsts = map[string]string{
+	"PHI":   "(1+SQRT(5))/2",
+	"SQRT2": "SQRT(2)",
+}
+
+// SolvePostfixExact evaluates the expression converted to postfix using exact
+// arithmetic. Only the arithmetic operators, integer powers, SQRT of rational
+// numbers and the constants in exactConsts are supported.
+func SolvePostfixExact(tokens Stack) (exact.Number, error) {
+	var stack []exact.Number
+	pop := func() (exact.Number, error) {
+		if len(stack) == 0 {
+			return exact.Number{}, ErrNotExact
+		}
+		value := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return value, nil
+	}
+
+	for _, v := range tokens.Values {
+		switch v.Type {
+		case NUMBER:
+			value, err := exact.Parse(v.Value)
+			if err != nil {
+				return exact.Number{}, err
+			}
+			stack = append(stack, value)
+		case FUNCTION:
+			value, err := SolveFunctionExact(v.Value)
+			if err != nil {
+				return exact.Number{}, err
+			}
+			stack = append(stack, value)
+		case CONSTANT:
+			s, ok := exactConsts[v.Value]
+			if !ok {
+				return exact.Number{}, ErrNotExact
+			}
+			value, err := SolveExact(s)
+			if err != nil {
+				return exact.Number{}, err
+			}
+			stack = append(stack, value)
+		case OPERATOR:
+			y, err := pop()
+			if err != nil {
+				return exact.Number{}, err
+			}
+			// Like SolvePostfix, a missing left operand is treated as 0
+			x, err := pop()
+			if err != nil {
+				x = exact.Number{}
+			}
+
+			var result exact.Number
+			switch v.Value {
+			case "+":
+				result, err = x.Add(y)
+			case "-":
+				result, err = x.Sub(y)
+			case "*":
+				result, err = x.Mul(y)
+			case "/":
+				result, err = x.Quo(y)
+			case "^":
+				result, err = powExact(x, y)
+			default:
+				err = ErrNotExact
+			}
+			if err != nil {
+				return exact.Number{}, err
+			}
+			stack = append(stack, result)
+		}
+	}
+
+	if len(stack) != 1 {
+		return exact.Number{}, ErrNotExact
+	}
+
+	return stack[0], nil
+}
+
+func powExact(x, y exact.Number) (exact.Number, error) {
+	exp := y.Rational()
+	if !y.IsRational() || !exp.IsInt() || !exp.Num().IsInt64() {
+		return exact.Number{}, ErrNotExact
+	}
+
+	return x.Pow(int(exp.Num().Int64()))
+}
+
+// SolveFunctionExact returns the exact answer of a function found within an
+// expression, only SQRT is supported.
+func SolveFunctionExact(s string) (exact.Number, error) {
+	fType := s[:strings.Index(s, "(")]
+	args := s[strings.Index(s, "(")+1 : strings.LastIndex(s, ")")]
+
+	if fType != "SQRT" {
+		return exact.Number{}, ErrNotExact
+	}
+
+	arg, err := SolveExact(args)
+	if err != nil {
+		return exact.Number{}, err
+	}
+
+	return exact.Sqrt(arg)
+}
+
+// Solves the expression exactly, see SolvePostfixExact.
+func SolveExact(s string) (exact.Number, error) {
+	p := NewParser(strings.NewReader(s))
+	stack, err := p.Parse()
+	if err != nil {
+		return exact.Number{}, err
+	}
+	stack = ShuntingYard(stack)
+	return SolvePostfixExact(stack)
+}
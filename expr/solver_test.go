@@ -28,3 +28,44 @@ func TestSolver(t *testing.T) {
 		}
 	}
 }
+
+var exactTests = []struct {
+	Expr   string
+	Result string
+}{
+	{"1/2", "1/2"},
+	{"0.125", "1/8"},
+	{"1 / (SQRT(5) + 5)", "1/4-1/20*SQRT(5)"},
+	{"(SQRT(5)+1)/2", "1/2+1/2*SQRT(5)"},
+	{"PHI^2 - PHI", "1"},
+	{"SQRT(20)/2", "SQRT(5)"},
+	{"(2+4*SQRT(5))/SQRT(5)", "4+2/5*SQRT(5)"},
+}
+
+func TestSolverExact(t *testing.T) {
+	for i, test := range exactTests {
+		res, err := SolveExact(test.Expr)
+		if err != nil {
+			t.Errorf("Test %d failed to solve %v", i, err)
+			continue
+		}
+		if res.String() != test.Result {
+			t.Errorf("Test %d failed, expected %s, got %s", i, test.Result, res)
+		}
+
+		// The string form must solve to the same number
+		again, err := SolveExact(res.String())
+		if err != nil || !again.Equal(res) {
+			t.Errorf("Test %d failed to round trip %s, got %s", i, res, again)
+		}
+
+		value, _ := Solve(test.Expr)
+		if math.Abs(value-res.Float64()) > 0.000000001 {
+			t.Errorf("Test %d exact value %f doesn't match %f", i, res.Float64(), value)
+		}
+	}
+
+	if _, err := SolveExact("PI"); err != ErrNotExact {
+		t.Errorf("Expected PI to fail, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package factory
+
+import (
+	"encoding/json"
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/attributors"
+)
+
+// The version of the attributes section written by MarshalDocument.
+const AttributesVersion = 1
+
+var ErrUnsupportedAttributesVersion = errors.New("Unsupported attributes version")
+var ErrUnknownAttributeNode = errors.New("Attributes refer to a node that isn't in the tree")
+
+// A tree stored along with the attributes of its nodes and the attributors
+// that produced them, so they can't get out of sync.
+type Document struct {
+	Tree        htree.Tree
+	Attributes  *attributors.NodeAttributer
+	Attributors []*attributors.StageSpec
+}
+
+// The optional attributes section of the JSON wrapper. Documents written
+// before it existed simply have no attributes.
+type jsonAttributes struct {
+	Version     int                      `json:"version"`
+	Nodes       json.RawMessage          `json:"nodes"`
+	Attributors []*attributors.StageSpec `json:"attributors,omitempty"`
+}
+
+// Encodes the document in the same format as MarshalJSON, adding an
+// attributes section if there are any attributes or attributors.
+func MarshalDocument(doc *Document) ([]byte, error) {
+	wrapper, err := newSimpleWrapper(doc.Tree)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := doc.Attributes
+	if attrs == nil {
+		attrs = attributors.NewNodeAttributer()
+	}
+
+	if len(attrs.Nodes()) > 0 || len(doc.Attributors) > 0 {
+		nodes, err := json.Marshal(attrs)
+		if err != nil {
+			return nil, err
+		}
+
+		wrapper.Attributes = &jsonAttributes{
+			Version:     AttributesVersion,
+			Nodes:       nodes,
+			Attributors: doc.Attributors,
+		}
+	}
+
+	return json.Marshal(wrapper)
+}
+
+// Decodes a document written by MarshalDocument or MarshalJSON, documents
+// without attributes have an empty NodeAttributer.
+func UnmarshalDocument(data []byte) (*Document, error) {
+	var wrapper *jsonWrapper
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return nil, err
+	}
+
+	tree, err := wrapper.unmarshalTree()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		Tree:       tree,
+		Attributes: attributors.NewNodeAttributer(),
+	}
+
+	if wrapper.Attributes == nil {
+		return doc, nil
+	}
+
+	if wrapper.Attributes.Version != AttributesVersion {
+		return nil, ErrUnsupportedAttributesVersion
+	}
+
+	ids := make(map[htree.NodeID]bool)
+	it := htree.NewNodeIterator(tree.Root())
+	for it.HasNext() {
+		ids[it.Next().ID()] = true
+	}
+
+	if err := unmarshalNodes(wrapper.Attributes, doc.Attributes); err != nil {
+		return nil, err
+	}
+
+	for _, id := range doc.Attributes.Nodes() {
+		if !ids[id] {
+			return nil, ErrUnknownAttributeNode
+		}
+	}
+
+	doc.Attributors = wrapper.Attributes.Attributors
+	return doc, nil
+}
+
+// Decodes the typed values of the nodes of the attributes section, a section
+// may only have attributors.
+func unmarshalNodes(section *jsonAttributes, attrs *attributors.NodeAttributer) error {
+	if len(section.Nodes) == 0 || string(section.Nodes) == "null" {
+		return nil
+	}
+
+	return json.Unmarshal(section.Nodes, attrs)
+}
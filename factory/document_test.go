@@ -0,0 +1,134 @@
+package factory_test
+
+import (
+	"encoding/json"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/attributors"
+	_ "github.com/scisci/hambidgetree/attributors/all"
+	"github.com/scisci/hambidgetree/attributors/neighbor"
+	"github.com/scisci/hambidgetree/factory"
+	"github.com/scisci/hambidgetree/generators/grid"
+	"reflect"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	tree := grid.New2D(4)
+	pipeline := attributors.NewPipeline().Add(neighbor.NewHasNeighborAttributor(5, 2, 1))
+	attrs, err := pipeline.Run(tree)
+	if err != nil {
+		t.Fatalf("Failed to run pipeline %v", err)
+	}
+	attrs.SetAttribute(tree.Root().ID(), "name", "root")
+	attrs.SetValue(tree.Root().ID(), "weight", attributors.FloatValue(0.5))
+
+	spec, err := pipeline.Spec()
+	if err != nil {
+		t.Fatalf("Failed to get pipeline spec %v", err)
+	}
+
+	data, err := factory.MarshalDocument(&factory.Document{
+		Tree:        tree,
+		Attributes:  attrs,
+		Attributors: spec.Stages,
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal document %v", err)
+	}
+
+	// The tree alone can still be read
+	if _, err := factory.UnmarshalJSON(data); err != nil {
+		t.Fatalf("Failed to unmarshal tree %v", err)
+	}
+
+	doc, err := factory.UnmarshalDocument(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal document %v", err)
+	}
+
+	if algo.HashString(doc.Tree) != algo.HashString(tree) {
+		t.Errorf("Expected the same tree")
+	}
+
+	if !reflect.DeepEqual(doc.Attributes.Nodes(), attrs.Nodes()) {
+		t.Errorf("Expected nodes %v, got %v", attrs.Nodes(), doc.Attributes.Nodes())
+	}
+
+	if keys := doc.Attributes.Keys(); !reflect.DeepEqual(keys, []string{neighbor.HasNeighborAttr, "name", "weight"}) {
+		t.Errorf("Expected keys of both attributes, got %v", keys)
+	}
+
+	for _, id := range attrs.Nodes() {
+		if !reflect.DeepEqual(doc.Attributes.Attributes(id), attrs.Attributes(id)) {
+			t.Errorf("Node %d attributes differ", id)
+		}
+	}
+
+	// Types survive the round trip
+	if weight, err := doc.Attributes.Value(tree.Root().ID(), "weight"); err != nil || weight.Type() != attributors.ValueTypeFloat {
+		t.Errorf("Expected a float weight, got %v %v", weight, err)
+	}
+
+	// Replaying the stored attributors gives the same attributes
+	replayed, err := attributors.NewPipelineFromSpec(&attributors.PipelineSpec{Stages: doc.Attributors})
+	if err != nil {
+		t.Fatalf("Failed to create pipeline %v", err)
+	}
+
+	replayedAttrs, err := replayed.Run(doc.Tree)
+	if err != nil {
+		t.Fatalf("Failed to run pipeline %v", err)
+	}
+
+	for _, id := range replayedAttrs.Nodes() {
+		if !reflect.DeepEqual(replayedAttrs.Attributes(id), doc.Attributes.Attributes(id)) {
+			t.Errorf("Node %d replayed attributes differ", id)
+		}
+	}
+}
+
+func TestDocumentVersions(t *testing.T) {
+	tree := grid.New2D(2)
+
+	// Documents written without attributes have none
+	data, err := factory.MarshalJSON(tree)
+	if err != nil {
+		t.Fatalf("Failed to marshal tree %v", err)
+	}
+
+	doc, err := factory.UnmarshalDocument(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal document %v", err)
+	}
+
+	if len(doc.Attributes.Nodes()) != 0 || doc.Attributors != nil {
+		t.Errorf("Expected no attributes")
+	}
+
+	// Without attributes the output is the same as MarshalJSON
+	docData, err := factory.MarshalDocument(&factory.Document{Tree: tree})
+	if err != nil {
+		t.Fatalf("Failed to marshal document %v", err)
+	}
+	if string(docData) != string(data) {
+		t.Errorf("Expected document without attributes to match the tree")
+	}
+
+	var wrapper map[string]interface{}
+	json.Unmarshal(data, &wrapper)
+
+	wrapper["attributes"] = map[string]interface{}{"version": factory.AttributesVersion + 1}
+	future, _ := json.Marshal(wrapper)
+	if _, err := factory.UnmarshalDocument(future); err != factory.ErrUnsupportedAttributesVersion {
+		t.Errorf("Expected unsupported version, got %v", err)
+	}
+
+	wrapper["attributes"] = map[string]interface{}{
+		"version": factory.AttributesVersion,
+		"nodes":   map[string]interface{}{"99": map[string]interface{}{"a": map[string]string{"string": "b"}}},
+	}
+	unknown, _ := json.Marshal(wrapper)
+	if _, err := factory.UnmarshalDocument(unknown); err != factory.ErrUnknownAttributeNode {
+		t.Errorf("Expected unknown node, got %v", err)
+	}
+}
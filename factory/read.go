@@ -8,27 +8,49 @@ import (
 )
 
 type jsonWrapper struct {
-	Type    string          `json:"type"`
-	Version int             `json:"version"`
-	Tree    json.RawMessage `json:"tree"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	Tree       json.RawMessage `json:"tree"`
+	Attributes *jsonAttributes `json:"attributes,omitempty"`
 }
 
+// Reads the tree of a document, any attributes are ignored.
 func UnmarshalJSON(data []byte) (htree.Tree, error) {
 	var wrapper *jsonWrapper
 	if err := json.Unmarshal(data, &wrapper); err != nil {
 		return nil, err
 	}
 
+	return wrapper.unmarshalTree()
+}
+
+func (wrapper *jsonWrapper) unmarshalTree() (htree.Tree, error) {
 	if wrapper.Type == "simple" {
 		return simple.UnmarshalJSON(wrapper.Version, wrapper.Tree)
 	}
 
+	if wrapper.Type == "compact" {
+		var data []byte
+		if err := json.Unmarshal(wrapper.Tree, &data); err != nil {
+			return nil, err
+		}
+		return simple.UnmarshalBinary(data)
+	}
+
 	return nil, fmt.Errorf("Unknown type %s", wrapper.Type)
 }
 
 func MarshalJSON(tree htree.Tree) ([]byte, error) {
-	_, ok := tree.(*simple.Tree)
-	if !ok {
+	wrapper, err := newSimpleWrapper(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(wrapper)
+}
+
+func newSimpleWrapper(tree htree.Tree) (*jsonWrapper, error) {
+	if _, ok := tree.(*simple.Tree); !ok {
 		return nil, fmt.Errorf("Unknown tree type!")
 	}
 
@@ -37,9 +59,39 @@ func MarshalJSON(tree htree.Tree) ([]byte, error) {
 		return nil, err
 	}
 
-	return json.Marshal(jsonWrapper{
+	return &jsonWrapper{
 		Type:    "simple",
 		Version: simple.JSONVersion,
 		Tree:    simpleData,
+	}, nil
+}
+
+// Same as MarshalJSON but the tree is stored in the compact binary format,
+// base64 encoded.
+func MarshalCompactJSON(tree htree.Tree) ([]byte, error) {
+	data, err := simple.MarshalBinary(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	compactData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonWrapper{
+		Type:    "compact",
+		Version: simple.BinaryVersion,
+		Tree:    compactData,
 	})
 }
+
+// Encodes the tree in the compact binary format, which carries its own
+// version.
+func MarshalBinary(tree htree.Tree) ([]byte, error) {
+	return simple.MarshalBinary(tree)
+}
+
+func UnmarshalBinary(data []byte) (htree.Tree, error) {
+	return simple.UnmarshalBinary(data)
+}
@@ -61,3 +61,78 @@ func TestSerialize(t *testing.T) {
 		}
 	}
 }
+
+func TestSerializeCompact(t *testing.T) {
+	goldenRatios := golden.RatioSource()
+
+	gen2, err := randombasic.New(goldenRatios, 1, 50, 7)
+	if err != nil {
+		t.Fatalf("failed to create generator %v", err)
+	}
+	rb2, err := gen2.Generate()
+	if err != nil {
+		t.Fatalf("failed to create random basic %v", err)
+	}
+	gen3, err := randombasic.New3D(goldenRatios, 1, 1, 50, 7)
+	if err != nil {
+		t.Fatalf("failed to create generator %v", err)
+	}
+	rb3, err := gen3.Generate()
+	if err != nil {
+		t.Fatalf("failed to create random basic %v", err)
+	}
+
+	treeTests := []htree.Tree{
+		grid.New2D(0), grid.New2D(4), grid.New3D(5), rb2, rb3,
+	}
+
+	for i, tree := range treeTests {
+		treeData, err := factory.MarshalJSON(tree)
+		if err != nil {
+			t.Fatalf("test %d failed to marshal %v", i, err)
+		}
+
+		binaryData, err := factory.MarshalBinary(tree)
+		if err != nil {
+			t.Fatalf("test %d failed to marshal binary %v", i, err)
+		}
+
+		if len(binaryData) >= len(treeData) {
+			t.Errorf("test %d binary is %d bytes, json is %d", i, len(binaryData), len(treeData))
+		}
+
+		tree2, err := factory.UnmarshalBinary(binaryData)
+		if err != nil {
+			t.Fatalf("test %d failed to unmarshal binary %v", i, err)
+		}
+
+		treeData2, err := factory.MarshalJSON(tree2)
+		if err != nil {
+			t.Fatalf("test %d failed to marshal 2nd time %v", i, err)
+		}
+
+		if !bytes.Equal(treeData, treeData2) {
+			t.Errorf("test %d binary encoding/decoding non-symmetric", i)
+		}
+
+		// The compact type is read by UnmarshalJSON as well
+		compactData, err := factory.MarshalCompactJSON(tree)
+		if err != nil {
+			t.Fatalf("test %d failed to marshal compact %v", i, err)
+		}
+
+		tree3, err := factory.UnmarshalJSON(compactData)
+		if err != nil {
+			t.Fatalf("test %d failed to unmarshal compact %v", i, err)
+		}
+
+		treeData3, err := factory.MarshalJSON(tree3)
+		if err != nil {
+			t.Fatalf("test %d failed to marshal 3rd time %v", i, err)
+		}
+
+		if !bytes.Equal(treeData, treeData3) {
+			t.Errorf("test %d compact encoding/decoding non-symmetric", i)
+		}
+	}
+}
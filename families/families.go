@@ -0,0 +1,57 @@
+package families
+
+import (
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/golden"
+	"github.com/scisci/hambidgetree/root2"
+	"github.com/scisci/hambidgetree/root3"
+	"github.com/scisci/hambidgetree/root5"
+	"github.com/scisci/hambidgetree/whirling"
+	"sort"
+)
+
+var ErrUnknownFamily = errors.New("Unknown ratio family")
+
+// The exprs of each of the built in ratio families by name.
+var familyExprs = map[string][]string{
+	"golden":   golden.Exprs,
+	"root2":    root2.Exprs,
+	"root3":    root3.Exprs,
+	"root5":    root5.Exprs,
+	"whirling": whirling.Exprs,
+}
+
+// Returns the names of the built in families sorted alphabetically.
+func Names() []string {
+	names := make([]string, 0, len(familyExprs))
+	for name := range familyExprs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Exprs(name string) ([]string, error) {
+	exprs, ok := familyExprs[name]
+	if !ok {
+		return nil, ErrUnknownFamily
+	}
+	return exprs, nil
+}
+
+func RatioSource(name string) (htree.RatioSource, error) {
+	exprs, err := Exprs(name)
+	if err != nil {
+		return nil, err
+	}
+	return htree.NewExprRatioSource(exprs)
+}
+
+func ExactRatioSource(name string) (htree.ExactRatioSource, error) {
+	exprs, err := Exprs(name)
+	if err != nil {
+		return nil, err
+	}
+	return htree.NewExactRatioSource(exprs)
+}
@@ -0,0 +1,58 @@
+package families_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/families"
+	"testing"
+)
+
+func TestFamilies(t *testing.T) {
+	epsilon := 0.0000001
+
+	for _, name := range families.Names() {
+		ratioSource, err := families.RatioSource(name)
+		if err != nil {
+			t.Fatalf("Failed to create %s ratio source %v", name, err)
+		}
+
+		ratios := ratioSource.Ratios()
+		if missing := htree.FindIndexesWithMissingInverses(ratios, epsilon); len(missing) != 0 {
+			t.Errorf("Family %s is missing inverses of %v", name, missing)
+		}
+
+		complements, err := htree.NewComplements(ratios, epsilon)
+		if err != nil {
+			t.Errorf("Failed to create %s complements %v", name, err)
+			continue
+		}
+
+		for i, splits := range complements {
+			if len(splits) == 0 {
+				t.Errorf("Family %s ratio %s has no splits", name, ratioSource.Exprs()[i])
+			}
+		}
+
+		// Exact evaluation must agree with the floats
+		exactSource, err := families.ExactRatioSource(name)
+		if err != nil {
+			t.Fatalf("Failed to create %s exact ratio source %v", name, err)
+		}
+
+		exactComplements, err := htree.NewRatioSourceComplements(exactSource, 0)
+		if err != nil {
+			t.Fatalf("Failed to create %s exact complements %v", name, err)
+		}
+
+		for i := range complements {
+			if len(complements[i]) != len(exactComplements[i]) {
+				t.Errorf("Family %s ratio %d has %d splits, exact has %d", name, i, len(complements[i]), len(exactComplements[i]))
+			}
+		}
+	}
+}
+
+func TestFamiliesUnknown(t *testing.T) {
+	if _, err := families.RatioSource("root7"); err != families.ErrUnknownFamily {
+		t.Errorf("Expected unknown family error, got %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+// Package all registers every built in generator with the generators
+// registry, import it for its side effects:
+//
+//	import _ "github.com/scisci/hambidgetree/generators/all"
+package all
+
+import (
+	_ "github.com/scisci/hambidgetree/generators/constrained"
+	_ "github.com/scisci/hambidgetree/generators/enumerate"
+	_ "github.com/scisci/hambidgetree/generators/grid"
+	_ "github.com/scisci/hambidgetree/generators/randombasic"
+)
@@ -0,0 +1,303 @@
+package constrained
+
+import (
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/builder"
+	"math"
+	"math/rand"
+)
+
+const defaultEpsilon = 0.0000001
+
+// The number of splits tried before giving up.
+const defaultMaxSteps = 100000
+
+var ErrInvalidNumLeaves = errors.New("Number of leaves must be at least 1")
+var ErrContainerRatioNotFound = errors.New("Container ratio not found in list of ratios.")
+var ErrUnsatisfiable = errors.New("No layout satisfies the constraints")
+var ErrMaxStepsReached = errors.New("Gave up searching for a layout that satisfies the constraints")
+
+// Limits on the leaves of generated trees, a zero value means there is no
+// limit.
+type Constraints struct {
+	// The smallest area, or volume in 3D, of a leaf as a fraction of the
+	// container.
+	MinArea float64
+	// The largest ratio of the longest side of a leaf to its shortest side.
+	MaxAspect float64
+	// The deepest a leaf can be, the root has a depth of 0.
+	MaxDepth int
+}
+
+func DefaultConstraints() *Constraints {
+	return &Constraints{}
+}
+
+// Generates a random tree with NumLeaves leaves that satisfies the
+// constraints. Splits that would create a region breaking the constraints are
+// never made, and when no leaf can be split any further the last splits are
+// undone and others tried instead.
+type ConstrainedTreeGenerator struct {
+	NumLeaves   int
+	RatioSource htree.RatioSource
+	Complements htree.Complements
+	Seed        int64
+	XYRatio     float64
+	ZYRatio     float64
+	Constraints Constraints
+	MaxSteps    int
+}
+
+func New(ratioSource htree.RatioSource, containerRatio float64, numLeaves int, seed int64, constraints *Constraints) (*ConstrainedTreeGenerator, error) {
+	return New3D(ratioSource, containerRatio, 0, numLeaves, seed, constraints)
+}
+
+func New3D(ratioSource htree.RatioSource, xyRatio, zyRatio float64, numLeaves int, seed int64, constraints *Constraints) (*ConstrainedTreeGenerator, error) {
+	if numLeaves < 1 {
+		return nil, ErrInvalidNumLeaves
+	}
+
+	if constraints == nil {
+		constraints = DefaultConstraints()
+	}
+
+	complements, err := htree.NewRatioSourceComplements(ratioSource, defaultEpsilon)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConstrainedTreeGenerator{
+		NumLeaves:   numLeaves,
+		RatioSource: ratioSource,
+		Complements: complements,
+		Seed:        seed,
+		XYRatio:     xyRatio,
+		ZYRatio:     zyRatio,
+		Constraints: *constraints,
+		MaxSteps:    defaultMaxSteps,
+	}, nil
+}
+
+func (gen *ConstrainedTreeGenerator) Is3D() bool {
+	return gen.ZYRatio > 0
+}
+
+// The state of a search for a tree.
+type search struct {
+	gen         *ConstrainedTreeGenerator
+	rnd         *rand.Rand
+	treeBuilder *builder.TreeBuilder
+	volume      float64
+	depths      map[htree.NodeID]int
+	steps       int
+}
+
+// Returns the area of the box in 2D or its volume in 3D.
+func measure(box *htree.AlignedBox, is3D bool) float64 {
+	if is3D {
+		return box.Width() * box.Height() * box.Depth()
+	}
+	return box.Width() * box.Height()
+}
+
+// Returns the ratio of the longest side of the box to its shortest.
+func aspect(box *htree.AlignedBox, is3D bool) float64 {
+	longest := math.Max(box.Width(), box.Height())
+	shortest := math.Min(box.Width(), box.Height())
+	if is3D {
+		longest = math.Max(longest, box.Depth())
+		shortest = math.Min(shortest, box.Depth())
+	}
+	return longest / shortest
+}
+
+// Returns true if the region could hold a leaf at the depth. Splitting only
+// makes regions deeper and smaller, so nothing inside a region that can't
+// hold a leaf can either.
+func (s *search) reachable(region *htree.Region, depth int) bool {
+	constraints := s.gen.Constraints
+
+	if constraints.MaxDepth > 0 && depth > constraints.MaxDepth {
+		return false
+	}
+
+	if constraints.MinArea > 0 && measure(region.AlignedBox(), s.gen.Is3D()) < constraints.MinArea*s.volume-defaultEpsilon {
+		return false
+	}
+
+	return true
+}
+
+// Returns true if the region has the shape of an allowed leaf.
+func (s *search) fits(region *htree.Region) bool {
+	maxAspect := s.gen.Constraints.MaxAspect
+	return maxAspect <= 0 || aspect(region.AlignedBox(), s.gen.Is3D()) <= maxAspect+defaultEpsilon
+}
+
+// Returns true if the leaf satisfies the constraints. Only leaves are
+// bounded, a region may be split into allowed leaves even if it isn't one.
+func (s *search) allowed(leaf htree.Leaf) bool {
+	region := s.treeBuilder.Region(leaf.ID())
+	return s.reachable(region, s.depths[leaf.ID()]) && s.fits(region)
+}
+
+// Returns the splits of the leaf whose regions could still hold leaves, the
+// splits whose regions are allowed leaves are returned first.
+func (s *search) candidates(leaf htree.Leaf) ([]htree.Split, []htree.Split) {
+	gen := s.gen
+	region := s.treeBuilder.Region(leaf.ID())
+	depth := s.depths[leaf.ID()] + 1
+
+	var splits []htree.Split
+	if gen.Is3D() {
+		splits = htree.AvailableRatioSourceSplits(gen.RatioSource, gen.Complements, leaf.RatioIndexXY(), leaf.RatioIndexZY(), defaultEpsilon)
+	} else {
+		splits = gen.Complements[leaf.RatioIndexXY()]
+	}
+
+	var fitting, others []htree.Split
+	for _, split := range splits {
+		candidates := []htree.Split{split}
+		if split.LeftIndex() != split.RightIndex() {
+			candidates = append(candidates, htree.NewInvertedSplit(split))
+		}
+
+		for _, candidate := range candidates {
+			left, right := htree.SplitRegion(gen.RatioSource, region, candidate.Type(), candidate.LeftIndex(), candidate.RightIndex())
+			if !s.reachable(left, depth) || !s.reachable(right, depth) {
+				continue
+			}
+			if s.fits(left) && s.fits(right) {
+				fitting = append(fitting, candidate)
+			} else {
+				others = append(others, candidate)
+			}
+		}
+	}
+
+	return fitting, others
+}
+
+// Returns the splits of the leaf in a random order, the ones that make
+// allowed leaves first.
+func (s *search) splits(leaf htree.Leaf) []htree.Split {
+	fitting, others := s.candidates(leaf)
+	s.rnd.Shuffle(len(fitting), func(i, j int) {
+		fitting[i], fitting[j] = fitting[j], fitting[i]
+	})
+	s.rnd.Shuffle(len(others), func(i, j int) {
+		others[i], others[j] = others[j], others[i]
+	})
+	return append(fitting, others...)
+}
+
+// Splits the open leaves until there are enough leaves. A random open leaf is
+// either split or closed so it is never split again, which visits every tree
+// once. Only leaves are checked against the constraints, a leaf is checked
+// when it is closed and an open leaf that isn't allowed has to be split
+// again before there are enough leaves. Returns false if no tree can be found.
+func (s *search) run(open []htree.Leaf) (bool, error) {
+	// Every open leaf that isn't allowed needs at least one more split, and
+	// every split adds a leaf
+	remaining := s.gen.NumLeaves - len(s.treeBuilder.Leaves())
+	for _, leaf := range open {
+		if !s.allowed(leaf) {
+			if fitting, others := s.candidates(leaf); len(fitting) == 0 && len(others) == 0 {
+				return false, nil
+			}
+			remaining--
+		}
+	}
+	if remaining < 0 {
+		return false, nil
+	}
+
+	if len(s.treeBuilder.Leaves()) == s.gen.NumLeaves {
+		return true, nil
+	}
+
+	if len(open) == 0 {
+		return false, nil
+	}
+
+	i := s.rnd.Intn(len(open))
+	leaf := open[i]
+	rest := append(append([]htree.Leaf{}, open[:i]...), open[i+1:]...)
+
+	for _, split := range s.splits(leaf) {
+		s.steps++
+		if s.gen.MaxSteps > 0 && s.steps > s.gen.MaxSteps {
+			return false, ErrMaxStepsReached
+		}
+
+		left, right := s.treeBuilder.Branch(leaf.ID(), split.Type(), split.LeftIndex(), split.RightIndex())
+		s.depths[left.ID()] = s.depths[leaf.ID()] + 1
+		s.depths[right.ID()] = s.depths[leaf.ID()] + 1
+
+		ok, err := s.run(append(append([]htree.Leaf{}, rest...), left, right))
+		if ok || err != nil {
+			return ok, err
+		}
+
+		s.treeBuilder.Undo()
+	}
+
+	// Try leaving it as a leaf
+	if !s.allowed(leaf) {
+		return false, nil
+	}
+	return s.run(rest)
+}
+
+func (gen *ConstrainedTreeGenerator) Generate() (htree.Tree, error) {
+	// The leaves can't all fit if they are at least the minimum area
+	if gen.Constraints.MinArea > 0 && float64(gen.NumLeaves)*gen.Constraints.MinArea > 1+defaultEpsilon {
+		return nil, ErrUnsatisfiable
+	}
+
+	// Every split at most doubles the leaves a depth can hold
+	if maxDepth := gen.Constraints.MaxDepth; maxDepth > 0 && maxDepth < 62 && gen.NumLeaves > 1<<uint(maxDepth) {
+		return nil, ErrUnsatisfiable
+	}
+
+	ratios := gen.RatioSource.Ratios()
+
+	epsilon := htree.CalculateRatiosEpsilon(ratios)
+	xyRatioIndex := htree.FindClosestRatioSourceIndex(gen.RatioSource, gen.XYRatio, epsilon)
+	if xyRatioIndex < 0 {
+		return nil, ErrContainerRatioNotFound
+	}
+
+	var treeBuilder *builder.TreeBuilder
+	if !gen.Is3D() {
+		treeBuilder = builder.New2D(gen.RatioSource, xyRatioIndex)
+	} else {
+		zyRatioIndex := htree.FindClosestRatioSourceIndex(gen.RatioSource, gen.ZYRatio, epsilon)
+		if zyRatioIndex < 0 {
+			return nil, ErrContainerRatioNotFound
+		}
+		treeBuilder = builder.New3D(gen.RatioSource, xyRatioIndex, zyRatioIndex)
+	}
+
+	root := treeBuilder.Leaves()[0]
+	s := &search{
+		gen:         gen,
+		rnd:         rand.New(rand.NewSource(gen.Seed)),
+		treeBuilder: treeBuilder,
+		volume:      measure(treeBuilder.Region(root.ID()).AlignedBox(), gen.Is3D()),
+		depths:      map[htree.NodeID]int{root.ID(): 0},
+	}
+
+	ok, err := s.run([]htree.Leaf{root})
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrUnsatisfiable
+	}
+
+	tree, _ := treeBuilder.Build()
+	return tree, nil
+}
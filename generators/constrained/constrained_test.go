@@ -0,0 +1,236 @@
+package constrained_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/generators/constrained"
+	"github.com/scisci/hambidgetree/golden"
+	"math"
+	"sync"
+	"testing"
+)
+
+func depth(tree htree.Tree, id htree.NodeID) int {
+	d := 0
+	for id != tree.Root().ID() {
+		id = tree.Parent(id).ID()
+		d++
+	}
+	return d
+}
+
+func checkConstraints(t *testing.T, tree htree.Tree, numLeaves int, constraints *constrained.Constraints) {
+	leaves := algo.FindLeaves(tree)
+	if len(leaves) != numLeaves {
+		t.Errorf("Expected %d leaves, got %d", numLeaves, len(leaves))
+	}
+
+	is3D := htree.IsRatioIndexDefined(tree.RatioIndexZY())
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	container := regionMap[tree.Root().ID()].AlignedBox()
+
+	for _, leaf := range leaves {
+		box := regionMap[leaf.ID()].AlignedBox()
+		sides := []float64{box.Width(), box.Height()}
+		area := box.Width() * box.Height() / (container.Width() * container.Height())
+		if is3D {
+			sides = append(sides, box.Depth())
+			area = area * box.Depth() / container.Depth()
+		}
+
+		if constraints.MinArea > 0 && area < constraints.MinArea-0.0000001 {
+			t.Errorf("Leaf %d area %f is less than %f", leaf.ID(), area, constraints.MinArea)
+		}
+
+		longest, shortest := 0.0, math.MaxFloat64
+		for _, side := range sides {
+			longest = math.Max(longest, side)
+			shortest = math.Min(shortest, side)
+		}
+		if constraints.MaxAspect > 0 && longest/shortest > constraints.MaxAspect+0.0000001 {
+			t.Errorf("Leaf %d aspect %f is more than %f", leaf.ID(), longest/shortest, constraints.MaxAspect)
+		}
+
+		if d := depth(tree, leaf.ID()); constraints.MaxDepth > 0 && d > constraints.MaxDepth {
+			t.Errorf("Leaf %d depth %d is more than %d", leaf.ID(), d, constraints.MaxDepth)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	constraints := &constrained.Constraints{
+		MinArea:   0.01,
+		MaxAspect: 3,
+		MaxDepth:  8,
+	}
+
+	for seed := int64(0); seed < 10; seed++ {
+		gen, err := constrained.New(golden.RatioSource(), 1, 20, seed, constraints)
+		if err != nil {
+			t.Fatalf("Failed to create generator %v", err)
+		}
+
+		tree, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate tree %v", err)
+		}
+
+		checkConstraints(t, tree, 20, constraints)
+
+		// The same seed always makes the same tree
+		again, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate tree %v", err)
+		}
+		if algo.HashString(tree) != algo.HashString(again) {
+			t.Errorf("Expected seed %d to generate the same tree", seed)
+		}
+	}
+}
+
+func TestGenerate3D(t *testing.T) {
+	constraints := &constrained.Constraints{
+		MinArea:   0.005,
+		MaxAspect: 4,
+		MaxDepth:  10,
+	}
+
+	for seed := int64(0); seed < 5; seed++ {
+		gen, err := constrained.New3D(golden.RatioSource(), 1, 1, 30, seed, constraints)
+		if err != nil {
+			t.Fatalf("Failed to create generator %v", err)
+		}
+
+		tree, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate tree %v", err)
+		}
+
+		checkConstraints(t, tree, 30, constraints)
+	}
+}
+
+func TestBacktrack(t *testing.T) {
+	ratioSource, err := htree.NewBasicRatioSource([]float64{0.5, 1, 2})
+	if err != nil {
+		t.Fatalf("Failed to create ratio source %v", err)
+	}
+
+	// Only the 2x2 grid has 4 leaves within a depth of 2, most random choices
+	// dead end
+	constraints := &constrained.Constraints{MaxDepth: 2}
+	for seed := int64(0); seed < 20; seed++ {
+		gen, err := constrained.New(ratioSource, 1, 4, seed, constraints)
+		if err != nil {
+			t.Fatalf("Failed to create generator %v", err)
+		}
+
+		tree, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate tree %v", err)
+		}
+
+		checkConstraints(t, tree, 4, constraints)
+	}
+
+	gen, err := constrained.New(ratioSource, 1, 5, 0, constraints)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	if _, err := gen.Generate(); err != constrained.ErrUnsatisfiable {
+		t.Errorf("Expected unsatisfiable, got %v", err)
+	}
+}
+
+func TestLeafConstraints(t *testing.T) {
+	ratioSource, err := htree.NewExprRatioSource([]string{"1/4", "1/3", "1/2", "1", "2", "3", "4"})
+	if err != nil {
+		t.Fatalf("Failed to create ratio source %v", err)
+	}
+
+	// Four unit squares fit, but only by splitting the container into
+	// regions more stretched than the leaves
+	constraints := &constrained.Constraints{MaxAspect: 1.5}
+	gen, err := constrained.New(ratioSource, 4, 4, 1, constraints)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	tree, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate tree %v", err)
+	}
+
+	checkConstraints(t, tree, 4, constraints)
+}
+
+func TestGenerateShared(t *testing.T) {
+	constraints := &constrained.Constraints{MinArea: 0.01, MaxAspect: 4, MaxDepth: 8}
+	gen, err := constrained.New3D(golden.RatioSource(), 1, 1, 20, 5, constraints)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	tree, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate tree %v", err)
+	}
+	expected := algo.HashString(tree)
+
+	// One generator used by many goroutines always makes the same tree
+	hashes := make([]string, 16)
+	var wg sync.WaitGroup
+	for i := range hashes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if tree, err := gen.Generate(); err == nil {
+				hashes[i] = algo.HashString(tree)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range hashes {
+		if hashes[i] != expected {
+			t.Errorf("Run %d generated a different tree when run concurrently", i)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := constrained.New(golden.RatioSource(), 1, 0, 0, nil); err != constrained.ErrInvalidNumLeaves {
+		t.Errorf("Expected invalid number of leaves, got %v", err)
+	}
+
+	gen, err := constrained.New(golden.RatioSource(), 1, 10, 0, &constrained.Constraints{MinArea: 0.2})
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	if _, err := gen.Generate(); err != constrained.ErrUnsatisfiable {
+		t.Errorf("Expected unsatisfiable, got %v", err)
+	}
+
+	// More leaves than the depth can hold fails without searching
+	gen, err = constrained.New(golden.RatioSource(), 1, 17, 0, &constrained.Constraints{MaxDepth: 4})
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	if _, err := gen.Generate(); err != constrained.ErrUnsatisfiable {
+		t.Errorf("Expected unsatisfiable, got %v", err)
+	}
+
+	// Give up long before the search is exhausted
+	gen, err = constrained.New(golden.RatioSource(), 1, 50, 0, &constrained.Constraints{MaxDepth: 6, MinArea: 0.015})
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+	gen.MaxSteps = 1000
+
+	if _, err := gen.Generate(); err != constrained.ErrMaxStepsReached {
+		t.Errorf("Expected max steps reached, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package constrained
+
+import (
+	"github.com/scisci/hambidgetree/generators"
+	"github.com/scisci/hambidgetree/print"
+	"strconv"
+)
+
+func (gen *ConstrainedTreeGenerator) Name() string {
+	return "Constrained"
+}
+
+func (gen *ConstrainedTreeGenerator) Description() string {
+	return "This algorithm generates a given number of leaves by splitting a container of a given ratio at random. Splits that would make a leaf smaller than the minimum area, more elongated than the maximum aspect or deeper than the maximum depth are skipped. When no leaf can be split any further it undoes its last splits and tries others."
+}
+
+func (gen *ConstrainedTreeGenerator) Parameters(f generators.ParameterFormatType) map[string]interface{} {
+	if f == generators.ParameterFormatTypeConcise {
+		return map[string]interface{}{
+			"# Leaves": gen.NumLeaves,
+			"Seed":     gen.Seed,
+		}
+	}
+
+	return map[string]interface{}{
+		"Ratios":               print.PrintRatios(gen.RatioSource),
+		"Container Ratio (XY)": strconv.FormatFloat(gen.XYRatio, 'f', 4, 64),
+		"Container Ratio (ZY)": strconv.FormatFloat(gen.ZYRatio, 'f', 4, 64),
+		"Number of Leaves":     gen.NumLeaves,
+		"Random Seed":          gen.Seed,
+		"Min Area":             strconv.FormatFloat(gen.Constraints.MinArea, 'f', 4, 64),
+		"Max Aspect":           strconv.FormatFloat(gen.Constraints.MaxAspect, 'f', 4, 64),
+		"Max Depth":            gen.Constraints.MaxDepth,
+	}
+}
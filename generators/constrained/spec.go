@@ -0,0 +1,76 @@
+package constrained
+
+import (
+	"github.com/scisci/hambidgetree/generators"
+)
+
+// The parameters of a ConstrainedTreeGenerator in a spec.
+type Params struct {
+	generators.RatioParams
+	Leaves    int     `json:"leaves" desc:"Number of leaves"`
+	Seed      int64   `json:"seed" desc:"Random seed"`
+	RatioXY   float64 `json:"ratioXY" desc:"Container ratio (XY)"`
+	RatioZY   float64 `json:"ratioZY" desc:"Container depth ratio (ZY), 0 for a 2D tree"`
+	MinArea   float64 `json:"minArea" desc:"Smallest leaf as a fraction of the container, 0 for no limit"`
+	MaxAspect float64 `json:"maxAspect" desc:"Largest ratio of the longest side of a leaf to its shortest, 0 for no limit"`
+	MaxDepth  int     `json:"maxDepth" desc:"Deepest a leaf can be, 0 for no limit"`
+	MaxSteps  int     `json:"maxSteps" desc:"Number of splits tried before giving up, 0 for no limit"`
+}
+
+func init() {
+	generators.Register(&generators.Registration{
+		Name: "Constrained",
+		Defaults: func() interface{} {
+			return &Params{
+				RatioParams: generators.RatioParams{Family: "golden"},
+				Leaves:      20,
+				RatioXY:     1,
+				MaxSteps:    defaultMaxSteps,
+			}
+		},
+		New: func(params interface{}) (generators.TreeGenerator, error) {
+			p, ok := params.(*Params)
+			if !ok {
+				return nil, generators.ErrInvalidParams
+			}
+
+			ratioSource, err := p.RatioSource()
+			if err != nil {
+				return nil, err
+			}
+
+			constraints := &Constraints{
+				MinArea:   p.MinArea,
+				MaxAspect: p.MaxAspect,
+				MaxDepth:  p.MaxDepth,
+			}
+			gen, err := New3D(ratioSource, p.RatioXY, p.RatioZY, p.Leaves, p.Seed, constraints)
+			if err != nil {
+				return nil, err
+			}
+			gen.MaxSteps = p.MaxSteps
+			return gen, nil
+		},
+		Params: func(gen generators.TreeGenerator) (interface{}, error) {
+			g, ok := gen.(*ConstrainedTreeGenerator)
+			if !ok {
+				return nil, generators.ErrInvalidParams
+			}
+
+			return &Params{
+				RatioParams: generators.NewRatioParams(g.RatioSource),
+				Leaves:      g.NumLeaves,
+				Seed:        g.Seed,
+				RatioXY:     g.XYRatio,
+				RatioZY:     g.ZYRatio,
+				MinArea:     g.Constraints.MinArea,
+				MaxAspect:   g.Constraints.MaxAspect,
+				MaxDepth:    g.Constraints.MaxDepth,
+				MaxSteps:    g.MaxSteps,
+			}, nil
+		},
+		NumLeaves: func(params interface{}) int {
+			return params.(*Params).Leaves
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package enumerate
+
+import (
+	"github.com/scisci/hambidgetree/generators"
+	"github.com/scisci/hambidgetree/print"
+	"strconv"
+)
+
+func (gen *EnumerateTreeGenerator) Name() string {
+	return "Enumerate"
+}
+
+func (gen *EnumerateTreeGenerator) Description() string {
+	return "This algorithm visits every layout with a given number of leaves that can be reached by splitting a container of a given ratio, skipping layouts whose leaves are identical to one already visited. It generates the layout at a given index."
+}
+
+func (gen *EnumerateTreeGenerator) Parameters(f generators.ParameterFormatType) map[string]interface{} {
+	if f == generators.ParameterFormatTypeConcise {
+		return map[string]interface{}{
+			"# Leaves": gen.NumLeaves,
+			"Index":    gen.Index,
+		}
+	}
+
+	return map[string]interface{}{
+		"Ratios":               print.PrintRatios(gen.RatioSource),
+		"Container Ratio (XY)": strconv.FormatFloat(gen.XYRatio, 'f', 4, 64),
+		"Container Ratio (ZY)": strconv.FormatFloat(gen.ZYRatio, 'f', 4, 64),
+		"Number of Leaves":     gen.NumLeaves,
+		"Index":                gen.Index,
+	}
+}
+
+func (gen *UniformTreeGenerator) Name() string {
+	return "Uniform"
+}
+
+func (gen *UniformTreeGenerator) Description() string {
+	return "This algorithm picks a random layout with a given number of leaves that can be reached by splitting a container of a given ratio. Every distinct layout is equally likely, including layouts that can be split in more than one order."
+}
+
+func (gen *UniformTreeGenerator) Parameters(f generators.ParameterFormatType) map[string]interface{} {
+	if f == generators.ParameterFormatTypeConcise {
+		return map[string]interface{}{
+			"# Leaves": gen.NumLeaves,
+			"Seed":     gen.Seed,
+		}
+	}
+
+	return map[string]interface{}{
+		"Ratios":               print.PrintRatios(gen.RatioSource),
+		"Container Ratio (XY)": strconv.FormatFloat(gen.XYRatio, 'f', 4, 64),
+		"Container Ratio (ZY)": strconv.FormatFloat(gen.ZYRatio, 'f', 4, 64),
+		"Number of Leaves":     gen.NumLeaves,
+		"Seed":                 gen.Seed,
+	}
+}
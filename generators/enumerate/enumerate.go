@@ -0,0 +1,245 @@
+package enumerate
+
+import (
+	"context"
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/builder"
+)
+
+const defaultEpsilon = 0.0000001
+
+var ErrInvalidNumLeaves = errors.New("Number of leaves must be at least 1")
+var ErrContainerRatioNotFound = errors.New("Container ratio not found in list of ratios.")
+var ErrIndexOutOfRange = errors.New("Index is beyond the number of layouts")
+
+// Enumerates every distinct layout with exactly NumLeaves leaves. Layouts with
+// identical geometry are only produced once, no matter how many trees
+// describe them. Generate returns the layout at Index.
+type EnumerateTreeGenerator struct {
+	NumLeaves   int
+	RatioSource htree.RatioSource
+	Complements htree.Complements
+	XYRatio     float64
+	ZYRatio     float64
+	Index       int
+}
+
+func New(ratioSource htree.RatioSource, containerRatio float64, numLeaves int) (*EnumerateTreeGenerator, error) {
+	return New3D(ratioSource, containerRatio, 0, numLeaves)
+}
+
+func New3D(ratioSource htree.RatioSource, xyRatio, zyRatio float64, numLeaves int) (*EnumerateTreeGenerator, error) {
+	if numLeaves < 1 {
+		return nil, ErrInvalidNumLeaves
+	}
+
+	complements, err := htree.NewRatioSourceComplements(ratioSource, defaultEpsilon)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EnumerateTreeGenerator{
+		NumLeaves:   numLeaves,
+		RatioSource: ratioSource,
+		Complements: complements,
+		XYRatio:     xyRatio,
+		ZYRatio:     zyRatio,
+	}, nil
+}
+
+func (gen *EnumerateTreeGenerator) Is3D() bool {
+	return gen.ZYRatio > 0
+}
+
+// A subtree in the making, nil is a leaf.
+type shape struct {
+	split htree.Split
+	left  *shape
+	right *shape
+}
+
+// The layouts reachable by splitting regions with a ratio source, shared by
+// the generators.
+type layoutSpace struct {
+	ratioSource htree.RatioSource
+	complements htree.Complements
+	is3D        bool
+}
+
+// Finds the ratio indexes of the container.
+func (space *layoutSpace) containerIndexes(xyRatio, zyRatio float64) (int, int, error) {
+	ratios := space.ratioSource.Ratios()
+	epsilon := htree.CalculateRatiosEpsilon(ratios)
+
+	xyRatioIndex := htree.FindClosestRatioSourceIndex(space.ratioSource, xyRatio, epsilon)
+	if xyRatioIndex < 0 {
+		return 0, 0, ErrContainerRatioNotFound
+	}
+
+	zyRatioIndex := htree.RatioIndexUndefined
+	if space.is3D {
+		zyRatioIndex = htree.FindClosestRatioSourceIndex(space.ratioSource, zyRatio, epsilon)
+		if zyRatioIndex < 0 {
+			return 0, 0, ErrContainerRatioNotFound
+		}
+	}
+
+	return xyRatioIndex, zyRatioIndex, nil
+}
+
+// Returns the splits of a region along with their inverses, since the
+// complements only hold one orientation of each split.
+func (space *layoutSpace) splits(ratioIndexXY, ratioIndexZY int) []htree.Split {
+	var splits []htree.Split
+	if space.is3D {
+		splits = htree.AvailableRatioSourceSplits(space.ratioSource, space.complements, ratioIndexXY, ratioIndexZY, defaultEpsilon)
+	} else {
+		splits = space.complements[ratioIndexXY]
+	}
+
+	var result []htree.Split
+	for _, split := range splits {
+		result = append(result, split)
+		if split.LeftIndex() != split.RightIndex() {
+			result = append(result, htree.NewInvertedSplit(split))
+		}
+	}
+	return result
+}
+
+func (space *layoutSpace) build(ratioIndexXY, ratioIndexZY int, s *shape) htree.Tree {
+	var treeBuilder *builder.TreeBuilder
+	if space.is3D {
+		treeBuilder = builder.New3D(space.ratioSource, ratioIndexXY, ratioIndexZY)
+	} else {
+		treeBuilder = builder.New2D(space.ratioSource, ratioIndexXY)
+	}
+
+	var branch func(id htree.NodeID, s *shape)
+	branch = func(id htree.NodeID, s *shape) {
+		if s == nil {
+			return
+		}
+		left, right := treeBuilder.Branch(id, s.split.Type(), s.split.LeftIndex(), s.split.RightIndex())
+		branch(left.ID(), s.left)
+		branch(right.ID(), s.right)
+	}
+	branch(treeBuilder.Leaves()[0].ID(), s)
+
+	tree, _ := treeBuilder.Build()
+	return tree
+}
+
+func (gen *EnumerateTreeGenerator) space() *layoutSpace {
+	return &layoutSpace{
+		ratioSource: gen.RatioSource,
+		complements: gen.Complements,
+		is3D:        gen.Is3D(),
+	}
+}
+
+// Calls fn with every subtree of the region with n leaves until fn returns
+// false, returns false if it was stopped.
+func (space *layoutSpace) shapes(ratioIndexXY, ratioIndexZY, n int, fn func(s *shape) bool) bool {
+	if n == 1 {
+		return fn(nil)
+	}
+
+	for _, split := range space.splits(ratioIndexXY, ratioIndexZY) {
+		leftXY, leftZY, rightXY, rightZY := htree.SplitRatioIndexes(space.ratioSource, ratioIndexXY, ratioIndexZY, split)
+		for k := 1; k < n; k++ {
+			ok := space.shapes(leftXY, leftZY, k, func(left *shape) bool {
+				return space.shapes(rightXY, rightZY, n-k, func(right *shape) bool {
+					return fn(&shape{split: split, left: left, right: right})
+				})
+			})
+			if !ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Calls fn with each distinct layout in a deterministic order until fn returns
+// false or there are no more.
+func (gen *EnumerateTreeGenerator) Enumerate(fn func(tree htree.Tree) bool) error {
+	space := gen.space()
+	ratioIndexXY, ratioIndexZY, err := space.containerIndexes(gen.XYRatio, gen.ZYRatio)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	space.shapes(ratioIndexXY, ratioIndexZY, gen.NumLeaves, func(s *shape) bool {
+		tree := space.build(ratioIndexXY, ratioIndexZY, s)
+		signature := algo.LayoutSignature(tree)
+		if seen[signature] {
+			return true
+		}
+		seen[signature] = true
+		return fn(tree)
+	})
+
+	return nil
+}
+
+// Same as Enumerate but the layouts are sent on the returned channel, which
+// is closed when they run out or the context is done.
+func (gen *EnumerateTreeGenerator) Stream(ctx context.Context) (<-chan htree.Tree, error) {
+	if _, _, err := gen.space().containerIndexes(gen.XYRatio, gen.ZYRatio); err != nil {
+		return nil, err
+	}
+
+	trees := make(chan htree.Tree)
+	go func() {
+		defer close(trees)
+		gen.Enumerate(func(tree htree.Tree) bool {
+			select {
+			case trees <- tree:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return trees, nil
+}
+
+// Returns the number of distinct layouts. Every layout is still built and its
+// signature kept to skip duplicates, only the trees themselves are dropped.
+func (gen *EnumerateTreeGenerator) Count() (int, error) {
+	count := 0
+	err := gen.Enumerate(func(tree htree.Tree) bool {
+		count++
+		return true
+	})
+	return count, err
+}
+
+// Returns the layout at Index in the order of Enumerate.
+func (gen *EnumerateTreeGenerator) Generate() (htree.Tree, error) {
+	var result htree.Tree
+	i := 0
+	err := gen.Enumerate(func(tree htree.Tree) bool {
+		if i == gen.Index {
+			result = tree
+			return false
+		}
+		i++
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result == nil {
+		return nil, ErrIndexOutOfRange
+	}
+
+	return result, nil
+}
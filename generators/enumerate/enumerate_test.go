@@ -0,0 +1,129 @@
+package enumerate_test
+
+import (
+	"context"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/generators/enumerate"
+	"github.com/scisci/hambidgetree/generators/randombasic"
+	"github.com/scisci/hambidgetree/golden"
+	"testing"
+)
+
+func newRatioSource(t *testing.T) htree.RatioSource {
+	ratioSource, err := htree.NewBasicRatioSource([]float64{0.5, 1, 2})
+	if err != nil {
+		t.Fatalf("Failed to create ratio source %v", err)
+	}
+	return ratioSource
+}
+
+func TestCount(t *testing.T) {
+	ratioSource := newRatioSource(t)
+
+	// A square splits into two halves either way, and then either half of
+	// those can be split into squares
+	expected := map[int]int{1: 1, 2: 2, 3: 4}
+	for numLeaves, count := range expected {
+		gen, err := enumerate.New(ratioSource, 1, numLeaves)
+		if err != nil {
+			t.Fatalf("Failed to create generator %v", err)
+		}
+
+		got, err := gen.Count()
+		if err != nil {
+			t.Fatalf("Failed to count %v", err)
+		}
+		if got != count {
+			t.Errorf("Expected %d layouts with %d leaves, got %d", count, numLeaves, got)
+		}
+	}
+}
+
+func TestEnumerate(t *testing.T) {
+	// The 2x2 grid can be split vertically or horizontally first but must
+	// only be produced once
+	gen, err := enumerate.New(newRatioSource(t), 1, 4)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	seen := make(map[string]bool)
+	err = gen.Enumerate(func(tree htree.Tree) bool {
+		if n := len(algo.FindLeaves(tree)); n != 4 {
+			t.Errorf("Expected 4 leaves, got %d", n)
+		}
+		signature := algo.LayoutSignature(tree)
+		if seen[signature] {
+			t.Errorf("Layout produced twice")
+		}
+		seen[signature] = true
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Failed to enumerate %v", err)
+	}
+
+	// Every layout the random generator can make must be enumerated
+	gen, err = enumerate.New3D(golden.RatioSource(), 1, 1, 3)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	seen = make(map[string]bool)
+	gen.Enumerate(func(tree htree.Tree) bool {
+		seen[algo.LayoutSignature(tree)] = true
+		return true
+	})
+
+	for seed := int64(0); seed < 50; seed++ {
+		random, err := randombasic.New3D(golden.RatioSource(), 1, 1, 3, seed)
+		if err != nil {
+			t.Fatalf("Failed to create generator %v", err)
+		}
+		tree, err := random.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate %v", err)
+		}
+		if !seen[algo.LayoutSignature(tree)] {
+			t.Errorf("Seed %d produced a layout that wasn't enumerated", seed)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	gen, err := enumerate.New(newRatioSource(t), 1, 3)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	trees, err := gen.Stream(ctx)
+	if err != nil {
+		t.Fatalf("Failed to stream %v", err)
+	}
+
+	i := 0
+	for tree := range trees {
+		gen.Index = i
+		indexed, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate %d %v", i, err)
+		}
+		if algo.HashString(indexed) != algo.HashString(tree) {
+			t.Errorf("Layout %d doesn't match the streamed layout", i)
+		}
+		i++
+	}
+
+	gen.Index = i
+	if _, err := gen.Generate(); err != enumerate.ErrIndexOutOfRange {
+		t.Errorf("Expected index out of range, got %v", err)
+	}
+
+	if _, err := enumerate.New(newRatioSource(t), 1, 0); err != enumerate.ErrInvalidNumLeaves {
+		t.Errorf("Expected invalid number of leaves, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package enumerate
+
+import (
+	"github.com/scisci/hambidgetree/generators"
+)
+
+// The parameters of an EnumerateTreeGenerator in a spec.
+type EnumerateParams struct {
+	generators.RatioParams
+	Leaves  int     `json:"leaves" desc:"Number of leaves"`
+	RatioXY float64 `json:"ratioXY" desc:"Container ratio (XY)"`
+	RatioZY float64 `json:"ratioZY" desc:"Container depth ratio (ZY), 0 for a 2D tree"`
+	Index   int     `json:"index" desc:"Index of the layout to generate"`
+}
+
+// The parameters of a UniformTreeGenerator in a spec.
+type UniformParams struct {
+	generators.RatioParams
+	Leaves      int     `json:"leaves" desc:"Number of leaves"`
+	Seed        int64   `json:"seed" desc:"Random seed"`
+	RatioXY     float64 `json:"ratioXY" desc:"Container ratio (XY)"`
+	RatioZY     float64 `json:"ratioZY" desc:"Container depth ratio (ZY), 0 for a 2D tree"`
+	MaxAttempts int     `json:"maxAttempts" desc:"Number of trees sampled before giving up, 0 for the default"`
+}
+
+func init() {
+	generators.Register(&generators.Registration{
+		Name: "Enumerate",
+		Defaults: func() interface{} {
+			return &EnumerateParams{
+				RatioParams: generators.RatioParams{Family: "golden"},
+				Leaves:      4,
+				RatioXY:     1,
+			}
+		},
+		New: func(params interface{}) (generators.TreeGenerator, error) {
+			p, ok := params.(*EnumerateParams)
+			if !ok {
+				return nil, generators.ErrInvalidParams
+			}
+
+			ratioSource, err := p.RatioSource()
+			if err != nil {
+				return nil, err
+			}
+
+			gen, err := New3D(ratioSource, p.RatioXY, p.RatioZY, p.Leaves)
+			if err != nil {
+				return nil, err
+			}
+			gen.Index = p.Index
+			return gen, nil
+		},
+		Params: func(gen generators.TreeGenerator) (interface{}, error) {
+			g, ok := gen.(*EnumerateTreeGenerator)
+			if !ok {
+				return nil, generators.ErrInvalidParams
+			}
+
+			return &EnumerateParams{
+				RatioParams: generators.NewRatioParams(g.RatioSource),
+				Leaves:      g.NumLeaves,
+				RatioXY:     g.XYRatio,
+				RatioZY:     g.ZYRatio,
+				Index:       g.Index,
+			}, nil
+		},
+		NumLeaves: func(params interface{}) int {
+			return params.(*EnumerateParams).Leaves
+		},
+	})
+
+	generators.Register(&generators.Registration{
+		Name: "Uniform",
+		Defaults: func() interface{} {
+			return &UniformParams{
+				RatioParams: generators.RatioParams{Family: "golden"},
+				Leaves:      10,
+				RatioXY:     1,
+				MaxAttempts: defaultMaxAttempts,
+			}
+		},
+		New: func(params interface{}) (generators.TreeGenerator, error) {
+			p, ok := params.(*UniformParams)
+			if !ok {
+				return nil, generators.ErrInvalidParams
+			}
+
+			ratioSource, err := p.RatioSource()
+			if err != nil {
+				return nil, err
+			}
+
+			gen, err := NewUniform3D(ratioSource, p.RatioXY, p.RatioZY, p.Leaves, p.Seed)
+			if err != nil {
+				return nil, err
+			}
+			gen.MaxAttempts = p.MaxAttempts
+			return gen, nil
+		},
+		Params: func(gen generators.TreeGenerator) (interface{}, error) {
+			g, ok := gen.(*UniformTreeGenerator)
+			if !ok {
+				return nil, generators.ErrInvalidParams
+			}
+
+			return &UniformParams{
+				RatioParams: generators.NewRatioParams(g.RatioSource),
+				Leaves:      g.NumLeaves,
+				Seed:        g.Seed,
+				RatioXY:     g.XYRatio,
+				RatioZY:     g.ZYRatio,
+				MaxAttempts: g.MaxAttempts,
+			}, nil
+		},
+		NumLeaves: func(params interface{}) int {
+			return params.(*UniformParams).Leaves
+		},
+	})
+}
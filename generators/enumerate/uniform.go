@@ -0,0 +1,256 @@
+package enumerate
+
+import (
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"math/big"
+	"math/rand"
+	"sync"
+)
+
+var ErrNoLayouts = errors.New("No layouts with the number of leaves")
+var ErrMaxAttemptsReached = errors.New("Gave up sampling before a tree was kept")
+
+const defaultMaxAttempts = 10000
+
+// Samples layouts with NumLeaves leaves uniformly, every distinct layout is
+// equally likely no matter how many trees describe it.
+//
+// Trees are sampled uniformly using the number of subtrees of each region,
+// then a tree is kept with a probability inversely proportional to the number
+// of trees with the same layout. A generator can be shared between goroutines.
+type UniformTreeGenerator struct {
+	NumLeaves   int
+	RatioSource htree.RatioSource
+	Complements htree.Complements
+	Seed        int64
+	XYRatio     float64
+	ZYRatio     float64
+	MaxAttempts int // Limits the trees sampled before giving up, 0 for the default
+
+	mu     sync.Mutex
+	counts map[countKey]*big.Int
+}
+
+// Identifies the subtrees of a region with a number of leaves.
+type countKey struct {
+	ratioIndexXY int
+	ratioIndexZY int
+	numLeaves    int
+}
+
+func NewUniform(ratioSource htree.RatioSource, containerRatio float64, numLeaves int, seed int64) (*UniformTreeGenerator, error) {
+	return NewUniform3D(ratioSource, containerRatio, 0, numLeaves, seed)
+}
+
+func NewUniform3D(ratioSource htree.RatioSource, xyRatio, zyRatio float64, numLeaves int, seed int64) (*UniformTreeGenerator, error) {
+	if numLeaves < 1 {
+		return nil, ErrInvalidNumLeaves
+	}
+
+	complements, err := htree.NewRatioSourceComplements(ratioSource, defaultEpsilon)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UniformTreeGenerator{
+		NumLeaves:   numLeaves,
+		RatioSource: ratioSource,
+		Complements: complements,
+		Seed:        seed,
+		XYRatio:     xyRatio,
+		ZYRatio:     zyRatio,
+		MaxAttempts: defaultMaxAttempts,
+	}, nil
+}
+
+func (gen *UniformTreeGenerator) Is3D() bool {
+	return gen.ZYRatio > 0
+}
+
+func (gen *UniformTreeGenerator) space() *layoutSpace {
+	return &layoutSpace{
+		ratioSource: gen.RatioSource,
+		complements: gen.Complements,
+		is3D:        gen.Is3D(),
+	}
+}
+
+// Returns the number of trees of the region with n leaves, counts are cached
+// so they are only computed once per generator. The cache is only touched
+// under the lock, a count is never modified once it is cached.
+func (gen *UniformTreeGenerator) count(space *layoutSpace, ratioIndexXY, ratioIndexZY, n int) *big.Int {
+	if n == 1 {
+		return big.NewInt(1)
+	}
+
+	key := countKey{ratioIndexXY, ratioIndexZY, n}
+	if count, ok := gen.cachedCount(key); ok {
+		return count
+	}
+
+	count := new(big.Int)
+	product := new(big.Int)
+	for _, split := range space.splits(ratioIndexXY, ratioIndexZY) {
+		leftXY, leftZY, rightXY, rightZY := htree.SplitRatioIndexes(space.ratioSource, ratioIndexXY, ratioIndexZY, split)
+		for k := 1; k < n; k++ {
+			product.Mul(gen.count(space, leftXY, leftZY, k), gen.count(space, rightXY, rightZY, n-k))
+			count.Add(count, product)
+		}
+	}
+
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+	if gen.counts == nil {
+		gen.counts = make(map[countKey]*big.Int)
+	}
+	gen.counts[key] = count
+	return count
+}
+
+func (gen *UniformTreeGenerator) cachedCount(key countKey) (*big.Int, bool) {
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+	count, ok := gen.counts[key]
+	return count, ok
+}
+
+// Returns the number of trees, not layouts, with NumLeaves leaves.
+func (gen *UniformTreeGenerator) NumTrees() (*big.Int, error) {
+	space := gen.space()
+	ratioIndexXY, ratioIndexZY, err := space.containerIndexes(gen.XYRatio, gen.ZYRatio)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).Set(gen.count(space, ratioIndexXY, ratioIndexZY, gen.NumLeaves)), nil
+}
+
+// Picks one of the trees of the region with n leaves uniformly.
+func (gen *UniformTreeGenerator) sample(rnd *rand.Rand, space *layoutSpace, ratioIndexXY, ratioIndexZY, n int) *shape {
+	if n == 1 {
+		return nil
+	}
+
+	target := new(big.Int).Rand(rnd, gen.count(space, ratioIndexXY, ratioIndexZY, n))
+	product := new(big.Int)
+	for _, split := range space.splits(ratioIndexXY, ratioIndexZY) {
+		leftXY, leftZY, rightXY, rightZY := htree.SplitRatioIndexes(space.ratioSource, ratioIndexXY, ratioIndexZY, split)
+		for k := 1; k < n; k++ {
+			product.Mul(gen.count(space, leftXY, leftZY, k), gen.count(space, rightXY, rightZY, n-k))
+			if target.Cmp(product) < 0 {
+				return &shape{
+					split: split,
+					left:  gen.sample(rnd, space, leftXY, leftZY, k),
+					right: gen.sample(rnd, space, rightXY, rightZY, n-k),
+				}
+			}
+			target.Sub(target, product)
+		}
+	}
+
+	panic("Sample exceeded count")
+}
+
+func (gen *UniformTreeGenerator) Generate() (htree.Tree, error) {
+	rnd := rand.New(rand.NewSource(gen.Seed))
+
+	space := gen.space()
+	ratioIndexXY, ratioIndexZY, err := space.containerIndexes(gen.XYRatio, gen.ZYRatio)
+	if err != nil {
+		return nil, err
+	}
+
+	if gen.count(space, ratioIndexXY, ratioIndexZY, gen.NumLeaves).Sign() == 0 {
+		return nil, ErrNoLayouts
+	}
+
+	maxAttempts := gen.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		tree := space.build(ratioIndexXY, ratioIndexZY, gen.sample(rnd, space, ratioIndexXY, ratioIndexZY, gen.NumLeaves))
+
+		// Keep the tree with a probability of 1 / multiplicity
+		multiplicity := space.treeMultiplicity(tree)
+		if multiplicity.Cmp(big.NewInt(1)) == 0 || new(big.Int).Rand(rnd, multiplicity).Sign() == 0 {
+			return tree, nil
+		}
+	}
+
+	return nil, ErrMaxAttemptsReached
+}
+
+// Returns the number of trees with the same ratio source and container whose
+// leaves are identical to the leaves of the tree, including the tree itself.
+func Multiplicity(tree htree.Tree) *big.Int {
+	complements, err := htree.NewRatioSourceComplements(tree.RatioSource(), defaultEpsilon)
+	if err != nil {
+		return big.NewInt(1)
+	}
+
+	space := &layoutSpace{
+		ratioSource: tree.RatioSource(),
+		complements: complements,
+		is3D:        htree.IsRatioIndexDefined(tree.RatioIndexZY()),
+	}
+
+	return space.treeMultiplicity(tree)
+}
+
+// Counts the trees in the space with the same leaves as the tree.
+func (space *layoutSpace) treeMultiplicity(tree htree.Tree) *big.Int {
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	var leaves []*htree.AlignedBox
+	for _, leaf := range algo.FindLeaves(tree) {
+		leaves = append(leaves, regionMap[leaf.ID()].AlignedBox())
+	}
+
+	return space.multiplicity(regionMap[tree.Root().ID()], leaves)
+}
+
+// Counts the ways the region can be split into exactly the leaves.
+func (space *layoutSpace) multiplicity(region *htree.Region, leaves []*htree.AlignedBox) *big.Int {
+	if len(leaves) == 1 {
+		return big.NewInt(1)
+	}
+
+	epsilon := 0.0000001
+	total := new(big.Int)
+	for _, split := range space.splits(region.RatioIndexXY(), region.RatioIndexZY()) {
+		left, right := htree.SplitRegion(space.ratioSource, region, split.Type(), split.LeftIndex(), split.RightIndex())
+		leftBox, rightBox := left.AlignedBox(), right.AlignedBox()
+
+		var leftLeaves, rightLeaves []*htree.AlignedBox
+		valid := true
+		for _, leaf := range leaves {
+			if contains(leftBox, leaf, epsilon) {
+				leftLeaves = append(leftLeaves, leaf)
+			} else if contains(rightBox, leaf, epsilon) {
+				rightLeaves = append(rightLeaves, leaf)
+			} else {
+				valid = false
+				break
+			}
+		}
+
+		if !valid || len(leftLeaves) == 0 || len(rightLeaves) == 0 {
+			continue
+		}
+
+		product := new(big.Int).Mul(space.multiplicity(left, leftLeaves), space.multiplicity(right, rightLeaves))
+		total.Add(total, product)
+	}
+
+	return total
+}
+
+// Returns true if the box is inside of the container.
+func contains(container, box *htree.AlignedBox, epsilon float64) bool {
+	return box.Left() > container.Left()-epsilon && box.Right() < container.Right()+epsilon &&
+		box.Top() > container.Top()-epsilon && box.Bottom() < container.Bottom()+epsilon &&
+		box.Front() > container.Front()-epsilon && box.Back() < container.Back()+epsilon
+}
@@ -0,0 +1,200 @@
+package enumerate_test
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/generators/enumerate"
+	"github.com/scisci/hambidgetree/golden"
+	"sync"
+	"testing"
+)
+
+func TestMultiplicity(t *testing.T) {
+	gen, err := enumerate.New(newRatioSource(t), 1, 4)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	// Only the 2x2 grid can be split in two orders
+	total := 0
+	gen.Enumerate(func(tree htree.Tree) bool {
+		total += int(enumerate.Multiplicity(tree).Int64())
+		return true
+	})
+
+	uniform, err := enumerate.NewUniform(newRatioSource(t), 1, 4, 0)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	numTrees, err := uniform.NumTrees()
+	if err != nil {
+		t.Fatalf("Failed to count trees %v", err)
+	}
+
+	if int64(total) != numTrees.Int64() {
+		t.Errorf("Expected multiplicities to add up to %d trees, got %d", numTrees.Int64(), total)
+	}
+
+	count, _ := gen.Count()
+	if total != count+1 {
+		t.Errorf("Expected %d trees for %d layouts, got %d", count+1, count, total)
+	}
+}
+
+func TestUniform(t *testing.T) {
+	ratioSource := newRatioSource(t)
+
+	gen, err := enumerate.New(ratioSource, 1, 4)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	frequencies := make(map[string]int)
+	gen.Enumerate(func(tree htree.Tree) bool {
+		frequencies[algo.LayoutSignature(tree)] = 0
+		return true
+	})
+
+	samples := 200 * len(frequencies)
+	for seed := 0; seed < samples; seed++ {
+		uniform, err := enumerate.NewUniform(ratioSource, 1, 4, int64(seed))
+		if err != nil {
+			t.Fatalf("Failed to create generator %v", err)
+		}
+
+		tree, err := uniform.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate %v", err)
+		}
+
+		signature := algo.LayoutSignature(tree)
+		if _, ok := frequencies[signature]; !ok {
+			t.Fatalf("Generated a layout that isn't enumerated")
+		}
+		frequencies[signature]++
+	}
+
+	// Each layout should show up about 200 times, the grid included
+	for _, frequency := range frequencies {
+		if frequency < 140 || frequency > 260 {
+			t.Errorf("Expected about 200 samples of each layout, got %d", frequency)
+		}
+	}
+}
+
+func TestUniform3D(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		gen, err := enumerate.NewUniform3D(golden.RatioSource(), 1, 1, 5, seed)
+		if err != nil {
+			t.Fatalf("Failed to create generator %v", err)
+		}
+
+		tree, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate %v", err)
+		}
+
+		if n := len(algo.FindLeaves(tree)); n != 5 {
+			t.Errorf("Expected 5 leaves, got %d", n)
+		}
+	}
+}
+
+func TestUniformErrors(t *testing.T) {
+	if _, err := enumerate.NewUniform(newRatioSource(t), 1, 0, 0); err != enumerate.ErrInvalidNumLeaves {
+		t.Errorf("Expected invalid number of leaves, got %v", err)
+	}
+
+	ratioSource, err := htree.NewBasicRatioSource([]float64{1})
+	if err != nil {
+		t.Fatalf("Failed to create ratio source %v", err)
+	}
+
+	gen, err := enumerate.NewUniform(ratioSource, 1, 2, 0)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	// A square can't be split into squares
+	if _, err := gen.Generate(); err != enumerate.ErrNoLayouts {
+		t.Errorf("Expected no layouts, got %v", err)
+	}
+}
+
+func TestUniformShared(t *testing.T) {
+	// A struct literal has no cached counts yet
+	gen := &enumerate.UniformTreeGenerator{
+		NumLeaves:   5,
+		RatioSource: golden.RatioSource(),
+		Seed:        3,
+		XYRatio:     1,
+		ZYRatio:     1,
+	}
+
+	complements, err := htree.NewRatioSourceComplements(gen.RatioSource, 0.0000001)
+	if err != nil {
+		t.Fatalf("Failed to create complements %v", err)
+	}
+	gen.Complements = complements
+
+	expected, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+
+	// Fresh counts are computed concurrently
+	gen.NumLeaves = 6
+	var wg sync.WaitGroup
+	signatures := make([]string, 8)
+	for i := range signatures {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if tree, err := gen.Generate(); err == nil {
+				signatures[i] = algo.LayoutSignature(tree)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, signature := range signatures {
+		if signature == "" || signature != signatures[0] {
+			t.Fatalf("Expected the shared generator to give the same tree, got %v", signatures)
+		}
+	}
+
+	gen.NumLeaves = 5
+	tree, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate %v", err)
+	}
+	if algo.LayoutSignature(tree) != algo.LayoutSignature(expected) {
+		t.Errorf("Expected the same tree after caching more counts")
+	}
+}
+
+func TestUniformMaxAttempts(t *testing.T) {
+	// The 2x2 grid is rejected half the time so some seed gives up after one
+	// attempt
+	reached := false
+	for seed := int64(0); seed < 200 && !reached; seed++ {
+		gen, err := enumerate.NewUniform(newRatioSource(t), 1, 4, seed)
+		if err != nil {
+			t.Fatalf("Failed to create generator %v", err)
+		}
+		gen.MaxAttempts = 1
+
+		switch _, err := gen.Generate(); err {
+		case nil:
+		case enumerate.ErrMaxAttemptsReached:
+			reached = true
+		default:
+			t.Fatalf("Failed to generate %v", err)
+		}
+	}
+
+	if !reached {
+		t.Errorf("Expected max attempts to be reached")
+	}
+}
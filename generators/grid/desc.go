@@ -0,0 +1,20 @@
+package grid
+
+import (
+	"github.com/scisci/hambidgetree/generators"
+)
+
+func (gen *GridTreeGenerator) Name() string {
+	return "Grid"
+}
+
+func (gen *GridTreeGenerator) Description() string {
+	return "This algorithm splits every leaf in half at each level, alternating between vertical and horizontal splits (and depth splits in 3D), producing a regular grid. It is mostly useful for testing."
+}
+
+func (gen *GridTreeGenerator) Parameters(f generators.ParameterFormatType) map[string]interface{} {
+	return map[string]interface{}{
+		"Levels": gen.Levels,
+		"3D":     gen.Is3D,
+	}
+}
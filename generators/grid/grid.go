@@ -57,3 +57,23 @@ func New3D(levels int) *simple.Tree {
 	tree, _ := builder.Build()
 	return tree
 }
+
+// Generates a grid as a generators.TreeGenerator.
+type GridTreeGenerator struct {
+	Levels int
+	Is3D   bool
+}
+
+func NewGenerator(levels int, is3D bool) *GridTreeGenerator {
+	return &GridTreeGenerator{
+		Levels: levels,
+		Is3D:   is3D,
+	}
+}
+
+func (gen *GridTreeGenerator) Generate() (htree.Tree, error) {
+	if gen.Is3D {
+		return New3D(gen.Levels), nil
+	}
+	return New2D(gen.Levels), nil
+}
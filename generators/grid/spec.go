@@ -0,0 +1,42 @@
+package grid
+
+import (
+	"github.com/scisci/hambidgetree/generators"
+	"math"
+)
+
+// The parameters of a GridTreeGenerator in a spec.
+type Params struct {
+	Levels int  `json:"levels" desc:"Number of times every leaf is split in half"`
+	Is3D   bool `json:"is3D" desc:"Generate a 3D tree"`
+}
+
+func init() {
+	generators.Register(&generators.Registration{
+		Name: "Grid",
+		Defaults: func() interface{} {
+			return &Params{Levels: 2}
+		},
+		New: func(params interface{}) (generators.TreeGenerator, error) {
+			p, ok := params.(*Params)
+			if !ok {
+				return nil, generators.ErrInvalidParams
+			}
+			return NewGenerator(p.Levels, p.Is3D), nil
+		},
+		Params: func(gen generators.TreeGenerator) (interface{}, error) {
+			g, ok := gen.(*GridTreeGenerator)
+			if !ok {
+				return nil, generators.ErrInvalidParams
+			}
+			return &Params{Levels: g.Levels, Is3D: g.Is3D}, nil
+		},
+		NumLeaves: func(params interface{}) int {
+			levels := params.(*Params).Levels
+			if levels >= 31 {
+				return math.MaxInt32
+			}
+			return 1 << uint(levels)
+		},
+	})
+}
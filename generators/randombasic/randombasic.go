@@ -37,7 +37,7 @@ func IsSplitDepth(split htree.Split) bool {
 }
 
 func New(ratioSource htree.RatioSource, containerRatio float64, numLeaves int, seed int64) (*RandomBasicTreeGenerator, error) {
-	complements, err := htree.NewComplements(ratioSource.Ratios(), defaultEpsilon)
+	complements, err := htree.NewRatioSourceComplements(ratioSource, defaultEpsilon)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +52,7 @@ func New(ratioSource htree.RatioSource, containerRatio float64, numLeaves int, s
 }
 
 func New3D(ratioSource htree.RatioSource, xyRatio, zyRatio float64, numLeaves int, seed int64) (*RandomBasicTreeGenerator, error) {
-	complements, err := htree.NewComplements(ratioSource.Ratios(), defaultEpsilon)
+	complements, err := htree.NewRatioSourceComplements(ratioSource, defaultEpsilon)
 	if err != nil {
 		return nil, err
 	}
@@ -85,100 +85,10 @@ func (gen *RandomBasicTreeGenerator) filterLeaves2D(leaf htree.Leaf, complements
 }
 
 func (gen *RandomBasicTreeGenerator) filterLeaves3D(leaf htree.Leaf, complements htree.Complements) *leafSplits {
-	ratios := gen.RatioSource.Ratios()
 	// We have horizontal and vertical splits defined in the complements array.
 	// We have 3 possible planes that could be divided vertically/horizontally.
-	xyRatioIndex := leaf.RatioIndexXY()
-	zyRatioIndex := leaf.RatioIndexZY()
-
-	xyComplements := complements[xyRatioIndex]
-	zyComplements := complements[zyRatioIndex]
-
-	// Visit each horizontal/vertical and see if it is compatible with the zy and
-	// zx planes.
-
-	// Any horizontal cut on the xy axis, affects the zy axis:
-	//   XYCutHeight = XYRatio / XYRatioTop
-	//   ZYRatioTop = ZYRatio / XYCutHeight
-	//   Compatible if ZYRatioTop can be found in the index
-	// Any vertical cut on the xy axis, affects the zx axis:
-	//   XYCutWidth = XYRatioLeft / XYRatio
-	//   XZRatioTop = XZRatio / XYCutWidth
-	// Any vertical cut on the zy axis, affects the zx axis
-	//   ZYCutWidth = ZYRatioLeft / ZYRatio
-	//   XZRatioLeft = ZYCutWidth / XZRatio
-
-	// Take each vertical split possible for the zyRatioIndex and check it against
-	// the zx plane. If good, then add these to the possibilities as a DepthSplit
-	// (instead of a vertical split)
-
-	xyRatio := ratios[xyRatioIndex]
-	zyRatio := ratios[zyRatioIndex]
-	zxRatio := zyRatio / xyRatio
-	var splits []htree.Split
-
-	for _, xySplit := range xyComplements {
-		if IsSplitHorizontal(xySplit) {
-			cutHeight := htree.RatioNormalHeight(xyRatio, ratios[xySplit.LeftIndex()])
-			compHeight := htree.RatioNormalHeight(xyRatio, ratios[xySplit.RightIndex()])
-			zyRatioTop := zyRatio / cutHeight
-			zyRatioBottom := zyRatio / compHeight
-			index := htree.FindClosestIndexWithinRange(ratios, zyRatioTop, defaultEpsilon)
-			if index < 0 {
-				continue
-			}
-
-			index = htree.FindClosestIndexWithinRange(ratios, zyRatioBottom, defaultEpsilon)
-			if index < 0 {
-				continue
-				//fmt.Printf("tried to split h ratio %f, got %f and %f, but %f is not a valid ratio\n", xyRatio, zyRatioTop, zyRatioBottom, zyRatioBottom)
-				//panic("right invalid")
-			}
-		} else if IsSplitVertical(xySplit) {
-			cutWidth := htree.RatioNormalWidth(xyRatio, ratios[xySplit.LeftIndex()])
-			compWidth := htree.RatioNormalWidth(xyRatio, ratios[xySplit.RightIndex()])
-			zxRatioTop := zxRatio / cutWidth
-			zxRatioBottom := zxRatio / compWidth
-			index := htree.FindClosestIndexWithinRange(ratios, zxRatioTop, defaultEpsilon)
-			if index < 0 {
-				continue
-			}
-
-			index = htree.FindClosestIndexWithinRange(ratios, zxRatioBottom, defaultEpsilon)
-			if index < 0 {
-				continue
-				//fmt.Printf("tried to split v ratio (xy:%f, xz:%f, zy:%f) with width %f from ratio %f against xz %f, got %f and %f, but %f is not a valid ratio\n", xyRatio, xzRatio, zyRatio, cutWidth, leaf.tree.Ratio(xySplit.LeftIndex()), xzRatio, xzRatioTop, xzRatioBottom, xzRatioBottom)
-				//panic("right invalid")
-			}
-		} else {
-			panic("What type?")
-		}
-
-		splits = append(splits, xySplit)
-	}
-
-	for _, zySplit := range zyComplements {
-		if !IsSplitVertical(zySplit) {
-			continue
-		}
-
-		cutWidth := htree.RatioNormalWidth(zyRatio, ratios[zySplit.LeftIndex()])
-		compWidth := htree.RatioNormalWidth(zyRatio, ratios[zySplit.RightIndex()])
-		zxRatioLeft := cutWidth * zxRatio
-		index := htree.FindClosestIndexWithinRange(ratios, zxRatioLeft, defaultEpsilon)
-		if index < 0 {
-			continue
-		}
-
-		zxRatioRight := compWidth * zxRatio
-		index = htree.FindClosestIndexWithinRange(ratios, zxRatioRight, defaultEpsilon)
-		if index < 0 {
-			continue
-			//fmt.Printf("tried to split d ratio %f, got %f and %f, but %f is not a valid ratio\n", xyRatio, xzRatioLeft, xzRatioRight, xzRatioRight)
-			//panic("right invalid")
-		}
-		splits = append(splits, htree.NewDepthSplit(zySplit.LeftIndex(), zySplit.RightIndex()))
-	}
+	splits := htree.AvailableRatioSourceSplits(gen.RatioSource, complements,
+		leaf.RatioIndexXY(), leaf.RatioIndexZY(), defaultEpsilon)
 
 	if len(splits) == 0 {
 		return nil
@@ -191,17 +101,18 @@ func (gen *RandomBasicTreeGenerator) filterLeaves3D(leaf htree.Leaf, complements
 }
 
 func (gen *RandomBasicTreeGenerator) Generate() (htree.Tree, error) {
-	rand.Seed(gen.Seed)
+	// Use a source of our own so generators can run at the same time
+	rnd := rand.New(rand.NewSource(gen.Seed))
 
 	ratios := gen.RatioSource.Ratios()
 
 	epsilon := htree.CalculateRatiosEpsilon(ratios)
-	xyRatioIndex := htree.FindClosestIndex(ratios, gen.XYRatio, epsilon)
+	xyRatioIndex := htree.FindClosestRatioSourceIndex(gen.RatioSource, gen.XYRatio, epsilon)
 	if xyRatioIndex < 0 {
 		return nil, errors.New("Container ratio not found in list of ratios.")
 	}
 
-	complements, err := htree.NewComplements(ratios, defaultEpsilon)
+	complements, err := htree.NewRatioSourceComplements(gen.RatioSource, defaultEpsilon)
 	if err != nil {
 		return nil, err
 	}
@@ -211,7 +122,7 @@ func (gen *RandomBasicTreeGenerator) Generate() (htree.Tree, error) {
 	if !gen.Is3D() {
 		treeBuilder = builder.New2D(gen.RatioSource, xyRatioIndex)
 	} else {
-		zyRatioIndex := htree.FindClosestIndex(ratios, gen.ZYRatio, epsilon)
+		zyRatioIndex := htree.FindClosestRatioSourceIndex(gen.RatioSource, gen.ZYRatio, epsilon)
 		if zyRatioIndex < 0 {
 			return nil, errors.New("Container ratio not found in list of ratios.")
 		}
@@ -256,17 +167,17 @@ func (gen *RandomBasicTreeGenerator) Generate() (htree.Tree, error) {
 		}
 
 		// Choose a leaf at random
-		leafIndex := rand.Intn(len(filteredLeaves))
+		leafIndex := rnd.Intn(len(filteredLeaves))
 		filteredLeaf := filteredLeaves[leafIndex]
 		splits := filteredLeaf.splits
 
 		// Choose a random split
-		splitIndex := rand.Intn(len(splits))
+		splitIndex := rnd.Intn(len(splits))
 		split := splits[splitIndex]
 
 		// Randomly invert the split (by default complements always have the smaller)
 		// ratio on the left, but we want it to be evenly distributed.
-		if rand.Int()&1 == 0 {
+		if rnd.Int()&1 == 0 {
 			split = htree.NewInvertedSplit(split)
 		}
 
@@ -5,6 +5,7 @@ import (
 	htree "github.com/scisci/hambidgetree"
 	"github.com/scisci/hambidgetree/algo"
 	"github.com/scisci/hambidgetree/golden"
+	"sync"
 	"testing"
 )
 
@@ -93,3 +94,44 @@ func TestGenerator3D(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateConcurrent(t *testing.T) {
+	numTrees := 32
+
+	generate := func(seed int64) string {
+		gen, err := New3D(golden.RatioSource(), 1, 1, 20, seed)
+		if err != nil {
+			t.Errorf("Error creating tree %v", err)
+			return ""
+		}
+		tree, err := gen.Generate()
+		if err != nil {
+			t.Errorf("Error generating tree %v", err)
+			return ""
+		}
+		return algo.HashString(tree)
+	}
+
+	expected := make([]string, numTrees)
+	for i := range expected {
+		expected[i] = generate(int64(i))
+	}
+
+	// Each seed must make the same tree no matter what else is running
+	hashes := make([]string, numTrees)
+	var wg sync.WaitGroup
+	for i := range hashes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hashes[i] = generate(int64(i))
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range hashes {
+		if hashes[i] != expected[i] {
+			t.Errorf("Seed %d generated a different tree when run concurrently", i)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package randombasic
+
+import (
+	"github.com/scisci/hambidgetree/generators"
+)
+
+// The parameters of a RandomBasicTreeGenerator in a spec.
+type Params struct {
+	generators.RatioParams
+	Leaves  int     `json:"leaves" desc:"Number of leaves"`
+	Seed    int64   `json:"seed" desc:"Random seed"`
+	RatioXY float64 `json:"ratioXY" desc:"Container ratio (XY)"`
+	RatioZY float64 `json:"ratioZY" desc:"Container depth ratio (ZY), 0 for a 2D tree"`
+}
+
+func init() {
+	generators.Register(&generators.Registration{
+		Name: "Random Basic",
+		Defaults: func() interface{} {
+			return &Params{
+				RatioParams: generators.RatioParams{Family: "golden"},
+				Leaves:      20,
+				RatioXY:     1,
+			}
+		},
+		New: func(params interface{}) (generators.TreeGenerator, error) {
+			p, ok := params.(*Params)
+			if !ok {
+				return nil, generators.ErrInvalidParams
+			}
+
+			ratioSource, err := p.RatioSource()
+			if err != nil {
+				return nil, err
+			}
+			return New3D(ratioSource, p.RatioXY, p.RatioZY, p.Leaves, p.Seed)
+		},
+		Params: func(gen generators.TreeGenerator) (interface{}, error) {
+			g, ok := gen.(*RandomBasicTreeGenerator)
+			if !ok {
+				return nil, generators.ErrInvalidParams
+			}
+
+			return &Params{
+				RatioParams: generators.NewRatioParams(g.RatioSource),
+				Leaves:      g.NumLeaves,
+				Seed:        g.Seed,
+				RatioXY:     g.XYRatio,
+				RatioZY:     g.ZYRatio,
+			}, nil
+		},
+		NumLeaves: func(params interface{}) int {
+			return params.(*Params).Leaves
+		},
+	})
+}
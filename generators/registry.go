@@ -0,0 +1,308 @@
+package generators
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/families"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownGenerator = errors.New("Unknown generator")
+var ErrInvalidParams = errors.New("Params don't belong to the generator")
+
+// Describes how to create a generator from its parameters and how to get
+// the parameters back from a generator.
+type Registration struct {
+	// The name of the generator, the same as its Name method.
+	Name string
+	// Returns a pointer to a struct holding the default parameters, specs are
+	// decoded on top of it. The json tags of the fields are the parameter
+	// names and a desc tag may describe them.
+	Defaults func() interface{}
+	// Creates a generator from parameters of the type returned by Defaults.
+	New func(params interface{}) (TreeGenerator, error)
+	// Returns the parameters that create an identical generator.
+	Params func(gen TreeGenerator) (interface{}, error)
+	// Returns the number of leaves the parameters will generate, optional.
+	// Used to reject specs that are too large before generating them.
+	NumLeaves func(params interface{}) int
+}
+
+var registryMutex sync.RWMutex
+var registry = make(map[string]*Registration)
+
+// Makes a generator available to specs, generator packages register
+// themselves when they are imported. Panics if the name is taken.
+func Register(registration *Registration) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, ok := registry[registration.Name]; ok {
+		panic("Generator " + registration.Name + " is already registered")
+	}
+	registry[registration.Name] = registration
+}
+
+func Lookup(name string) (*Registration, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	registration, ok := registry[name]
+	if !ok {
+		return nil, ErrUnknownGenerator
+	}
+	return registration, nil
+}
+
+// Returns the names of the registered generators sorted alphabetically.
+func Names() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns the name of the registered generator that matches the name when
+// case and spaces are ignored, i.e. "randombasic" for "Random Basic".
+func ResolveName(name string) (string, error) {
+	key := nameKey(name)
+	for _, registered := range Names() {
+		if nameKey(registered) == key {
+			return registered, nil
+		}
+	}
+	return "", ErrUnknownGenerator
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.Replace(name, " ", "", -1))
+}
+
+// Creates a spec for a generator from a flat set of parameters that may be
+// shared by several generators, the ones the generator doesn't have are left
+// out. The name is resolved with ResolveName.
+func NewSpec(name string, params map[string]interface{}) (*Spec, error) {
+	name, err := ResolveName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := Schema(name)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make(map[string]interface{})
+	for _, param := range schema {
+		if value, ok := params[param.Name]; ok {
+			kept[param.Name] = value
+		}
+	}
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Spec{Generator: name, Params: data}, nil
+}
+
+// A generator and its parameters in a form that can be stored as JSON, i.e.
+// {"generator":"Random Basic","params":{"leaves":20,"seed":1}}. Parameters
+// left out use their default values.
+type Spec struct {
+	Generator string          `json:"generator"`
+	Params    json.RawMessage `json:"params,omitempty"`
+}
+
+// Decodes the parameters of the spec on top of the defaults of its generator.
+func (spec *Spec) decodeParams() (*Registration, interface{}, error) {
+	registration, err := Lookup(spec.Generator)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	params := registration.Defaults()
+	if len(spec.Params) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(spec.Params))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(params); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return registration, params, nil
+}
+
+// Returns the number of leaves the spec will generate, or -1 if its generator
+// doesn't say.
+func (spec *Spec) NumLeaves() (int, error) {
+	registration, params, err := spec.decodeParams()
+	if err != nil {
+		return 0, err
+	}
+
+	if registration.NumLeaves == nil {
+		return -1, nil
+	}
+	return registration.NumLeaves(params), nil
+}
+
+// Creates the generator described by the spec.
+func NewFromSpec(spec *Spec) (TreeGenerator, error) {
+	registration, params, err := spec.decodeParams()
+	if err != nil {
+		return nil, err
+	}
+
+	return registration.New(params)
+}
+
+// Same as NewFromSpec but decodes the spec from JSON first.
+func UnmarshalSpec(data []byte) (TreeGenerator, error) {
+	spec := &Spec{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+
+	return NewFromSpec(spec)
+}
+
+// Returns the spec that recreates the generator, it must be registered.
+func SpecFor(gen TreeGenerator) (*Spec, error) {
+	registration, err := Lookup(gen.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := registration.Params(gen)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Spec{
+		Generator: registration.Name,
+		Params:    data,
+	}, nil
+}
+
+// Same as SpecFor but encodes the spec as JSON.
+func MarshalSpec(gen TreeGenerator) ([]byte, error) {
+	spec, err := SpecFor(gen)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(spec)
+}
+
+// Describes one of the parameters of a generator.
+type ParameterSchema struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default"`
+}
+
+// Returns the parameters of the generator along with their types and
+// defaults, in the order they are declared.
+func Schema(name string) ([]ParameterSchema, error) {
+	registration, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return structSchema(reflect.ValueOf(registration.Defaults()).Elem()), nil
+}
+
+func structSchema(value reflect.Value) []ParameterSchema {
+	var schema []ParameterSchema
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+
+		// Embedded structs have their fields inlined by encoding/json
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			schema = append(schema, structSchema(value.Field(i))...)
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		schema = append(schema, ParameterSchema{
+			Name:        name,
+			Type:        typeName(field.Type),
+			Description: field.Tag.Get("desc"),
+			Default:     value.Field(i).Interface(),
+		})
+	}
+	return schema
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "[]" + typeName(t.Elem())
+	}
+	return "object"
+}
+
+// The parameters shared by generators that split with a ratio source. The
+// ratios are the exprs of the ratio source, if they are left out the ratios
+// of the family are used instead.
+type RatioParams struct {
+	Family string   `json:"family,omitempty" desc:"Name of a built in ratio family, used if ratios is empty"`
+	Ratios []string `json:"ratios,omitempty" desc:"Exprs of the ratios to split with"`
+	Exact  bool     `json:"exact" desc:"Evaluate the ratios exactly"`
+}
+
+// Returns the params for a ratio source, keeping its exprs.
+func NewRatioParams(ratioSource htree.RatioSource) RatioParams {
+	_, exact := ratioSource.(htree.ExactRatioSource)
+	return RatioParams{
+		Ratios: append([]string{}, ratioSource.Exprs()...),
+		Exact:  exact,
+	}
+}
+
+func (params *RatioParams) RatioSource() (htree.RatioSource, error) {
+	exprs := params.Ratios
+	if len(exprs) == 0 {
+		var err error
+		if exprs, err = families.Exprs(params.Family); err != nil {
+			return nil, err
+		}
+	}
+
+	if params.Exact {
+		return htree.NewExactRatioSource(exprs)
+	}
+	return htree.NewExprRatioSource(exprs)
+}
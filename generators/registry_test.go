@@ -0,0 +1,195 @@
+package generators_test
+
+import (
+	"encoding/json"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/generators"
+	_ "github.com/scisci/hambidgetree/generators/all"
+	"github.com/scisci/hambidgetree/generators/enumerate"
+	"github.com/scisci/hambidgetree/generators/randombasic"
+	"github.com/scisci/hambidgetree/golden"
+	"reflect"
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	expected := []string{"Constrained", "Enumerate", "Grid", "Random Basic", "Uniform"}
+	if names := generators.Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected generators %v, got %v", expected, names)
+	}
+}
+
+func TestSpecRoundTrip(t *testing.T) {
+	specs := []string{
+		`{"generator":"Random Basic","params":{"leaves":12,"seed":3}}`,
+		`{"generator":"Random Basic","params":{"family":"root2","exact":true,"leaves":8,"seed":1,"ratioZY":1}}`,
+		`{"generator":"Grid","params":{"levels":3,"is3D":true}}`,
+		`{"generator":"Enumerate","params":{"ratios":["1/2","1","2"],"leaves":4,"index":2}}`,
+		`{"generator":"Uniform","params":{"leaves":6,"seed":9}}`,
+		`{"generator":"Uniform","params":{"leaves":6,"seed":9,"maxAttempts":500}}`,
+		`{"generator":"Constrained","params":{"leaves":10,"seed":2,"minArea":0.02,"maxDepth":6}}`,
+	}
+
+	for _, spec := range specs {
+		gen, err := generators.UnmarshalSpec([]byte(spec))
+		if err != nil {
+			t.Fatalf("Failed to decode spec %s %v", spec, err)
+		}
+
+		tree, err := gen.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate %s %v", spec, err)
+		}
+
+		// The re-serialized spec must make the same tree
+		data, err := generators.MarshalSpec(gen)
+		if err != nil {
+			t.Fatalf("Failed to encode spec %v", err)
+		}
+
+		again, err := generators.UnmarshalSpec(data)
+		if err != nil {
+			t.Fatalf("Failed to decode spec %s %v", data, err)
+		}
+
+		againTree, err := again.Generate()
+		if err != nil {
+			t.Fatalf("Failed to generate %s %v", data, err)
+		}
+
+		if algo.HashString(tree) != algo.HashString(againTree) {
+			t.Errorf("Spec %s generated a different tree than %s", data, spec)
+		}
+	}
+
+	// Limits are kept along with the tree
+	gen, err := generators.UnmarshalSpec([]byte(`{"generator":"Uniform","params":{"leaves":6,"maxAttempts":500}}`))
+	if err != nil {
+		t.Fatalf("Failed to decode spec %v", err)
+	}
+
+	spec, err := generators.SpecFor(gen)
+	if err != nil {
+		t.Fatalf("Failed to get spec %v", err)
+	}
+
+	var params enumerate.UniformParams
+	if err := json.Unmarshal(spec.Params, &params); err != nil {
+		t.Fatalf("Failed to decode params %v", err)
+	}
+
+	if params.MaxAttempts != 500 {
+		t.Errorf("Expected 500 max attempts in spec, got %d", params.MaxAttempts)
+	}
+}
+
+func TestSpecFor(t *testing.T) {
+	gen, err := randombasic.New(golden.RatioSource(), 1, 15, 7)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+
+	spec, err := generators.SpecFor(gen)
+	if err != nil {
+		t.Fatalf("Failed to get spec %v", err)
+	}
+
+	if spec.Generator != gen.Name() {
+		t.Errorf("Expected generator %s, got %s", gen.Name(), spec.Generator)
+	}
+
+	if n, err := spec.NumLeaves(); err != nil || n != 15 {
+		t.Errorf("Expected 15 leaves, got %d %v", n, err)
+	}
+
+	var params randombasic.Params
+	if err := json.Unmarshal(spec.Params, &params); err != nil {
+		t.Fatalf("Failed to decode params %v", err)
+	}
+
+	if params.Seed != 7 || len(params.Ratios) != len(golden.Exprs) {
+		t.Errorf("Expected params of the generator, got %+v", params)
+	}
+}
+
+func TestSpecErrors(t *testing.T) {
+	if _, err := generators.UnmarshalSpec([]byte(`{"generator":"Missing"}`)); err != generators.ErrUnknownGenerator {
+		t.Errorf("Expected unknown generator, got %v", err)
+	}
+
+	if _, err := generators.UnmarshalSpec([]byte(`{"generator":"Grid","params":{"level":3}}`)); err == nil {
+		t.Errorf("Expected unknown parameter to fail")
+	}
+
+	if _, err := generators.UnmarshalSpec([]byte(`{"generator":"Random Basic","params":{"family":"missing"}}`)); err == nil {
+		t.Errorf("Expected unknown family to fail")
+	}
+}
+
+func TestNewSpec(t *testing.T) {
+	params := map[string]interface{}{
+		"leaves":  12,
+		"seed":    3,
+		"levels":  4,
+		"family":  "golden",
+		"ratioXY": 1,
+	}
+
+	// Parameters the generator doesn't have are left out
+	spec, err := generators.NewSpec("randombasic", params)
+	if err != nil {
+		t.Fatalf("Failed to create spec %v", err)
+	}
+	if spec.Generator != "Random Basic" {
+		t.Errorf("Expected Random Basic, got %s", spec.Generator)
+	}
+
+	gen, err := generators.NewFromSpec(spec)
+	if err != nil {
+		t.Fatalf("Failed to create generator %v", err)
+	}
+	if g := gen.(*randombasic.RandomBasicTreeGenerator); g.NumLeaves != 12 || g.Seed != 3 {
+		t.Errorf("Expected 12 leaves with seed 3, got %d with seed %d", g.NumLeaves, g.Seed)
+	}
+
+	spec, err = generators.NewSpec("GRID", params)
+	if err != nil {
+		t.Fatalf("Failed to create spec %v", err)
+	}
+	if leaves, err := spec.NumLeaves(); err != nil || leaves != 16 {
+		t.Errorf("Expected a grid with 16 leaves, got %d %v", leaves, err)
+	}
+
+	if _, err := generators.NewSpec("random", params); err != generators.ErrUnknownGenerator {
+		t.Errorf("Expected unknown generator, got %v", err)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema, err := generators.Schema("Random Basic")
+	if err != nil {
+		t.Fatalf("Failed to get schema %v", err)
+	}
+
+	types := make(map[string]string)
+	for _, param := range schema {
+		types[param.Name] = param.Type
+	}
+
+	expected := map[string]string{
+		"family":  "string",
+		"ratios":  "[]string",
+		"exact":   "bool",
+		"leaves":  "int",
+		"seed":    "int",
+		"ratioXY": "float",
+		"ratioZY": "float",
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected parameters %v, got %v", expected, types)
+	}
+
+	if _, err := generators.Schema("Missing"); err != generators.ErrUnknownGenerator {
+		t.Errorf("Expected unknown generator, got %v", err)
+	}
+}
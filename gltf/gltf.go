@@ -0,0 +1,340 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/attributors"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbVersion   = 2
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBIN  = 0x004E4942 // "BIN\0"
+
+	componentTypeFloat         = 5126
+	componentTypeUnsignedShort = 5123
+	targetArrayBuffer          = 34962
+	targetElementArrayBuffer   = 34963
+)
+
+// The default depth of the leaves of 2D trees, a hundredth of the height of
+// the container so their nodes don't have a flat scale.
+const defaultThickness = 0.01
+
+// Options used when creating a glTF document from a tree.
+type Options struct {
+	Offset       *htree.Vector          // Offset of the container, as in NewTreeRegionMap
+	Scale        float64                // Scale of the container, as in NewTreeRegionMap
+	Thickness    float64                // Depth given to the leaves of 2D trees
+	MaterialKey  string                 // Attribute whose value names a leaf's material
+	Colors       map[string]color.Color // Base color of each named material
+	DefaultColor color.Color            // Base color of leaves without a material
+}
+
+// Returns the options used when none are provided.
+func DefaultOptions() *Options {
+	return &Options{
+		Offset:       htree.Origin,
+		Scale:        htree.UnityScale,
+		Thickness:    defaultThickness,
+		DefaultColor: color.White,
+	}
+}
+
+// A glTF 2.0 document along with its binary buffer.
+type Document struct {
+	Asset       asset        `json:"asset"`
+	Scene       int          `json:"scene"`
+	Scenes      []scene      `json:"scenes"`
+	Nodes       []node       `json:"nodes"`
+	Meshes      []mesh       `json:"meshes"`
+	Materials   []material   `json:"materials"`
+	Buffers     []buffer     `json:"buffers"`
+	BufferViews []bufferView `json:"bufferViews"`
+	Accessors   []accessor   `json:"accessors"`
+
+	bin []byte
+}
+
+type asset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+type scene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type node struct {
+	Name        string      `json:"name,omitempty"`
+	Children    []int       `json:"children,omitempty"`
+	Mesh        *int        `json:"mesh,omitempty"`
+	Translation []float64   `json:"translation,omitempty"`
+	Scale       []float64   `json:"scale,omitempty"`
+	Extras      *nodeExtras `json:"extras,omitempty"`
+}
+
+// Extras carried by every leaf node.
+type nodeExtras struct {
+	NodeID     htree.NodeID      `json:"nodeID"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+type mesh struct {
+	Name       string      `json:"name,omitempty"`
+	Primitives []primitive `json:"primitives"`
+}
+
+type primitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   int            `json:"material"`
+}
+
+type material struct {
+	Name                 string               `json:"name,omitempty"`
+	PBRMetallicRoughness pbrMetallicRoughness `json:"pbrMetallicRoughness"`
+}
+
+type pbrMetallicRoughness struct {
+	BaseColorFactor [4]float64 `json:"baseColorFactor"`
+	MetallicFactor  float64    `json:"metallicFactor"`
+}
+
+type buffer struct {
+	ByteLength int    `json:"byteLength"`
+	URI        string `json:"uri,omitempty"`
+}
+
+type bufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target"`
+}
+
+type accessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+}
+
+// Creates a document with one scene node per leaf of the tree. Every leaf
+// node instances a unit cube mesh, translated and scaled to the leaf's region
+// so coordinates match what NewTreeRegionMap reports for the same offset and
+// scale. attrs and opts may be nil.
+func New(tree htree.Tree, attrs *attributors.NodeAttributer, opts *Options) *Document {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	offset := opts.Offset
+	if offset == nil {
+		offset = htree.Origin
+	}
+
+	doc := &Document{
+		Asset:  asset{Version: "2.0", Generator: "hambidgetree"},
+		Scenes: []scene{{Nodes: []int{0}}},
+		Nodes:  []node{{Name: "tree"}},
+	}
+
+	doc.addCube()
+
+	// Maps material names to their mesh
+	meshes := make(map[string]int)
+
+	it := htree.NewRegionIterator(tree, offset, opts.Scale)
+	for it.HasNext() {
+		nodeRegion := it.Next()
+		if nodeRegion.Node().Branch() != nil {
+			continue
+		}
+
+		id := nodeRegion.Node().ID()
+		dim := nodeRegion.Region().AlignedBox()
+
+		depth := dim.Depth()
+		if !dim.Is3D() {
+			depth = opts.Thickness
+		}
+
+		extras := &nodeExtras{NodeID: id}
+		materialName := ""
+		if attrs != nil {
+			extras.Attributes = attrs.Attributes(id)
+			if opts.MaterialKey != "" {
+				materialName = extras.Attributes[opts.MaterialKey]
+			}
+		}
+
+		meshIndex, ok := meshes[materialName]
+		if !ok {
+			meshIndex = doc.addMesh(materialName, opts)
+			meshes[materialName] = meshIndex
+		}
+
+		doc.Nodes[0].Children = append(doc.Nodes[0].Children, len(doc.Nodes))
+		doc.Nodes = append(doc.Nodes, node{
+			Name:        "node-" + strconv.FormatInt(int64(id), 10),
+			Mesh:        &meshIndex,
+			Translation: []float64{dim.Left(), dim.Top(), dim.Front()},
+			Scale:       []float64{dim.Width(), dim.Height(), depth},
+			Extras:      extras,
+		})
+	}
+
+	doc.Buffers = []buffer{{ByteLength: len(doc.bin)}}
+	return doc
+}
+
+// Adds a mesh using the cube geometry and a new material.
+func (doc *Document) addMesh(name string, opts *Options) int {
+	c := opts.DefaultColor
+	if name != "" {
+		if named, ok := opts.Colors[name]; ok {
+			c = named
+		}
+	}
+	if c == nil {
+		c = color.White
+	}
+
+	r, g, b, a := c.RGBA()
+	doc.Materials = append(doc.Materials, material{
+		Name: name,
+		PBRMetallicRoughness: pbrMetallicRoughness{
+			BaseColorFactor: [4]float64{
+				float64(r) / 0xffff, float64(g) / 0xffff,
+				float64(b) / 0xffff, float64(a) / 0xffff,
+			},
+		},
+	})
+
+	doc.Meshes = append(doc.Meshes, mesh{
+		Name: name,
+		Primitives: []primitive{{
+			Attributes: map[string]int{"POSITION": 0, "NORMAL": 1},
+			Indices:    2,
+			Material:   len(doc.Materials) - 1,
+		}},
+	})
+
+	return len(doc.Meshes) - 1
+}
+
+// Each face of the unit cube, a normal and 4 corners wound counter-clockwise
+// when viewed from outside.
+var cubeFaces = [6]struct {
+	normal  [3]float32
+	corners [4][3]float32
+}{
+	{[3]float32{-1, 0, 0}, [4][3]float32{{0, 0, 0}, {0, 0, 1}, {0, 1, 1}, {0, 1, 0}}},
+	{[3]float32{1, 0, 0}, [4][3]float32{{1, 0, 0}, {1, 1, 0}, {1, 1, 1}, {1, 0, 1}}},
+	{[3]float32{0, -1, 0}, [4][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 0, 1}, {0, 0, 1}}},
+	{[3]float32{0, 1, 0}, [4][3]float32{{0, 1, 0}, {0, 1, 1}, {1, 1, 1}, {1, 1, 0}}},
+	{[3]float32{0, 0, -1}, [4][3]float32{{0, 0, 0}, {0, 1, 0}, {1, 1, 0}, {1, 0, 0}}},
+	{[3]float32{0, 0, 1}, [4][3]float32{{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1}}},
+}
+
+// Writes the unit cube geometry into the binary buffer, accessor 0 holds the
+// positions, 1 the normals and 2 the indices.
+func (doc *Document) addCube() {
+	var positions, normals bytes.Buffer
+	var indices bytes.Buffer
+
+	for i, face := range cubeFaces {
+		for _, corner := range face.corners {
+			binary.Write(&positions, binary.LittleEndian, corner)
+			binary.Write(&normals, binary.LittleEndian, face.normal)
+		}
+		base := uint16(i * 4)
+		binary.Write(&indices, binary.LittleEndian, []uint16{base, base + 1, base + 2, base, base + 2, base + 3})
+	}
+
+	doc.addBufferView(positions.Bytes(), targetArrayBuffer)
+	doc.addBufferView(normals.Bytes(), targetArrayBuffer)
+	doc.addBufferView(indices.Bytes(), targetElementArrayBuffer)
+
+	doc.Accessors = []accessor{
+		{BufferView: 0, ComponentType: componentTypeFloat, Count: 24, Type: "VEC3", Min: []float64{0, 0, 0}, Max: []float64{1, 1, 1}},
+		{BufferView: 1, ComponentType: componentTypeFloat, Count: 24, Type: "VEC3"},
+		{BufferView: 2, ComponentType: componentTypeUnsignedShort, Count: 36, Type: "SCALAR"},
+	}
+}
+
+// Appends data to the binary buffer keeping every view 4 byte aligned.
+func (doc *Document) addBufferView(data []byte, target int) {
+	doc.BufferViews = append(doc.BufferViews, bufferView{
+		Buffer:     0,
+		ByteOffset: len(doc.bin),
+		ByteLength: len(data),
+		Target:     target,
+	})
+	doc.bin = append(doc.bin, data...)
+	for len(doc.bin)%4 != 0 {
+		doc.bin = append(doc.bin, 0)
+	}
+}
+
+// Writes the document as a .gltf JSON file with the buffer embedded as a
+// data uri.
+func (doc *Document) WriteGLTF(w io.Writer) error {
+	embedded := *doc
+	embedded.Buffers = []buffer{{
+		ByteLength: len(doc.bin),
+		URI:        "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(doc.bin),
+	}}
+
+	data, err := json.Marshal(&embedded)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// Writes the document as a binary .glb file.
+func (doc *Document) WriteGLB(w io.Writer) error {
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	for len(jsonData)%4 != 0 {
+		jsonData = append(jsonData, ' ')
+	}
+
+	length := 12 + 8 + len(jsonData) + 8 + len(doc.bin)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []uint32{glbMagic, glbVersion, uint32(length)})
+	binary.Write(&buf, binary.LittleEndian, []uint32{uint32(len(jsonData)), glbChunkJSON})
+	buf.Write(jsonData)
+	binary.Write(&buf, binary.LittleEndian, []uint32{uint32(len(doc.bin)), glbChunkBIN})
+	buf.Write(doc.bin)
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// Creates the document for the tree and writes it as a .gltf file.
+func WriteGLTF(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, opts *Options) error {
+	return New(tree, attrs, opts).WriteGLTF(w)
+}
+
+// Creates the document for the tree and writes it as a .glb file.
+func WriteGLB(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, opts *Options) error {
+	return New(tree, attrs, opts).WriteGLB(w)
+}
@@ -0,0 +1,146 @@
+package gltf_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/generators/grid"
+	"github.com/scisci/hambidgetree/gltf"
+	"image/color"
+	"testing"
+)
+
+type testDoc struct {
+	Nodes []struct {
+		Name        string    `json:"name"`
+		Children    []int     `json:"children"`
+		Mesh        *int      `json:"mesh"`
+		Translation []float64 `json:"translation"`
+		Scale       []float64 `json:"scale"`
+		Extras      *struct {
+			NodeID     htree.NodeID      `json:"nodeID"`
+			Attributes map[string]string `json:"attributes"`
+		} `json:"extras"`
+	} `json:"nodes"`
+	Materials []struct {
+		Name string `json:"name"`
+	} `json:"materials"`
+	Buffers []struct {
+		ByteLength int    `json:"byteLength"`
+		URI        string `json:"uri"`
+	} `json:"buffers"`
+}
+
+func TestGLTF(t *testing.T) {
+	tree := grid.New3D(3)
+	leaves := algo.FindLeaves(tree)
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, 2)
+
+	attrs := attributors.NewNodeAttributer()
+	attrs.SetAttribute(leaves[0].ID(), "material", "red")
+	attrs.SetAttribute(leaves[0].ID(), "onPath", "true")
+
+	opts := gltf.DefaultOptions()
+	opts.Scale = 2
+	opts.MaterialKey = "material"
+	opts.Colors = map[string]color.Color{"red": color.RGBA{255, 0, 0, 255}}
+
+	buf := bytes.NewBuffer(nil)
+	if err := gltf.WriteGLTF(buf, tree, attrs, opts); err != nil {
+		t.Fatalf("Failed to write gltf %v", err)
+	}
+
+	var doc testDoc
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to parse gltf %v", err)
+	}
+
+	if len(doc.Nodes) != len(leaves)+1 || len(doc.Nodes[0].Children) != len(leaves) {
+		t.Errorf("Expected a root with %d leaf nodes", len(leaves))
+	}
+
+	if len(doc.Materials) != 2 {
+		t.Errorf("Expected a default and a red material, got %d", len(doc.Materials))
+	}
+
+	if len(doc.Buffers) != 1 || doc.Buffers[0].URI == "" {
+		t.Errorf("Expected an embedded buffer")
+	}
+
+	for _, n := range doc.Nodes[1:] {
+		if n.Extras == nil {
+			t.Fatalf("Leaf node %s is missing extras", n.Name)
+		}
+
+		dim := regionMap[n.Extras.NodeID].AlignedBox()
+		if n.Translation[0] != dim.Left() || n.Translation[1] != dim.Top() || n.Translation[2] != dim.Front() {
+			t.Errorf("Node %d translation %v doesn't match region %v", n.Extras.NodeID, n.Translation, dim)
+		}
+		if n.Scale[0] != dim.Width() || n.Scale[1] != dim.Height() || n.Scale[2] != dim.Depth() {
+			t.Errorf("Node %d scale %v doesn't match region %v", n.Extras.NodeID, n.Scale, dim)
+		}
+
+		if n.Extras.NodeID == leaves[0].ID() {
+			if n.Extras.Attributes["onPath"] != "true" || n.Extras.Attributes["material"] != "red" {
+				t.Errorf("Expected attributes in extras, got %v", n.Extras.Attributes)
+			}
+		}
+	}
+}
+
+func TestGLTF2D(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	if err := gltf.WriteGLTF(buf, grid.New2D(2), nil, nil); err != nil {
+		t.Fatalf("Failed to write gltf %v", err)
+	}
+
+	var doc testDoc
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to parse gltf %v", err)
+	}
+
+	for _, n := range doc.Nodes[1:] {
+		if n.Scale[2] <= 0 {
+			t.Errorf("Expected 2D node %s to have depth, got scale %v", n.Name, n.Scale)
+		}
+	}
+}
+
+func TestGLB(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	if err := gltf.WriteGLB(buf, grid.New3D(3), nil, nil); err != nil {
+		t.Fatalf("Failed to write glb %v", err)
+	}
+
+	data := buf.Bytes()
+	var header [5]uint32
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &header); err != nil {
+		t.Fatalf("Failed to read header %v", err)
+	}
+
+	if header[0] != 0x46546C67 || header[1] != 2 || int(header[2]) != len(data) {
+		t.Errorf("Invalid glb header %v for length %d", header[:3], len(data))
+	}
+
+	jsonLength := int(header[3])
+	if jsonLength%4 != 0 {
+		t.Errorf("JSON chunk should be 4 byte aligned, got %d", jsonLength)
+	}
+
+	var doc testDoc
+	if err := json.Unmarshal(data[20:20+jsonLength], &doc); err != nil {
+		t.Fatalf("Failed to parse json chunk %v", err)
+	}
+
+	if doc.Buffers[0].URI != "" {
+		t.Errorf("Binary buffer should not have a uri")
+	}
+
+	binLength := binary.LittleEndian.Uint32(data[20+jsonLength:])
+	if int(binLength) != doc.Buffers[0].ByteLength {
+		t.Errorf("Bin chunk length %d doesn't match buffer %d", binLength, doc.Buffers[0].ByteLength)
+	}
+}
@@ -208,3 +208,13 @@ func RatioSource() htree.RatioSource {
 	}
 	return ratioSource
 }
+
+// Same as RatioSource but the ratios are evaluated exactly, so complements and
+// splits are found without relying on an epsilon.
+func ExactRatioSource() htree.ExactRatioSource {
+	ratioSource, err := htree.NewExactRatioSource(Exprs)
+	if err != nil {
+		panic(err)
+	}
+	return ratioSource
+}
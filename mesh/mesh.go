@@ -0,0 +1,150 @@
+package mesh
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+)
+
+// The default depth of the leaves of 2D trees, a hundredth of the height of
+// the container so they aren't flat.
+const defaultThickness = 0.01
+
+// Options used when converting the leaves of a tree into boxes.
+type Options struct {
+	Scale     float64 // Height of the container in output units
+	Gap       float64 // Space left between neighboring boxes in output units
+	Thickness float64 // Depth given to the leaves of 2D trees in output units
+}
+
+// Returns the options used when none are provided.
+func DefaultOptions() *Options {
+	return &Options{
+		Scale:     htree.UnityScale,
+		Gap:       0,
+		Thickness: defaultThickness,
+	}
+}
+
+type Vertex struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+func (v Vertex) Sub(other Vertex) Vertex {
+	return Vertex{v.X - other.X, v.Y - other.Y, v.Z - other.Z}
+}
+
+func (v Vertex) Cross(other Vertex) Vertex {
+	return Vertex{
+		v.Y*other.Z - v.Z*other.Y,
+		v.Z*other.X - v.X*other.Z,
+		v.X*other.Y - v.Y*other.X,
+	}
+}
+
+// A closed box mesh representing a single leaf.
+type Box struct {
+	ID  htree.NodeID
+	Min Vertex
+	Max Vertex
+}
+
+// Each face of a box as a quad of vertex indexes, wound counter-clockwise
+// when viewed from outside so that normals point outward.
+var boxQuads = [6][4]int{
+	{0, 4, 6, 2}, // -X
+	{1, 3, 7, 5}, // +X
+	{0, 1, 5, 4}, // -Y
+	{2, 6, 7, 3}, // +Y
+	{0, 2, 3, 1}, // -Z
+	{4, 5, 7, 6}, // +Z
+}
+
+// The outward normal of each face in boxQuads.
+var boxNormals = [6]Vertex{
+	{-1, 0, 0},
+	{1, 0, 0},
+	{0, -1, 0},
+	{0, 1, 0},
+	{0, 0, -1},
+	{0, 0, 1},
+}
+
+// Returns the 8 corners of the box, bit 0 of the index selects max x, bit 1
+// max y and bit 2 max z.
+func (box Box) Vertices() [8]Vertex {
+	var vertices [8]Vertex
+	for i := range vertices {
+		v := box.Min
+		if i&1 != 0 {
+			v.X = box.Max.X
+		}
+		if i&2 != 0 {
+			v.Y = box.Max.Y
+		}
+		if i&4 != 0 {
+			v.Z = box.Max.Z
+		}
+		vertices[i] = v
+	}
+	return vertices
+}
+
+// Returns the 12 triangles of the box along with their normals.
+func (box Box) Triangles() ([12][3]Vertex, [12]Vertex) {
+	var triangles [12][3]Vertex
+	var normals [12]Vertex
+
+	vertices := box.Vertices()
+	for i, quad := range boxQuads {
+		a, b, c, d := vertices[quad[0]], vertices[quad[1]], vertices[quad[2]], vertices[quad[3]]
+		triangles[i*2] = [3]Vertex{a, b, c}
+		triangles[i*2+1] = [3]Vertex{a, c, d}
+		normals[i*2] = boxNormals[i]
+		normals[i*2+1] = boxNormals[i]
+	}
+
+	return triangles, normals
+}
+
+// Creates a box for each leaf of the tree in tree iteration order. Each box
+// is shrunk by half the gap on every side so neighbors end up gap apart. opts
+// may be nil.
+func NewBoxes(tree htree.Tree, opts *Options) []Box {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, opts.Scale)
+	return NewRegionMapBoxes(algo.FindLeaves(tree), regionMap, opts)
+}
+
+// Creates a box for each of the given leaves using the regions in regionMap.
+func NewRegionMapBoxes(leaves []htree.Node, regionMap htree.RegionMap, opts *Options) []Box {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	inset := opts.Gap / 2
+
+	boxes := make([]Box, 0, len(leaves))
+	for _, leaf := range leaves {
+		dim := regionMap[leaf.ID()].AlignedBox()
+
+		box := Box{
+			ID:  leaf.ID(),
+			Min: Vertex{dim.Left() + inset, dim.Top() + inset, dim.Front() + inset},
+			Max: Vertex{dim.Right() - inset, dim.Bottom() - inset, dim.Back() - inset},
+		}
+
+		if !dim.Is3D() {
+			box.Min.Z = 0
+			box.Max.Z = opts.Thickness
+		}
+
+		boxes = append(boxes, box)
+	}
+
+	return boxes
+}
@@ -0,0 +1,115 @@
+package mesh_test
+
+import (
+	"bytes"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/generators/grid"
+	"github.com/scisci/hambidgetree/mesh"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBoxWinding(t *testing.T) {
+	box := mesh.Box{Min: mesh.Vertex{0, 0, 0}, Max: mesh.Vertex{1, 2, 3}}
+
+	// Using the divergence theorem, outward facing triangles sum to the volume
+	volume := 0.0
+	triangles, normals := box.Triangles()
+	for i, tri := range triangles {
+		cross := tri[1].Sub(tri[0]).Cross(tri[2].Sub(tri[0]))
+		if cross.X*normals[i].X+cross.Y*normals[i].Y+cross.Z*normals[i].Z <= 0 {
+			t.Errorf("Triangle %d winding doesn't match its normal", i)
+		}
+		a, b, c := tri[0], tri[1], tri[2]
+		volume += (a.X*(b.Y*c.Z-b.Z*c.Y) - a.Y*(b.X*c.Z-b.Z*c.X) + a.Z*(b.X*c.Y-b.Y*c.X)) / 6
+	}
+
+	if math.Abs(volume-6) > 0.0000001 {
+		t.Errorf("Expected volume 6, got %f", volume)
+	}
+}
+
+func TestBoxes(t *testing.T) {
+	tree := grid.New3D(3)
+
+	opts := mesh.DefaultOptions()
+	opts.Scale = 10
+	opts.Gap = 1
+	boxes := mesh.NewBoxes(tree, opts)
+	if len(boxes) != 8 {
+		t.Fatalf("Expected 8 boxes, got %d", len(boxes))
+	}
+
+	for _, box := range boxes {
+		size := box.Max.Sub(box.Min)
+		if size.X != 4 || size.Y != 4 || size.Z != 4 {
+			t.Errorf("Expected box of size 4 after gap, got %v", size)
+		}
+	}
+
+	// 2D trees have depth by default
+	for _, box := range mesh.NewBoxes(grid.New2D(2), nil) {
+		if box.Max.Z-box.Min.Z <= 0 {
+			t.Errorf("Expected 2D box to have depth, got %f", box.Max.Z-box.Min.Z)
+		}
+	}
+
+	// 2D trees are extruded by the thickness
+	opts = mesh.DefaultOptions()
+	opts.Thickness = 0.1
+	for _, box := range mesh.NewBoxes(grid.New2D(2), opts) {
+		if box.Max.Z-box.Min.Z != 0.1 {
+			t.Errorf("Expected 2D box to have thickness 0.1, got %f", box.Max.Z-box.Min.Z)
+		}
+	}
+}
+
+func TestWriteOBJ(t *testing.T) {
+	boxes := mesh.NewBoxes(grid.New3D(3), nil)
+
+	attrs := attributors.NewNodeAttributer()
+	attrs.SetAttribute(boxes[0].ID, "material", "red paint")
+
+	buf := bytes.NewBuffer(nil)
+	err := mesh.WriteOBJ(buf, boxes, attrs, &mesh.OBJOptions{MaterialLib: "tree.mtl", MaterialKey: "material"})
+	if err != nil {
+		t.Fatalf("Failed to write obj %v", err)
+	}
+
+	obj := buf.String()
+	counts := map[string]int{"o ": 8, "g ": 8, "v ": 64, "f ": 48, "usemtl red_paint": 1, "mtllib tree.mtl": 1}
+	for prefix, expected := range counts {
+		n := 0
+		for _, line := range strings.Split(obj, "\n") {
+			if strings.HasPrefix(line, prefix) {
+				n++
+			}
+		}
+		if n != expected {
+			t.Errorf("Expected %d lines starting with %q, got %d", expected, prefix, n)
+		}
+	}
+}
+
+func TestWriteSTL(t *testing.T) {
+	boxes := mesh.NewBoxes(grid.New3D(3), nil)
+
+	buf := bytes.NewBuffer(nil)
+	if err := mesh.WriteSTLBinary(buf, boxes); err != nil {
+		t.Fatalf("Failed to write stl %v", err)
+	}
+
+	if buf.Len() != 84+50*12*len(boxes) {
+		t.Errorf("Unexpected binary stl size %d", buf.Len())
+	}
+
+	buf.Reset()
+	if err := mesh.WriteSTLASCII(buf, "tree", boxes); err != nil {
+		t.Fatalf("Failed to write stl %v", err)
+	}
+
+	if n := strings.Count(buf.String(), "facet normal"); n != 12*len(boxes) {
+		t.Errorf("Expected %d facets, got %d", 12*len(boxes), n)
+	}
+}
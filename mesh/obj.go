@@ -0,0 +1,89 @@
+package mesh
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/scisci/hambidgetree/attributors"
+	"image/color"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options used when writing Wavefront OBJ files.
+type OBJOptions struct {
+	MaterialLib string // Name of the mtl file referenced by the obj, optional
+	MaterialKey string // Attribute whose value names each box's material
+}
+
+// Writes the boxes as a Wavefront OBJ. Each box becomes its own object and
+// group named after its node id. When MaterialKey is set, boxes with that
+// attribute use the material named by its value. attrs and opts may be nil.
+func WriteOBJ(w io.Writer, boxes []Box, attrs *attributors.NodeAttributer, opts *OBJOptions) error {
+	if opts == nil {
+		opts = &OBJOptions{}
+	}
+
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "# hambidgetree %d boxes\n", len(boxes))
+
+	if opts.MaterialLib != "" {
+		fmt.Fprintf(buf, "mtllib %s\n", opts.MaterialLib)
+	}
+
+	for i, box := range boxes {
+		name := "node-" + strconv.FormatInt(int64(box.ID), 10)
+		fmt.Fprintf(buf, "o %s\ng %s\n", name, name)
+
+		if attrs != nil && opts.MaterialKey != "" {
+			if material, err := attrs.Attribute(box.ID, opts.MaterialKey); err == nil {
+				fmt.Fprintf(buf, "usemtl %s\n", materialName(material))
+			}
+		}
+
+		for _, v := range box.Vertices() {
+			fmt.Fprintf(buf, "v %s %s %s\n", formatFloat(v.X), formatFloat(v.Y), formatFloat(v.Z))
+		}
+
+		// Indexes are 1 based and global to the file
+		base := i*8 + 1
+		for _, quad := range boxQuads {
+			fmt.Fprintf(buf, "f %d %d %d %d\n", base+quad[0], base+quad[1], base+quad[2], base+quad[3])
+		}
+	}
+
+	return buf.Flush()
+}
+
+// Writes a material library defining a diffuse color for each material name.
+func WriteMTL(w io.Writer, materials map[string]color.Color) error {
+	names := make([]string, 0, len(materials))
+	for name := range materials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := bufio.NewWriter(w)
+	for _, name := range names {
+		r, g, b, a := materials[name].RGBA()
+		fmt.Fprintf(buf, "newmtl %s\n", materialName(name))
+		fmt.Fprintf(buf, "Kd %s %s %s\n", formatChannel(r), formatChannel(g), formatChannel(b))
+		fmt.Fprintf(buf, "d %s\n\n", formatChannel(a))
+	}
+
+	return buf.Flush()
+}
+
+// Material names can't contain whitespace.
+func materialName(name string) string {
+	return strings.Join(strings.Fields(name), "_")
+}
+
+func formatChannel(value uint32) string {
+	return strconv.FormatFloat(float64(value)/0xffff, 'f', 4, 64)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
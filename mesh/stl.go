@@ -0,0 +1,67 @@
+package mesh
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Writes the boxes as a binary STL.
+func WriteSTLBinary(w io.Writer, boxes []Box) error {
+	buf := bufio.NewWriter(w)
+
+	header := make([]byte, 80)
+	copy(header, "hambidgetree")
+	buf.Write(header)
+
+	if err := binary.Write(buf, binary.LittleEndian, uint32(len(boxes)*12)); err != nil {
+		return err
+	}
+
+	record := make([]byte, 50)
+	for _, box := range boxes {
+		triangles, normals := box.Triangles()
+		for i, triangle := range triangles {
+			putVertex(record[0:], normals[i])
+			putVertex(record[12:], triangle[0])
+			putVertex(record[24:], triangle[1])
+			putVertex(record[36:], triangle[2])
+			binary.LittleEndian.PutUint16(record[48:], 0)
+			if _, err := buf.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return buf.Flush()
+}
+
+// Writes the boxes as an ASCII STL solid with the given name.
+func WriteSTLASCII(w io.Writer, name string, boxes []Box) error {
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "solid %s\n", name)
+
+	for _, box := range boxes {
+		triangles, normals := box.Triangles()
+		for i, triangle := range triangles {
+			n := normals[i]
+			fmt.Fprintf(buf, "facet normal %s %s %s\n", formatFloat(n.X), formatFloat(n.Y), formatFloat(n.Z))
+			fmt.Fprintf(buf, "outer loop\n")
+			for _, v := range triangle {
+				fmt.Fprintf(buf, "vertex %s %s %s\n", formatFloat(v.X), formatFloat(v.Y), formatFloat(v.Z))
+			}
+			fmt.Fprintf(buf, "endloop\nendfacet\n")
+		}
+	}
+
+	fmt.Fprintf(buf, "endsolid %s\n", name)
+	return buf.Flush()
+}
+
+func putVertex(b []byte, v Vertex) {
+	binary.LittleEndian.PutUint32(b[0:], math.Float32bits(float32(v.X)))
+	binary.LittleEndian.PutUint32(b[4:], math.Float32bits(float32(v.Y)))
+	binary.LittleEndian.PutUint32(b[8:], math.Float32bits(float32(v.Z)))
+}
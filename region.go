@@ -47,17 +47,14 @@ type NodeRegion interface {
 func SplitRegionHorizontal(ratios Ratios, region *Region, leftIndex, rightIndex int) (left, right *Region) {
 	epsilon := 0.0000001
 
-	dimension := region.AlignedBox()
 	ratioIndexXY := region.RatioIndexXY()
 	ratioIndexZY := region.RatioIndexZY()
 
-	leftRatio := ratios[leftIndex]
-	leftHeightParam := RatioNormalHeight(ratios[ratioIndexXY], leftRatio)
-
 	leftRatioIndexZY := ratioIndexZY
 	rightRatioIndexZY := ratioIndexZY
 
 	if IsRatioIndexDefined(ratioIndexZY) {
+		leftHeightParam := RatioNormalHeight(ratios[ratioIndexXY], ratios[leftIndex])
 		zyRatio := ratios[ratioIndexZY]
 		leftRatio := zyRatio / leftHeightParam
 		rightRatio := zyRatio / (1 - leftHeightParam)
@@ -70,6 +67,14 @@ func SplitRegionHorizontal(ratios Ratios, region *Region, leftIndex, rightIndex
 		}
 	}
 
+	return splitRegionHorizontal(ratios, region, leftIndex, rightIndex, leftRatioIndexZY, rightRatioIndexZY)
+}
+
+// Splits the region once the zy ratios of the children are known.
+func splitRegionHorizontal(ratios Ratios, region *Region, leftIndex, rightIndex, leftRatioIndexZY, rightRatioIndexZY int) (left, right *Region) {
+	dimension := region.AlignedBox()
+	leftHeightParam := RatioNormalHeight(ratios[region.RatioIndexXY()], ratios[leftIndex])
+
 	// Find the right ratio index
 	right = NewRegion(
 		dimension.Inset(AxisY, dimension.Height()*leftHeightParam),
@@ -133,6 +138,34 @@ func SplitRegionDepth(ratios Ratios, region *Region, leftIndex, rightIndex int)
 	return
 }
 
+// Splits the region using the split type, if the ratio source is an
+// ExactRatioSource the ratios of the children are found exactly.
+func SplitRegion(ratioSource RatioSource, region *Region, splitType SplitType, leftIndex, rightIndex int) (left, right *Region) {
+	ratios := ratioSource.Ratios()
+
+	switch splitType {
+	case SplitTypeHorizontal:
+		if exactSource, ok := ratioSource.(ExactRatioSource); ok {
+			return SplitRegionHorizontalExact(ratios, exactSource.ExactRatios(), region, leftIndex, rightIndex)
+		}
+		return SplitRegionHorizontal(ratios, region, leftIndex, rightIndex)
+	case SplitTypeVertical:
+		return SplitRegionVertical(ratios, region, leftIndex, rightIndex)
+	case SplitTypeDepth:
+		return SplitRegionDepth(ratios, region, leftIndex, rightIndex)
+	}
+
+	panic("Unknown split type")
+}
+
+// Returns the ratio indexes of the children created by applying the split to a
+// region with the given ratio indexes.
+func SplitRatioIndexes(ratioSource RatioSource, ratioIndexXY, ratioIndexZY int, split Split) (leftXY, leftZY, rightXY, rightZY int) {
+	region := NewRegion(NewAlignedBox3D(0, 0, 0, 1, 1, 1), ratioIndexXY, ratioIndexZY)
+	left, right := SplitRegion(ratioSource, region, split.Type(), split.LeftIndex(), split.RightIndex())
+	return left.RatioIndexXY(), left.RatioIndexZY(), right.RatioIndexXY(), right.RatioIndexZY()
+}
+
 type RegionIterator struct {
 	tree    Tree
 	regions []*nodeRatioRegion
@@ -196,33 +229,16 @@ func (it *RegionIterator) Next() NodeRegion {
 	it.regions = it.regions[:len(it.regions)-1]
 
 	branch := node.node.Branch()
-	ratios := it.tree.RatioSource().Ratios()
 
 	if branch != nil {
 		left := branch.Left()
 		right := branch.Right()
-		splitType := branch.SplitType()
-
-		var leftRegion, rightRegion *Region
-		if splitType == SplitTypeHorizontal {
-			leftRegion, rightRegion = SplitRegionHorizontal(ratios,
-				node.Region(),
-				branch.LeftIndex(),
-				branch.RightIndex())
-		} else if splitType == SplitTypeVertical {
-			// When we split vertically
-			leftRegion, rightRegion = SplitRegionVertical(ratios,
-				node.Region(),
-				branch.LeftIndex(),
-				branch.RightIndex())
-		} else if splitType == SplitTypeDepth {
-			leftRegion, rightRegion = SplitRegionDepth(ratios,
-				node.Region(),
-				branch.LeftIndex(),
-				branch.RightIndex())
-		} else {
-			panic("Unknown split type")
-		}
+
+		leftRegion, rightRegion := SplitRegion(it.tree.RatioSource(),
+			node.Region(),
+			branch.SplitType(),
+			branch.LeftIndex(),
+			branch.RightIndex())
 
 		it.regions = append(it.regions, &nodeRatioRegion{right, rightRegion})
 		it.regions = append(it.regions, &nodeRatioRegion{left, leftRegion})
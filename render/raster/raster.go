@@ -0,0 +1,166 @@
+package raster
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/render"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Chooses the fill color of a leaf. attrs is never nil, when no attributes
+// were provided it is empty.
+type FillFunc func(id htree.NodeID, attrs attributors.NodeAttributes) color.Color
+
+// Options controlling how a tree is rasterized.
+type Options struct {
+	Width      int               // Width of the image in pixels
+	Height     int               // Height of the image in pixels
+	Margin     float64           // Space left around the layout in pixels
+	Projection render.Projection // Face to draw for 3D trees
+	Background color.Color       // Color behind the leaves
+	Fill       FillFunc          // Color of each leaf, nil uses DefaultFill
+	Gutter     float64           // Space between neighboring leaves in pixels
+	LineWidth  float64           // Width of each leaf outline in pixels
+	LineColor  color.Color       // Color of each leaf outline
+}
+
+// The fill used when Options.Fill is nil.
+var DefaultFill FillFunc = func(id htree.NodeID, attrs attributors.NodeAttributes) color.Color {
+	return color.White
+}
+
+// Returns the options used when none are provided.
+func DefaultOptions() *Options {
+	return &Options{
+		Width:      256,
+		Height:     256,
+		Projection: render.ProjectionFront,
+		Background: color.White,
+		Fill:       DefaultFill,
+		Gutter:     0,
+		LineWidth:  1,
+		LineColor:  color.Black,
+	}
+}
+
+// Draws the leaves of the tree into a new image. attrs and opts may be nil.
+func Render(tree htree.Tree, attrs *attributors.NodeAttributer, opts *Options) *image.RGBA {
+	return RenderRegionMap(tree, htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale), attrs, opts)
+}
+
+// Draws the leaves of a region map created from tree into a new image. The
+// layout is scaled to fit the image regardless of the offset and scale the
+// region map was created with.
+func RenderRegionMap(tree htree.Tree, regionMap htree.RegionMap, attrs *attributors.NodeAttributer, opts *Options) *image.RGBA {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	if attrs == nil {
+		attrs = attributors.NewNodeAttributer()
+	}
+
+	fill := opts.Fill
+	if fill == nil {
+		fill = DefaultFill
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	if opts.Background != nil {
+		fillRect(img, render.Rect{Right: float64(opts.Width), Bottom: float64(opts.Height)}, opts.Background)
+	}
+
+	// 2D trees collapse to lines in the other projections
+	projection := render.ProjectionFor(tree, opts.Projection)
+	bounds := render.Bounds(tree, regionMap, projection)
+	transform := render.NewFitTransform(bounds, float64(opts.Width), float64(opts.Height), opts.Margin)
+
+	for _, leaf := range render.VisibleLeaves(tree, regionMap, projection) {
+		rect := inset(transform.Apply(leaf.Rect), opts.Gutter/2)
+
+		if opts.LineWidth > 0 && opts.LineColor != nil {
+			fillRect(img, rect, opts.LineColor)
+			rect = inset(rect, opts.LineWidth)
+		}
+
+		fillRect(img, rect, fill(leaf.Node.ID(), attrs))
+	}
+
+	return img
+}
+
+// Renders the tree and encodes it to w as a PNG.
+func WritePNG(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, opts *Options) error {
+	return png.Encode(w, Render(tree, attrs, opts))
+}
+
+func inset(r render.Rect, distance float64) render.Rect {
+	r.Left += distance
+	r.Top += distance
+	r.Right -= distance
+	r.Bottom -= distance
+	return r
+}
+
+// Fills the rectangle, anti-aliasing the edges by blending each pixel with
+// the fraction of it that is covered.
+func fillRect(img *image.RGBA, r render.Rect, c color.Color) {
+	if r.Right <= r.Left || r.Bottom <= r.Top {
+		return
+	}
+
+	bounds := img.Bounds()
+	x0 := maxInt(int(math.Floor(r.Left)), bounds.Min.X)
+	y0 := maxInt(int(math.Floor(r.Top)), bounds.Min.Y)
+	x1 := minInt(int(math.Ceil(r.Right)), bounds.Max.X)
+	y1 := minInt(int(math.Ceil(r.Bottom)), bounds.Max.Y)
+
+	sr, sg, sb, sa := c.RGBA()
+
+	for y := y0; y < y1; y++ {
+		coverY := coverage(float64(y), r.Top, r.Bottom)
+		for x := x0; x < x1; x++ {
+			cover := coverY * coverage(float64(x), r.Left, r.Right)
+			if cover <= 0 {
+				continue
+			}
+
+			i := img.PixOffset(x, y)
+			pix := img.Pix[i : i+4 : i+4]
+			inv := 1 - float64(sa)/0xffff*cover
+			pix[0] = blend(sr, pix[0], cover, inv)
+			pix[1] = blend(sg, pix[1], cover, inv)
+			pix[2] = blend(sb, pix[2], cover, inv)
+			pix[3] = blend(sa, pix[3], cover, inv)
+		}
+	}
+}
+
+// Returns how much of the pixel starting at p is covered by the span.
+func coverage(p, start, end float64) float64 {
+	return math.Max(0, math.Min(p+1, end)-math.Max(p, start))
+}
+
+// Blends a premultiplied 16 bit source channel over an 8 bit destination.
+func blend(src uint32, dst uint8, cover, inv float64) uint8 {
+	v := float64(src)/0x101*cover + float64(dst)*inv
+	return uint8(math.Min(255, math.Floor(v+0.5)))
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,87 @@
+package raster_test
+
+import (
+	"bytes"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/generators/grid"
+	"github.com/scisci/hambidgetree/render/raster"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+var red = color.RGBA{255, 0, 0, 255}
+
+func TestRender(t *testing.T) {
+	tree := grid.New2D(2)
+	leaves := algo.FindLeaves(tree)
+
+	attrs := attributors.NewNodeAttributer()
+	attrs.SetAttribute(leaves[0].ID(), "color", "red")
+
+	opts := raster.DefaultOptions()
+	opts.Width = 100
+	opts.Height = 100
+	opts.LineWidth = 0
+	opts.Fill = func(id htree.NodeID, attrs attributors.NodeAttributes) color.Color {
+		if value, err := attrs.Attribute(id, "color"); err == nil && value == "red" {
+			return red
+		}
+		return color.Black
+	}
+
+	img := raster.Render(tree, attrs, opts)
+
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	for _, leaf := range leaves {
+		dim := regionMap[leaf.ID()].AlignedBox()
+		x := int((dim.Left() + dim.Width()/2) * 100)
+		y := int((dim.Top() + dim.Height()/2) * 100)
+
+		expected := color.RGBA{0, 0, 0, 255}
+		if leaf.ID() == leaves[0].ID() {
+			expected = red
+		}
+
+		if got := img.RGBAAt(x, y); got != expected {
+			t.Errorf("Leaf %d expected %v, got %v", leaf.ID(), expected, got)
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := png.Encode(buf, img); err != nil {
+		t.Errorf("Failed to encode png %v", err)
+	}
+}
+
+func TestRenderAntiAliased(t *testing.T) {
+	tree := grid.New2D(1) // Two halves side by side
+
+	opts := raster.DefaultOptions()
+	opts.Width = 6
+	opts.Height = 6
+	opts.LineWidth = 0
+	opts.Gutter = 1
+	opts.Background = color.White
+	opts.Fill = func(id htree.NodeID, attrs attributors.NodeAttributes) color.Color {
+		return color.Black
+	}
+
+	// The left leaf spans 0.5 to 2.5 after removing the gutter
+	img := raster.Render(tree, nil, opts)
+	got := img.RGBAAt(2, 2)
+	if got.R < 100 || got.R > 155 {
+		t.Errorf("Expected center column to be blended gray, got %v", got)
+	}
+
+	if got := img.RGBAAt(1, 2); got.R != 0 {
+		t.Errorf("Expected fully covered pixel to be black, got %v", got)
+	}
+
+	// The outer edge is also half covered.
+	if got := img.RGBAAt(0, 2); got.R < 100 || got.R > 155 {
+		t.Errorf("Expected edge pixel to be blended gray, got %v", got)
+	}
+}
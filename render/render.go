@@ -0,0 +1,250 @@
+package render
+
+import (
+	htree "github.com/scisci/hambidgetree"
+	"math"
+)
+
+const epsilon = 0.0000001
+
+// Projection selects which face of a tree is drawn when rendering to a flat
+// surface. 2D trees have no depth so every projection but the front one
+// collapses them to lines, renderers draw them with ProjectionFront whatever
+// projection is asked for.
+type Projection int
+
+const (
+	ProjectionFront Projection = 0 // Looking down the Z axis, draws X and Y
+	ProjectionTop   Projection = 1 // Looking down the Y axis, draws X and Z
+	ProjectionSide  Projection = 2 // Looking down the X axis, draws Z and Y
+)
+
+func (projection Projection) String() string {
+	switch projection {
+	case ProjectionFront:
+		return "front"
+	case ProjectionTop:
+		return "top"
+	case ProjectionSide:
+		return "side"
+	}
+
+	return "unknown"
+}
+
+// Returns the projection with the given name, names match those returned by
+// String.
+func ProjectionForName(name string) (Projection, bool) {
+	switch name {
+	case "front":
+		return ProjectionFront, true
+	case "top":
+		return ProjectionTop, true
+	case "side":
+		return ProjectionSide, true
+	}
+
+	return ProjectionFront, false
+}
+
+// Returns the projection used to draw the tree, ProjectionFront for 2D trees.
+func ProjectionFor(tree htree.Tree, projection Projection) Projection {
+	if !htree.IsRatioIndexDefined(tree.RatioIndexZY()) {
+		return ProjectionFront
+	}
+	return projection
+}
+
+// A rectangle on the projected plane. Y grows downward just like the trees.
+type Rect struct {
+	Left   float64
+	Top    float64
+	Right  float64
+	Bottom float64
+}
+
+func (r Rect) Width() float64 {
+	return r.Right - r.Left
+}
+
+func (r Rect) Height() float64 {
+	return r.Bottom - r.Top
+}
+
+// Projects the box onto the plane of the given projection.
+func Project(box *htree.AlignedBox, projection Projection) Rect {
+	switch projection {
+	case ProjectionTop:
+		return Rect{box.Left(), box.Front(), box.Right(), box.Back()}
+	case ProjectionSide:
+		return Rect{box.Front(), box.Top(), box.Back(), box.Bottom()}
+	}
+
+	return Rect{box.Left(), box.Top(), box.Right(), box.Bottom()}
+}
+
+// Whether the box lies on the face of the container that is visible for the
+// given projection.
+func IsVisible(container, box *htree.AlignedBox, projection Projection) bool {
+	switch projection {
+	case ProjectionTop:
+		return math.Abs(box.Top()-container.Top()) < epsilon
+	case ProjectionSide:
+		return math.Abs(box.Left()-container.Left()) < epsilon
+	}
+
+	return math.Abs(box.Front()-container.Front()) < epsilon
+}
+
+// Maps rectangles from tree space into output space so that the bounds fit
+// within the output size, centered, preserving aspect ratio.
+type Transform struct {
+	Scale   float64
+	OffsetX float64
+	OffsetY float64
+}
+
+// Creates a transform that fits bounds into a width x height surface leaving
+// margin on all sides.
+func NewFitTransform(bounds Rect, width, height, margin float64) Transform {
+	availWidth := width - 2*margin
+	availHeight := height - 2*margin
+
+	// A side without extent, i.e. a line, doesn't limit the scale
+	scale := math.Inf(1)
+	if bounds.Width() > epsilon {
+		scale = availWidth / bounds.Width()
+	}
+	if bounds.Height() > epsilon {
+		scale = math.Min(scale, availHeight/bounds.Height())
+	}
+	if math.IsInf(scale, 0) || math.IsNaN(scale) || scale < 0 {
+		scale = 0
+	}
+
+	return Transform{
+		Scale:   scale,
+		OffsetX: margin + (availWidth-bounds.Width()*scale)/2 - bounds.Left*scale,
+		OffsetY: margin + (availHeight-bounds.Height()*scale)/2 - bounds.Top*scale,
+	}
+}
+
+func (t Transform) Apply(r Rect) Rect {
+	return Rect{
+		Left:   r.Left*t.Scale + t.OffsetX,
+		Top:    r.Top*t.Scale + t.OffsetY,
+		Right:  r.Right*t.Scale + t.OffsetX,
+		Bottom: r.Bottom*t.Scale + t.OffsetY,
+	}
+}
+
+// A node along with its projected rectangle.
+type NodeRect struct {
+	Node htree.Node
+	Rect Rect
+}
+
+// A straight line on the projected plane, created by the split of a branch.
+type SplitLine struct {
+	Node      htree.Node
+	SplitType htree.SplitType
+	X1, Y1    float64
+	X2, Y2    float64
+}
+
+// Returns the projected rectangle of the container, i.e. the root node.
+func Bounds(tree htree.Tree, regionMap htree.RegionMap, projection Projection) Rect {
+	return Project(regionMap[tree.Root().ID()].AlignedBox(), projection)
+}
+
+// Returns the leaves that are visible for the given projection in tree
+// iteration order.
+func VisibleLeaves(tree htree.Tree, regionMap htree.RegionMap, projection Projection) []NodeRect {
+	container := regionMap[tree.Root().ID()].AlignedBox()
+
+	var leaves []NodeRect
+	it := htree.NewNodeIterator(tree.Root())
+	for it.HasNext() {
+		node := it.Next()
+		if node.Branch() != nil {
+			continue
+		}
+
+		box := regionMap[node.ID()].AlignedBox()
+		if !IsVisible(container, box, projection) {
+			continue
+		}
+
+		leaves = append(leaves, NodeRect{Node: node, Rect: Project(box, projection)})
+	}
+
+	return leaves
+}
+
+// Returns the lines created by each visible branch split. Splits whose plane
+// is parallel to the projection plane can't be seen and are skipped.
+func VisibleSplitLines(tree htree.Tree, regionMap htree.RegionMap, projection Projection) []SplitLine {
+	container := regionMap[tree.Root().ID()].AlignedBox()
+
+	var lines []SplitLine
+	it := htree.NewNodeIterator(tree.Root())
+	for it.HasNext() {
+		node := it.Next()
+		branch := node.Branch()
+		if branch == nil {
+			continue
+		}
+
+		box := regionMap[node.ID()].AlignedBox()
+		if !IsVisible(container, box, projection) {
+			continue
+		}
+
+		rect := Project(box, projection)
+		left := Project(regionMap[branch.Left().ID()].AlignedBox(), projection)
+
+		line := SplitLine{Node: node, SplitType: branch.SplitType()}
+		switch splitAxis(branch.SplitType(), projection) {
+		case htree.AxisX:
+			line.X1, line.Y1, line.X2, line.Y2 = left.Right, rect.Top, left.Right, rect.Bottom
+		case htree.AxisY:
+			line.X1, line.Y1, line.X2, line.Y2 = rect.Left, left.Bottom, rect.Right, left.Bottom
+		default:
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// Returns the axis on the projected plane that a split divides, or 0 if the
+// split is parallel to the plane.
+func splitAxis(splitType htree.SplitType, projection Projection) htree.Axis {
+	switch projection {
+	case ProjectionTop:
+		switch splitType {
+		case htree.SplitTypeVertical:
+			return htree.AxisX
+		case htree.SplitTypeDepth:
+			return htree.AxisY
+		}
+	case ProjectionSide:
+		switch splitType {
+		case htree.SplitTypeDepth:
+			return htree.AxisX
+		case htree.SplitTypeHorizontal:
+			return htree.AxisY
+		}
+	default:
+		switch splitType {
+		case htree.SplitTypeVertical:
+			return htree.AxisX
+		case htree.SplitTypeHorizontal:
+			return htree.AxisY
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,174 @@
+package svg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/render"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Options controlling how a tree is drawn.
+type Options struct {
+	Width             float64           // Width of the document in pixels
+	Height            float64           // Height of the document in pixels
+	Margin            float64           // Space left around the layout
+	Projection        render.Projection // Face to draw for 3D trees
+	Background        string            // Background fill, empty for none
+	Fill              string            // Default leaf fill
+	Stroke            string            // Leaf outline color
+	StrokeWidth       float64           // Leaf outline width, 0 for none
+	Branches          bool              // Whether to draw branch split lines
+	BranchStroke      string            // Color of the split lines
+	BranchStrokeWidth float64           // Width of the split lines
+	IDPrefix          string            // Prefix of each element id
+	ClassKeys         []string          // Attributes turned into classes
+	FillKey           string            // Attribute holding the leaf fill
+}
+
+// Returns the options used when none are provided.
+func DefaultOptions() *Options {
+	return &Options{
+		Width:             800,
+		Height:            800,
+		Margin:            0,
+		Projection:        render.ProjectionFront,
+		Background:        "",
+		Fill:              "#ffffff",
+		Stroke:            "#000000",
+		StrokeWidth:       1,
+		Branches:          false,
+		BranchStroke:      "#000000",
+		BranchStrokeWidth: 1,
+		IDPrefix:          "node-",
+	}
+}
+
+// Writes a complete SVG document of the tree to w. Each visible leaf is drawn
+// as a rect whose id is derived from its node id and whose classes are derived
+// from the attributes named in ClassKeys. attrs and opts may be nil.
+func Render(w io.Writer, tree htree.Tree, attrs *attributors.NodeAttributer, opts *Options) error {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+	// 2D trees collapse to lines in the other projections
+	projection := render.ProjectionFor(tree, opts.Projection)
+	bounds := render.Bounds(tree, regionMap, projection)
+	transform := render.NewFitTransform(bounds, opts.Width, opts.Height, opts.Margin)
+
+	buf := bufio.NewWriter(w)
+
+	fmt.Fprintf(buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n",
+		formatFloat(opts.Width), formatFloat(opts.Height),
+		formatFloat(opts.Width), formatFloat(opts.Height))
+
+	if opts.Background != "" {
+		fmt.Fprintf(buf, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", escape(opts.Background))
+	}
+
+	fmt.Fprintf(buf, `<g class="leaves" fill="%s"`, escape(opts.Fill))
+	if opts.StrokeWidth > 0 {
+		fmt.Fprintf(buf, ` stroke="%s" stroke-width="%s"`, escape(opts.Stroke), formatFloat(opts.StrokeWidth))
+	}
+	fmt.Fprintf(buf, ">\n")
+
+	for _, leaf := range render.VisibleLeaves(tree, regionMap, projection) {
+		rect := transform.Apply(leaf.Rect)
+		id := leaf.Node.ID()
+
+		fmt.Fprintf(buf, `<rect id="%s" class="%s" x="%s" y="%s" width="%s" height="%s"`,
+			escape(opts.IDPrefix+strconv.FormatInt(int64(id), 10)),
+			escape(classes(id, attrs, opts.ClassKeys)),
+			formatFloat(rect.Left), formatFloat(rect.Top),
+			formatFloat(rect.Width()), formatFloat(rect.Height()))
+
+		if attrs != nil && opts.FillKey != "" {
+			if fill, err := attrs.Attribute(id, opts.FillKey); err == nil {
+				fmt.Fprintf(buf, ` fill="%s"`, escape(fill))
+			}
+		}
+
+		fmt.Fprintf(buf, "/>\n")
+	}
+
+	fmt.Fprintf(buf, "</g>\n")
+
+	if opts.Branches {
+		fmt.Fprintf(buf, `<g class="branches" stroke="%s" stroke-width="%s">`+"\n",
+			escape(opts.BranchStroke), formatFloat(opts.BranchStrokeWidth))
+
+		for _, line := range render.VisibleSplitLines(tree, regionMap, projection) {
+			r := transform.Apply(render.Rect{Left: line.X1, Top: line.Y1, Right: line.X2, Bottom: line.Y2})
+			fmt.Fprintf(buf, `<line id="%s" class="split-%s" x1="%s" y1="%s" x2="%s" y2="%s"/>`+"\n",
+				escape(opts.IDPrefix+strconv.FormatInt(int64(line.Node.ID()), 10)),
+				splitClass(line.SplitType),
+				formatFloat(r.Left), formatFloat(r.Top),
+				formatFloat(r.Right), formatFloat(r.Bottom))
+		}
+
+		fmt.Fprintf(buf, "</g>\n")
+	}
+
+	fmt.Fprintf(buf, "</svg>\n")
+
+	return buf.Flush()
+}
+
+// Builds the class list of a leaf, each attribute becomes "key-value".
+func classes(id htree.NodeID, attrs *attributors.NodeAttributer, keys []string) string {
+	list := []string{"leaf"}
+	if attrs == nil {
+		return list[0]
+	}
+
+	for _, key := range keys {
+		value, err := attrs.Attribute(id, key)
+		if err != nil {
+			continue
+		}
+		list = append(list, sanitizeClass(key+"-"+value))
+	}
+
+	return strings.Join(list, " ")
+}
+
+// Replaces anything that can't be used in a class name with a dash.
+func sanitizeClass(class string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, class)
+}
+
+func splitClass(splitType htree.SplitType) string {
+	switch splitType {
+	case htree.SplitTypeHorizontal:
+		return "horizontal"
+	case htree.SplitTypeVertical:
+		return "vertical"
+	case htree.SplitTypeDepth:
+		return "depth"
+	}
+
+	return "unknown"
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func escape(s string) string {
+	buf := bytes.NewBuffer(nil)
+	xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,126 @@
+package svg_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/algo"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/generators/grid"
+	"github.com/scisci/hambidgetree/render"
+	"github.com/scisci/hambidgetree/render/svg"
+	"strings"
+	"testing"
+)
+
+func TestRender2D(t *testing.T) {
+	tree := grid.New2D(2)
+	leaves := algo.FindLeaves(tree)
+
+	attrs := attributors.NewNodeAttributer()
+	attrs.SetAttribute(leaves[0].ID(), "marked", "true")
+	attrs.SetAttribute(leaves[0].ID(), "color", "#ff0000")
+
+	opts := svg.DefaultOptions()
+	opts.Branches = true
+	opts.ClassKeys = []string{"marked"}
+	opts.FillKey = "color"
+
+	buf := bytes.NewBuffer(nil)
+	if err := svg.Render(buf, tree, attrs, opts); err != nil {
+		t.Fatalf("Failed to render %v", err)
+	}
+
+	doc := buf.String()
+	if err := xml.Unmarshal(buf.Bytes(), new(interface{})); err != nil {
+		t.Errorf("Invalid xml %v", err)
+	}
+
+	if n := strings.Count(doc, "<rect "); n != len(leaves) {
+		t.Errorf("Expected %d rects, got %d", len(leaves), n)
+	}
+
+	// A 2 level grid has one vertical split and two horizontal splits
+	if n := strings.Count(doc, "<line "); n != 3 {
+		t.Errorf("Expected 3 split lines, got %d", n)
+	}
+
+	if !strings.Contains(doc, `class="leaf marked-true"`) {
+		t.Errorf("Expected class derived from attribute")
+	}
+
+	if !strings.Contains(doc, `fill="#ff0000"`) {
+		t.Errorf("Expected fill derived from attribute")
+	}
+
+	if !strings.Contains(doc, `width="400" height="400"`) {
+		t.Errorf("Expected each cell to be a quarter of the document")
+	}
+}
+
+var projectionTests = []struct {
+	projection render.Projection
+	lines      int
+}{
+	{render.ProjectionFront, 3},
+	{render.ProjectionTop, 3},
+	{render.ProjectionSide, 3},
+}
+
+func TestRender3D(t *testing.T) {
+	tree := grid.New3D(3)
+	regionMap := htree.NewTreeRegionMap(tree, htree.Origin, htree.UnityScale)
+
+	for _, test := range projectionTests {
+		leaves := render.VisibleLeaves(tree, regionMap, test.projection)
+		if len(leaves) != 4 {
+			t.Errorf("Projection %v should see 4 leaves, got %d", test.projection, len(leaves))
+		}
+
+		lines := render.VisibleSplitLines(tree, regionMap, test.projection)
+		if len(lines) != test.lines {
+			t.Errorf("Projection %v should see %d lines, got %d", test.projection, test.lines, len(lines))
+		}
+
+		opts := svg.DefaultOptions()
+		opts.Projection = test.projection
+
+		buf := bytes.NewBuffer(nil)
+		if err := svg.Render(buf, tree, nil, opts); err != nil {
+			t.Errorf("Failed to render %v", err)
+		}
+
+		if n := strings.Count(buf.String(), "<rect "); n != 4 {
+			t.Errorf("Projection %v expected 4 rects, got %d", test.projection, n)
+		}
+	}
+}
+
+func TestRender2DProjections(t *testing.T) {
+	tree := grid.New2D(2)
+
+	render2D := func(projection render.Projection) string {
+		opts := svg.DefaultOptions()
+		opts.Projection = projection
+
+		buf := bytes.NewBuffer(nil)
+		if err := svg.Render(buf, tree, nil, opts); err != nil {
+			t.Fatalf("Failed to render %v", err)
+		}
+		return buf.String()
+	}
+
+	// 2D trees have no depth so they are always drawn from the front
+	front := render2D(render.ProjectionFront)
+	for _, projection := range []render.Projection{render.ProjectionTop, render.ProjectionSide} {
+		if doc := render2D(projection); doc != front {
+			t.Errorf("Projection %v expected the front of a 2D tree, got\n%s", projection, doc)
+		}
+	}
+
+	// A line still fits along its length
+	transform := render.NewFitTransform(render.Rect{Right: 2}, 100, 100, 0)
+	if transform.Scale != 50 {
+		t.Errorf("Expected a line to scale by 50, got %v", transform.Scale)
+	}
+}
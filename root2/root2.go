@@ -0,0 +1,45 @@
+package root2
+
+import (
+	htree "github.com/scisci/hambidgetree"
+)
+
+// Ratios built from the root two rectangle, the square, and their gnomons
+// (√2-1 and √2+1), halved and doubled so that every ratio has a split.
+var Exprs = []string{
+	"SQRT(2)/8",     // 0.1768 inverse of 5.657
+	"(SQRT(2)-1)/2", // 0.2071 1/2 of 0.4142
+	"1/4",           // 0.25
+	"SQRT(2)/4",     // 0.3536 1/2 of 0.7071
+	"SQRT(2)-1",     // 0.4142 inverse of 2.4142
+	"1/2",           // 0.5
+	"(SQRT(2)+1)/4", // 0.6036 1/2 of 1.2071
+	"SQRT(2)/2",     // 0.7071 inverse of 1.4142
+	"2*(SQRT(2)-1)", // 0.8284 inverse of 1.2071
+	"1",             // 1
+	"(SQRT(2)+1)/2", // 1.2071 1/2 of 2.4142
+	"SQRT(2)",       // 1.4142
+	"4*(SQRT(2)-1)", // 1.6569 inverse of 0.6036
+	"2",             // 2
+	"SQRT(2)+1",     // 2.4142
+	"2*SQRT(2)",     // 2.8284 inverse of 0.3536
+	"4",             // 4
+	"2*(SQRT(2)+1)", // 4.8284 inverse of 0.2071
+	"4*SQRT(2)",     // 5.6569
+}
+
+func RatioSource() htree.RatioSource {
+	ratioSource, err := htree.NewExprRatioSource(Exprs)
+	if err != nil {
+		panic(err)
+	}
+	return ratioSource
+}
+
+func ExactRatioSource() htree.ExactRatioSource {
+	ratioSource, err := htree.NewExactRatioSource(Exprs)
+	if err != nil {
+		panic(err)
+	}
+	return ratioSource
+}
@@ -0,0 +1,51 @@
+package root3
+
+import (
+	htree "github.com/scisci/hambidgetree"
+)
+
+// Ratios built from the root three rectangle, the square, and their gnomons
+// (√3-1 and √3+1), halved and doubled so that every ratio has a split.
+var Exprs = []string{
+	"SQRT(3)/12",    // 0.1443 inverse of 6.928
+	"(SQRT(3)-1)/4", // 0.1830 1/2 of 0.366
+	"1/4",           // 0.25
+	"SQRT(3)/6",     // 0.2887 inverse of 3.4641
+	"(SQRT(3)+1)/8", // 0.3415 inverse of 2.9282
+	"(SQRT(3)-1)/2", // 0.3660 1/2 of 0.7321
+	"SQRT(3)/4",     // 0.4330 1/2 of 0.866
+	"1/2",           // 0.5
+	"1/SQRT(3)",     // 0.5774 inverse of 1.7321
+	"(SQRT(3)+1)/4", // 0.6830 1/2 of 1.366
+	"SQRT(3)-1",     // 0.7321 inverse of 1.366
+	"SQRT(3)/2",     // 0.8660 1/2 of 1.7321
+	"1",             // 1
+	"2/SQRT(3)",     // 1.1547 inverse of 0.866
+	"(SQRT(3)+1)/2", // 1.3660 1/2 of 2.7321
+	"2*(SQRT(3)-1)", // 1.4641 inverse of 0.683
+	"SQRT(3)",       // 1.7321
+	"2",             // 2
+	"4/SQRT(3)",     // 2.3094 inverse of 0.433
+	"SQRT(3)+1",     // 2.7321
+	"4*(SQRT(3)-1)", // 2.9282 inverse of 0.3415
+	"2*SQRT(3)",     // 3.4641
+	"4",             // 4
+	"2*(SQRT(3)+1)", // 5.4641 inverse of 0.183
+	"4*SQRT(3)",     // 6.9282
+}
+
+func RatioSource() htree.RatioSource {
+	ratioSource, err := htree.NewExprRatioSource(Exprs)
+	if err != nil {
+		panic(err)
+	}
+	return ratioSource
+}
+
+func ExactRatioSource() htree.ExactRatioSource {
+	ratioSource, err := htree.NewExactRatioSource(Exprs)
+	if err != nil {
+		panic(err)
+	}
+	return ratioSource
+}
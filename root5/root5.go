@@ -0,0 +1,53 @@
+package root5
+
+import (
+	htree "github.com/scisci/hambidgetree"
+)
+
+// Ratios built from the root five rectangle, the square, and their gnomons
+// (√5-1 and √5+1), halved and doubled so that every ratio has a split. This is
+// a much smaller set than golden, which contains many more of the root five
+// subdivisions.
+var Exprs = []string{
+	"(SQRT(5)-1)/8",  // 0.1545 inverse of 6.4721
+	"(SQRT(5)+1)/16", // 0.2023 inverse of 4.9443
+	"SQRT(5)/10",     // 0.2236 inverse of 4.4721
+	"1/4",            // 0.25
+	"(SQRT(5)-1)/4",  // 0.3090 1/2 of 0.618
+	"(SQRT(5)+1)/8",  // 0.4045 1/2 of 0.809
+	"1/SQRT(5)",      // 0.4472 inverse of 2.2361
+	"1/2",            // 0.5
+	"SQRT(5)/4",      // 0.5590 1/2 of 1.118
+	"(SQRT(5)-1)/2",  // 0.6180
+	"(SQRT(5)+1)/4",  // 0.8090 1/2 of 1.618
+	"2/SQRT(5)",      // 0.8944 inverse of 1.118
+	"1",              // 1
+	"SQRT(5)/2",      // 1.1180 1/2 of 2.2361
+	"SQRT(5)-1",      // 1.2361
+	"(SQRT(5)+1)/2",  // 1.6180
+	"4/SQRT(5)",      // 1.7889 inverse of 0.559
+	"2",              // 2
+	"SQRT(5)",        // 2.2361
+	"2*(SQRT(5)-1)",  // 2.4721 inverse of 0.4045
+	"SQRT(5)+1",      // 3.2361
+	"4",              // 4
+	"2*SQRT(5)",      // 4.4721
+	"4*(SQRT(5)-1)",  // 4.9443
+	"2*(SQRT(5)+1)",  // 6.4721
+}
+
+func RatioSource() htree.RatioSource {
+	ratioSource, err := htree.NewExprRatioSource(Exprs)
+	if err != nil {
+		panic(err)
+	}
+	return ratioSource
+}
+
+func ExactRatioSource() htree.ExactRatioSource {
+	ratioSource, err := htree.NewExactRatioSource(Exprs)
+	if err != nil {
+		panic(err)
+	}
+	return ratioSource
+}
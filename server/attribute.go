@@ -0,0 +1,48 @@
+package server
+
+import (
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/attributors/edgepath"
+)
+
+// The parameter document used to run an attributor. The attributor is the
+// name of a registered attributor or one of its aliases, case and spaces are
+// ignored. Fields not used by the chosen attributor are ignored.
+type AttributeRequest struct {
+	Attributor string              `json:"attributor"`
+	Seed       int64               `json:"seed"`
+	MaxMarks   int                 `json:"maxMarks"`
+	Dimension  int                 `json:"dimension"`
+	Paths      []edgepath.EdgePath `json:"paths"`
+	Chaos      float64             `json:"chaos"`
+}
+
+// Returns the request used for any fields left out of an attribute request.
+func DefaultAttributeRequest() *AttributeRequest {
+	return &AttributeRequest{
+		Attributor: "neighbor",
+		MaxMarks:   10,
+		Dimension:  2,
+		Paths:      []edgepath.EdgePath{{From: edgepath.EdgeNameLeft, To: edgepath.EdgeNameRight}},
+	}
+}
+
+// Returns the spec of the attributor described by the request.
+func (req *AttributeRequest) Spec() (*attributors.Spec, error) {
+	return attributors.NewSpec(req.Attributor, map[string]interface{}{
+		"seed":      req.Seed,
+		"maxMarks":  req.MaxMarks,
+		"dimension": req.Dimension,
+		"paths":     req.Paths,
+		"chaos":     req.Chaos,
+	})
+}
+
+// Creates the attributor described by the request.
+func NewAttributor(req *AttributeRequest) (attributors.TreeAttributor, error) {
+	spec, err := req.Spec()
+	if err != nil {
+		return nil, err
+	}
+	return attributors.NewFromSpec(spec)
+}
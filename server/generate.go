@@ -0,0 +1,60 @@
+package server
+
+import (
+	"github.com/scisci/hambidgetree/generators"
+)
+
+// The parameter document used to generate a tree. The generator is the name
+// of a registered generator, case and spaces are ignored so "randombasic"
+// works as well as "Random Basic". Fields not used by the chosen generator
+// are ignored.
+type GenerateRequest struct {
+	Generator string  `json:"generator"`
+	Family    string  `json:"family"`
+	Exact     bool    `json:"exact"`
+	Leaves    int     `json:"leaves"`
+	Seed      int64   `json:"seed"`
+	RatioXY   float64 `json:"ratioXY"`
+	RatioZY   float64 `json:"ratioZY"`
+	Levels    int     `json:"levels"`
+	Is3D      bool    `json:"is3D"`
+}
+
+// Returns the request used for any fields left out of a generate request.
+func DefaultGenerateRequest() *GenerateRequest {
+	return &GenerateRequest{
+		Generator: "randombasic",
+		Family:    "golden",
+		Leaves:    20,
+		RatioXY:   1,
+		Levels:    2,
+	}
+}
+
+// Returns the spec of the generator described by the request.
+func (req *GenerateRequest) Spec() (*generators.Spec, error) {
+	ratioZY := req.RatioZY
+	if req.Is3D && ratioZY <= 0 {
+		ratioZY = 1
+	}
+
+	return generators.NewSpec(req.Generator, map[string]interface{}{
+		"family":  req.Family,
+		"exact":   req.Exact,
+		"leaves":  req.Leaves,
+		"seed":    req.Seed,
+		"ratioXY": req.RatioXY,
+		"ratioZY": ratioZY,
+		"levels":  req.Levels,
+		"is3D":    req.Is3D,
+	})
+}
+
+// Creates the generator described by the request.
+func NewGenerator(req *GenerateRequest) (generators.TreeGenerator, error) {
+	spec, err := req.Spec()
+	if err != nil {
+		return nil, err
+	}
+	return generators.NewFromSpec(spec)
+}
@@ -0,0 +1,419 @@
+// Package server exposes tree generation, regions and attributors as a JSON
+// service over HTTP.
+//
+// Endpoints:
+//
+//	GET  /generators  list the available generators and their default parameters
+//	POST /generate    generate a tree from a GenerateRequest or a generator spec,
+//	                  returns factory JSON
+//	POST /regions     compute the regions of a tree at an offset and scale
+//	GET  /attributors list the attributors and their default parameters
+//	POST /attribute   run attributors on a tree in order, returns the attributes
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	htree "github.com/scisci/hambidgetree"
+	"github.com/scisci/hambidgetree/attributors"
+	_ "github.com/scisci/hambidgetree/attributors/all"
+	"github.com/scisci/hambidgetree/factory"
+	"github.com/scisci/hambidgetree/generators"
+	_ "github.com/scisci/hambidgetree/generators/all"
+	"github.com/scisci/hambidgetree/generators/constrained"
+	"net/http"
+)
+
+var ErrTooManyLeaves = errors.New("Too many leaves requested")
+var ErrInvalidLeaves = errors.New("Number of leaves must be at least 1")
+var ErrGeneratorNotAllowed = errors.New("Generator isn't available")
+var ErrTooManySteps = errors.New("Too many steps requested")
+var ErrMissingTree = errors.New("Request must contain a tree or a generate request")
+
+const defaultMaxSteps = 100000
+
+// Limits applied to every request.
+type Options struct {
+	MaxLeaves int // Largest number of leaves a generator may be asked for
+	// Lower limits on the leaves of generators whose time grows much faster
+	// than their leaves, a limit of 0 means the generator can't be used. Nil
+	// uses DefaultGeneratorLeaves.
+	GeneratorLeaves map[string]int
+	MaxSteps        int   // Most splits the Constrained generator may try, 0 for the default
+	MaxBodySize     int64 // Largest request body in bytes
+}
+
+// Returns the options used when none are provided.
+func DefaultOptions() *Options {
+	return &Options{
+		MaxLeaves:       10000,
+		GeneratorLeaves: DefaultGeneratorLeaves(),
+		MaxSteps:        defaultMaxSteps,
+		MaxBodySize:     16 << 20,
+	}
+}
+
+// Enumerate walks every layout up to the index it is asked for so it isn't
+// available, Uniform rejects more samples the more leaves there are.
+func DefaultGeneratorLeaves() map[string]int {
+	return map[string]int{
+		"Enumerate": 0,
+		"Uniform":   30,
+	}
+}
+
+type Server struct {
+	opts *Options
+	mux  *http.ServeMux
+}
+
+// Creates a new server, opts may be nil.
+func New(opts *Options) *Server {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	server := &Server{
+		opts: opts,
+		mux:  http.NewServeMux(),
+	}
+
+	server.mux.HandleFunc("/generators", server.method(http.MethodGet, server.handleGenerators))
+	server.mux.HandleFunc("/generate", server.method(http.MethodPost, server.handleGenerate))
+	server.mux.HandleFunc("/regions", server.method(http.MethodPost, server.handleRegions))
+	server.mux.HandleFunc("/attributors", server.method(http.MethodGet, server.handleAttributors))
+	server.mux.HandleFunc("/attribute", server.method(http.MethodPost, server.handleAttribute))
+	return server
+}
+
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mux.ServeHTTP(w, r)
+}
+
+func (server *Server) method(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("Method %s not allowed", r.Method))
+			return
+		}
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, server.opts.MaxBodySize)
+		}
+		handler(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// A tree sent with a request, either in factory JSON format or as the
+// parameters to generate it with.
+type TreeRequest struct {
+	Tree     json.RawMessage `json:"tree,omitempty"`
+	Generate json.RawMessage `json:"generate,omitempty"`
+}
+
+// Returns the most leaves the generator may be asked for, 0 if it can't be
+// used.
+func (server *Server) maxLeaves(name string) int {
+	generatorLeaves := server.opts.GeneratorLeaves
+	if generatorLeaves == nil {
+		generatorLeaves = DefaultGeneratorLeaves()
+	}
+
+	if limit, ok := generatorLeaves[name]; ok && limit < server.opts.MaxLeaves {
+		return limit
+	}
+	return server.opts.MaxLeaves
+}
+
+// Returns the names of the generators that can be used sorted
+// alphabetically.
+func (server *Server) GeneratorNames() []string {
+	var names []string
+	for _, name := range generators.Names() {
+		if server.maxLeaves(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Creates the generator of a spec if it is within the limits.
+func (server *Server) newGenerator(spec *generators.Spec) (generators.TreeGenerator, error) {
+	maxLeaves := server.maxLeaves(spec.Generator)
+	if maxLeaves <= 0 {
+		return nil, ErrGeneratorNotAllowed
+	}
+
+	leaves, err := spec.NumLeaves()
+	if err != nil {
+		return nil, err
+	}
+	if leaves < 1 {
+		return nil, ErrInvalidLeaves
+	}
+	if leaves > maxLeaves {
+		return nil, ErrTooManyLeaves
+	}
+
+	gen, err := generators.NewFromSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSteps := server.opts.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultMaxSteps
+	}
+	if gen, ok := gen.(*constrained.ConstrainedTreeGenerator); ok && (gen.MaxSteps <= 0 || gen.MaxSteps > maxSteps) {
+		return nil, ErrTooManySteps
+	}
+
+	return gen, nil
+}
+
+// Decodes a generator spec, i.e. {"generator":"Random Basic","params":{}}, or
+// a generate request.
+func (server *Server) decodeGenerator(data []byte) (generators.TreeGenerator, error) {
+	spec := &generators.Spec{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+
+	// Specs use the names of the registered generators
+	if _, err := generators.Lookup(spec.Generator); err != nil {
+		req := DefaultGenerateRequest()
+		if err := json.Unmarshal(data, req); err != nil {
+			return nil, err
+		}
+		if spec, err = req.Spec(); err != nil {
+			return nil, err
+		}
+	}
+
+	return server.newGenerator(spec)
+}
+
+func (server *Server) generate(data []byte) (htree.Tree, error) {
+	gen, err := server.decodeGenerator(data)
+	if err != nil {
+		return nil, err
+	}
+	return gen.Generate()
+}
+
+func (server *Server) tree(req *TreeRequest) (htree.Tree, error) {
+	if len(req.Tree) > 0 {
+		return factory.UnmarshalJSON(req.Tree)
+	}
+	if len(req.Generate) > 0 {
+		return server.generate(req.Generate)
+	}
+	return nil, ErrMissingTree
+}
+
+// Describes a generator or attributor with its default parameters. Schema
+// lists the parameters of a generator's spec.
+type Description struct {
+	Name        string                       `json:"name"`
+	Title       string                       `json:"title"`
+	Description string                       `json:"description"`
+	Parameters  map[string]interface{}       `json:"parameters"`
+	Schema      []generators.ParameterSchema `json:"schema,omitempty"`
+}
+
+func (server *Server) handleGenerators(w http.ResponseWriter, r *http.Request) {
+	var descs []Description
+	for _, name := range server.GeneratorNames() {
+		gen, err := generators.NewFromSpec(&generators.Spec{Generator: name})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		schema, _ := generators.Schema(name)
+		descs = append(descs, Description{
+			Name:        name,
+			Title:       gen.Name(),
+			Description: gen.Description(),
+			Parameters:  gen.Parameters(generators.ParameterFormatTypeVerbose),
+			Schema:      schema,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, descs)
+}
+
+func (server *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var data json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tree, err := server.generate(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	treeData, err := factory.MarshalJSON(tree)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(treeData)
+}
+
+// Request for the regions of a tree. Scale defaults to unity.
+type RegionsRequest struct {
+	TreeRequest
+	Offset   [3]float64 `json:"offset"`
+	Scale    float64    `json:"scale"`
+	Branches bool       `json:"branches"` // Whether to include branches as well as leaves
+}
+
+type RegionResponse struct {
+	ID           htree.NodeID `json:"id"`
+	Leaf         bool         `json:"leaf"`
+	Min          [3]float64   `json:"min"`
+	Max          [3]float64   `json:"max"`
+	RatioIndexXY int          `json:"ratioIndexXY"`
+	RatioIndexZY int          `json:"ratioIndexZY"`
+}
+
+func (server *Server) handleRegions(w http.ResponseWriter, r *http.Request) {
+	req := &RegionsRequest{Scale: htree.UnityScale}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tree, err := server.tree(&req.TreeRequest)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	offset := htree.NewVector(req.Offset[0], req.Offset[1], req.Offset[2])
+	regions := []RegionResponse{}
+	it := htree.NewRegionIterator(tree, offset, req.Scale)
+	for it.HasNext() {
+		nodeRegion := it.Next()
+		leaf := nodeRegion.Node().Branch() == nil
+		if !leaf && !req.Branches {
+			continue
+		}
+
+		region := nodeRegion.Region()
+		dim := region.AlignedBox()
+		regions = append(regions, RegionResponse{
+			ID:           nodeRegion.Node().ID(),
+			Leaf:         leaf,
+			Min:          [3]float64{dim.Left(), dim.Top(), dim.Front()},
+			Max:          [3]float64{dim.Right(), dim.Bottom(), dim.Back()},
+			RatioIndexXY: region.RatioIndexXY(),
+			RatioIndexZY: region.RatioIndexZY(),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, regions)
+}
+
+func (server *Server) handleAttributors(w http.ResponseWriter, r *http.Request) {
+	var descs []Description
+	for _, name := range attributors.Names() {
+		attributor, err := attributors.NewFromSpec(&attributors.Spec{Attributor: name})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		descs = append(descs, Description{
+			Name:        name,
+			Title:       attributor.Name(),
+			Description: attributor.Description(),
+			Parameters:  attributor.Parameters(attributors.ParameterFormatTypeVerbose),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, descs)
+}
+
+// Decodes a stage of the attribute pipeline, either an attribute request or
+// an attributor spec that may have conditions, i.e.
+// {"attributor":"EdgePath","params":{},"where":[{"key":"hasNeighbor"}]}.
+func decodeStage(data []byte) (*attributors.Stage, error) {
+	stageSpec := &attributors.StageSpec{}
+	if err := json.Unmarshal(data, stageSpec); err != nil {
+		return nil, err
+	}
+
+	// Specs use the names of the registered attributors
+	spec := &stageSpec.Spec
+	if _, err := attributors.Lookup(spec.Attributor); err != nil {
+		req := DefaultAttributeRequest()
+		if err := json.Unmarshal(data, req); err != nil {
+			return nil, err
+		}
+		if spec, err = req.Spec(); err != nil {
+			return nil, err
+		}
+	}
+
+	attributor, err := attributors.NewFromSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &attributors.Stage{Attributor: attributor, Where: stageSpec.Where}, nil
+}
+
+// Request to run one or more attributors on a tree, in order. Each is an
+// attribute request or an attributor spec.
+type AttributeTreeRequest struct {
+	TreeRequest
+	Attributors []json.RawMessage `json:"attributors"`
+}
+
+func (server *Server) handleAttribute(w http.ResponseWriter, r *http.Request) {
+	req := &AttributeTreeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tree, err := server.tree(&req.TreeRequest)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	pipeline := attributors.NewPipeline()
+	for _, data := range req.Attributors {
+		stage, err := decodeStage(data)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		pipeline.Stages = append(pipeline.Stages, stage)
+	}
+
+	attrs, err := pipeline.Run(tree)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Attributes keep their types, as in the attribute files of the cli
+	writeJSON(w, http.StatusOK, attrs)
+}
@@ -0,0 +1,320 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/scisci/hambidgetree/attributors"
+	"github.com/scisci/hambidgetree/factory"
+	"github.com/scisci/hambidgetree/server"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func post(t *testing.T, ts *httptest.Server, path, body string) *http.Response {
+	resp, err := http.Post(ts.URL+path, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Failed to post %s %v", path, err)
+	}
+	return resp
+}
+
+func TestGenerators(t *testing.T) {
+	srv := server.New(nil)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/generators")
+	if err != nil {
+		t.Fatalf("Failed to get generators %v", err)
+	}
+	defer resp.Body.Close()
+
+	var descs []server.Description
+	if err := json.NewDecoder(resp.Body).Decode(&descs); err != nil {
+		t.Fatalf("Failed to decode generators %v", err)
+	}
+
+	if len(descs) != len(srv.GeneratorNames()) {
+		t.Fatalf("Expected %d generators, got %d", len(srv.GeneratorNames()), len(descs))
+	}
+
+	for _, desc := range descs {
+		if desc.Name == "Enumerate" {
+			t.Errorf("Expected Enumerate not to be available")
+		}
+		if desc.Title == "" || desc.Description == "" || len(desc.Parameters) == 0 {
+			t.Errorf("Generator %s is missing its description", desc.Name)
+		}
+		if len(desc.Schema) == 0 {
+			t.Errorf("Generator %s is missing its schema", desc.Name)
+		}
+	}
+}
+
+func TestAttributors(t *testing.T) {
+	ts := httptest.NewServer(server.New(nil))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/attributors")
+	if err != nil {
+		t.Fatalf("Failed to get attributors %v", err)
+	}
+	defer resp.Body.Close()
+
+	var descs []server.Description
+	if err := json.NewDecoder(resp.Body).Decode(&descs); err != nil {
+		t.Fatalf("Failed to decode attributors %v", err)
+	}
+
+	// Every registered attributor is listed
+	names := make(map[string]bool)
+	for _, desc := range descs {
+		names[desc.Name] = true
+		if desc.Title == "" || desc.Description == "" {
+			t.Errorf("Attributor %s is missing its description", desc.Name)
+		}
+	}
+	for _, name := range attributors.Names() {
+		if !names[name] {
+			t.Errorf("Expected attributor %s to be listed", name)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	ts := httptest.NewServer(server.New(nil))
+	defer ts.Close()
+
+	body := `{"generator": "randombasic", "leaves": 15, "seed": 4, "family": "root3"}`
+	resp := post(t, ts, "/generate", body)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status ok, got %d", resp.StatusCode)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	tree, err := factory.UnmarshalJSON(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to unmarshal tree %v", err)
+	}
+
+	// The same parameters must generate the same tree
+	resp2 := post(t, ts, "/generate", body)
+	defer resp2.Body.Close()
+	var buf2 bytes.Buffer
+	buf2.ReadFrom(resp2.Body)
+	if !bytes.Equal(buf.Bytes(), buf2.Bytes()) {
+		t.Errorf("Expected generate to be deterministic")
+	}
+
+	// Regions of the returned tree at an offset and scale
+	regionsBody, _ := json.Marshal(map[string]interface{}{
+		"tree":   json.RawMessage(buf.Bytes()),
+		"offset": []float64{10, 20, 0},
+		"scale":  2,
+	})
+	resp3 := post(t, ts, "/regions", string(regionsBody))
+	defer resp3.Body.Close()
+
+	var regions []server.RegionResponse
+	if err := json.NewDecoder(resp3.Body).Decode(&regions); err != nil {
+		t.Fatalf("Failed to decode regions %v", err)
+	}
+
+	if len(regions) != 15 {
+		t.Fatalf("Expected 15 leaf regions, got %d", len(regions))
+	}
+
+	area := 0.0
+	for _, region := range regions {
+		if !region.Leaf {
+			t.Errorf("Expected only leaves")
+		}
+		if region.Min[0] < 10 || region.Min[1] < 20 || region.Max[1] > 22+0.0000001 {
+			t.Errorf("Region %d outside of container %v %v", region.ID, region.Min, region.Max)
+		}
+		area += (region.Max[0] - region.Min[0]) * (region.Max[1] - region.Min[1])
+	}
+
+	width := tree.RatioSource().Ratios()[tree.RatioIndexXY()] * 2
+	if math.Abs(area-width*2) > 0.000001 {
+		t.Errorf("Expected leaves to cover the container, got area %f", area)
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	ts := httptest.NewServer(server.New(nil))
+	defer ts.Close()
+
+	body := `{
+		"generate": {"generator": "grid", "levels": 4},
+		"attributors": [
+			{"attributor": "neighbor", "maxMarks": 3, "seed": 1},
+			{"attributor": "edgepath", "paths": [{"From": 0, "To": 1}], "seed": 1},
+			{"attributor": "coloring", "seed": 1}
+		]
+	}`
+	resp := post(t, ts, "/attribute", body)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status ok, got %d", resp.StatusCode)
+	}
+
+	attrs := attributors.NewNodeAttributer()
+	if err := json.NewDecoder(resp.Body).Decode(attrs); err != nil {
+		t.Fatalf("Failed to decode attributes %v", err)
+	}
+
+	marks, onPath := 0, 0
+	for _, id := range attrs.Nodes() {
+		if _, err := attrs.Int(id, "colorIndex"); err != nil {
+			t.Errorf("Expected node %d to have an int color index, got %v", id, err)
+		}
+		if marked, _ := attrs.Bool(id, "hasNeighbor"); marked {
+			marks++
+		}
+		if path, _ := attrs.Bool(id, "onPath"); path {
+			onPath++
+		}
+	}
+
+	if marks != 3 {
+		t.Errorf("Expected 3 marked leaves, got %d", marks)
+	}
+
+	// A 4x4 grid needs at least 4 leaves to get from left to right
+	if onPath < 4 {
+		t.Errorf("Expected a path of at least 4 leaves, got %d", onPath)
+	}
+}
+
+func TestAttributeSpec(t *testing.T) {
+	ts := httptest.NewServer(server.New(nil))
+	defer ts.Close()
+
+	// Only leaves marked by the first stage can be on the path
+	body := `{
+		"generate": {"generator": "Grid", "params": {"levels": 4}},
+		"attributors": [
+			{"attributor": "HasNeighbor", "params": {"maxMarks": 8, "seed": 1}},
+			{"attributor": "EdgePath", "params": {"seed": 1}, "where": [{"key": "hasNeighbor"}]}
+		]
+	}`
+	resp := post(t, ts, "/attribute", body)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status ok, got %d", resp.StatusCode)
+	}
+
+	attrs := attributors.NewNodeAttributer()
+	if err := json.NewDecoder(resp.Body).Decode(attrs); err != nil {
+		t.Fatalf("Failed to decode attributes %v", err)
+	}
+
+	marks := 0
+	for _, id := range attrs.Nodes() {
+		if marked, _ := attrs.Bool(id, "hasNeighbor"); marked {
+			marks++
+		} else if path, _ := attrs.Bool(id, "onPath"); path {
+			t.Errorf("Expected unmarked node %d to be left off the path", id)
+		}
+	}
+
+	if marks != 8 {
+		t.Errorf("Expected 8 marked leaves, got %d", marks)
+	}
+}
+
+func TestGenerateSpec(t *testing.T) {
+	ts := httptest.NewServer(server.New(nil))
+	defer ts.Close()
+
+	// A spec and a generate request for the same generator make the same tree
+	bodies := []string{
+		`{"generator": "Random Basic", "params": {"leaves": 15, "seed": 4, "family": "root3"}}`,
+		`{"generator": "randombasic", "leaves": 15, "seed": 4, "family": "root3"}`,
+		`{"generator": "RANDOM BASIC", "leaves": 15, "seed": 4, "family": "root3"}`,
+	}
+
+	var trees [][]byte
+	for _, body := range bodies {
+		resp := post(t, ts, "/generate", body)
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status ok, got %d", resp.StatusCode)
+		}
+
+		var buf bytes.Buffer
+		buf.ReadFrom(resp.Body)
+		trees = append(trees, buf.Bytes())
+	}
+
+	for _, tree := range trees[1:] {
+		if !bytes.Equal(trees[0], tree) {
+			t.Errorf("Expected spec to generate the same tree as the request")
+		}
+	}
+
+	// Generators limited to fewer leaves still work below the limit
+	for _, body := range []string{
+		`{"generator": "Uniform", "params": {"leaves": 10, "seed": 2}}`,
+		`{"generator": "constrained", "leaves": 10, "seed": 2}`,
+	} {
+		resp := post(t, ts, "/generate", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected %s to generate, got status %d", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ts := httptest.NewServer(server.New(&server.Options{MaxLeaves: 100, MaxBodySize: 1 << 20}))
+	defer ts.Close()
+
+	tests := []struct {
+		path   string
+		body   string
+		status int
+	}{
+		{"/generate", `{"generator": "bogus"}`, http.StatusBadRequest},
+		{"/generate", `{"leaves": 1000}`, http.StatusBadRequest},
+		{"/generate", `{"generator": "grid", "levels": 40}`, http.StatusBadRequest},
+		{"/generate", `not json`, http.StatusBadRequest},
+		{"/generate", `{"generator": "Grid", "params": {"levels": 40}}`, http.StatusBadRequest},
+		{"/generate", `{"generator": "Grid", "params": {"bogus": 1}}`, http.StatusBadRequest},
+		{"/generate", `{"generator": "Random Basic", "params": {"leaves": -1}}`, http.StatusBadRequest},
+		{"/generate", `{"generator": "randombasic", "leaves": 0}`, http.StatusBadRequest},
+		{"/generate", `{"generator": "Enumerate", "params": {"leaves": 14, "index": 100000000}}`, http.StatusBadRequest},
+		{"/generate", `{"generator": "enumerate", "leaves": 4}`, http.StatusBadRequest},
+		{"/generate", `{"generator": "Uniform", "params": {"leaves": 40}}`, http.StatusBadRequest},
+		{"/generate", `{"generator": "Constrained", "params": {"maxSteps": 0}}`, http.StatusBadRequest},
+		{"/regions", `{}`, http.StatusBadRequest},
+		{"/attribute", `{"generate": {}, "attributors": [{"attributor": "bogus"}]}`, http.StatusBadRequest},
+		{"/attribute", `{"generate": {}, "attributors": [{"attributor": "HasNeighbor", "params": {"bogus": 1}}]}`, http.StatusBadRequest},
+	}
+
+	for i, test := range tests {
+		resp := post(t, ts, test.path, test.body)
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("Test %d expected status %d, got %d", i, test.status, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(ts.URL + "/generate")
+	if err != nil {
+		t.Fatalf("Failed to get %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected method not allowed, got %d", resp.StatusCode)
+	}
+}
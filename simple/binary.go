@@ -0,0 +1,254 @@
+package simple
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	htree "github.com/scisci/hambidgetree"
+	"io"
+)
+
+const BinaryVersion = 1
+
+var ErrInvalidBinary = errors.New("Invalid binary tree data")
+var ErrUnsupportedBinaryVersion = errors.New("Unsupported binary tree version")
+
+// Binary layout, all integers are varints:
+//
+// magic: "htb"
+// version: uvarint
+// flags: uvarint, binaryFlagExact if the ratio source is exact
+// ratios: uvarint count, then each expr as uvarint length and bytes
+// ratioIndexXY, ratioIndexZY: varint, -1 when undefined
+// root: varint id
+// nodes: pre-order, each node is a uvarint code, 0 for a leaf, otherwise
+// (leftIndex * numRatios + rightIndex) * 4 + splitType followed by the child
+// ids as varint deltas, left - parent and right - left - 1.
+//
+// The structure is implied by the pre-order but the ids aren't, since
+// attributes and documents refer to nodes by id they have to survive a round
+// trip, and the builder numbers nodes in the order they were split rather
+// than in pre-order. The builder numbers the children of a branch
+// consecutively so the right delta is always 0, which takes a single byte.
+var binaryMagic = []byte("htb")
+
+const binarySplitTypeBits = 2
+
+const binaryFlagExact = 1
+
+// Encodes any tree in the compact binary format.
+func MarshalBinary(tree htree.Tree) ([]byte, error) {
+	var buf bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+
+	putUvarint := func(v uint64) {
+		buf.Write(scratch[:binary.PutUvarint(scratch[:], v)])
+	}
+	putVarint := func(v int64) {
+		buf.Write(scratch[:binary.PutVarint(scratch[:], v)])
+	}
+
+	buf.Write(binaryMagic)
+	putUvarint(BinaryVersion)
+
+	var flags uint64
+	if _, ok := tree.RatioSource().(htree.ExactRatioSource); ok {
+		flags |= binaryFlagExact
+	}
+	putUvarint(flags)
+
+	exprs := tree.RatioSource().Exprs()
+	numRatios := uint64(len(exprs))
+	putUvarint(numRatios)
+	for _, expr := range exprs {
+		putUvarint(uint64(len(expr)))
+		buf.WriteString(expr)
+	}
+
+	putVarint(int64(tree.RatioIndexXY()))
+	putVarint(int64(tree.RatioIndexZY()))
+	putVarint(int64(tree.Root().ID()))
+
+	stack := []htree.Node{tree.Root()}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		branch := node.Branch()
+		if branch == nil {
+			putUvarint(0)
+			continue
+		}
+
+		splitType := uint64(branch.SplitType())
+		if splitType == 0 || splitType >= 1<<binarySplitTypeBits {
+			return nil, InvalidSplitType
+		}
+
+		leftIndex, rightIndex := branch.LeftIndex(), branch.RightIndex()
+		if leftIndex < 0 || uint64(leftIndex) >= numRatios || rightIndex < 0 || uint64(rightIndex) >= numRatios {
+			return nil, ErrInvalidBinary
+		}
+
+		putUvarint((uint64(leftIndex)*numRatios+uint64(rightIndex))<<binarySplitTypeBits | splitType)
+
+		left, right := branch.Left(), branch.Right()
+		putVarint(int64(left.ID() - node.ID()))
+		putVarint(int64(right.ID() - left.ID() - 1))
+
+		stack = append(stack, right, left)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (tree *Tree) MarshalBinary() ([]byte, error) {
+	return MarshalBinary(tree)
+}
+
+// Decodes a tree in the compact binary format.
+func UnmarshalBinary(data []byte) (*Tree, error) {
+	tree := &Tree{}
+	err := tree.UnmarshalBinary(data)
+	return tree, err
+}
+
+func (tree *Tree) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, binaryMagic) {
+		return ErrInvalidBinary
+	}
+
+	r := bytes.NewReader(data[len(binaryMagic):])
+
+	version, err := binary.ReadUvarint(r)
+	if err != nil {
+		return ErrInvalidBinary
+	}
+	if version != BinaryVersion {
+		return ErrUnsupportedBinaryVersion
+	}
+
+	flags, err := binary.ReadUvarint(r)
+	if err != nil || flags&^binaryFlagExact != 0 {
+		return ErrInvalidBinary
+	}
+
+	numRatios, err := binary.ReadUvarint(r)
+	if err != nil || numRatios > uint64(r.Len()) {
+		return ErrInvalidBinary
+	}
+
+	exprs := make([]string, numRatios)
+	for i := range exprs {
+		n, err := binary.ReadUvarint(r)
+		if err != nil || n > uint64(r.Len()) {
+			return ErrInvalidBinary
+		}
+		expr := make([]byte, n)
+		if _, err := io.ReadFull(r, expr); err != nil {
+			return ErrInvalidBinary
+		}
+		exprs[i] = string(expr)
+	}
+
+	var header [3]int64
+	for i := range header {
+		if header[i], err = binary.ReadVarint(r); err != nil {
+			return ErrInvalidBinary
+		}
+	}
+
+	// The container ratios must be in the ratio source, ZY is undefined in 2D
+	if header[0] < 0 || uint64(header[0]) >= numRatios ||
+		header[1] < -1 || (header[1] >= 0 && uint64(header[1]) >= numRatios) {
+		return ErrInvalidBinary
+	}
+
+	nodes := make(NodeLookup)
+	parents := make(ParentLookup)
+
+	newNode := func(id htree.NodeID) (*Node, error) {
+		if _, ok := nodes[id]; ok {
+			return nil, ErrInvalidBinary
+		}
+		node := &Node{id: id}
+		nodes[id] = node
+		return node, nil
+	}
+
+	root, err := newNode(htree.NodeID(header[2]))
+	if err != nil {
+		return err
+	}
+
+	stack := []*Node{root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		code, err := binary.ReadUvarint(r)
+		if err != nil {
+			return ErrInvalidBinary
+		}
+		if code == 0 {
+			continue
+		}
+
+		splitType := htree.SplitType(code & (1<<binarySplitTypeBits - 1))
+		if splitType != htree.SplitTypeHorizontal && splitType != htree.SplitTypeVertical && splitType != htree.SplitTypeDepth {
+			return InvalidSplitType
+		}
+
+		indexes := code >> binarySplitTypeBits
+		if numRatios == 0 || indexes >= numRatios*numRatios {
+			return ErrInvalidBinary
+		}
+
+		leftDelta, err := binary.ReadVarint(r)
+		if err != nil {
+			return ErrInvalidBinary
+		}
+		rightDelta, err := binary.ReadVarint(r)
+		if err != nil {
+			return ErrInvalidBinary
+		}
+
+		leftID := node.id + htree.NodeID(leftDelta)
+		left, err := newNode(leftID)
+		if err != nil {
+			return err
+		}
+		right, err := newNode(leftID + 1 + htree.NodeID(rightDelta))
+		if err != nil {
+			return err
+		}
+
+		node.branch = NewBranch(splitType, left, right, int(indexes/numRatios), int(indexes%numRatios))
+		parents[left.id] = node.id
+		parents[right.id] = node.id
+
+		stack = append(stack, right, left)
+	}
+
+	if r.Len() != 0 {
+		return ErrInvalidBinary
+	}
+
+	var ratioSource htree.RatioSource
+	if flags&binaryFlagExact != 0 {
+		ratioSource, err = htree.NewExactRatioSource(exprs)
+	} else {
+		ratioSource, err = htree.NewExprRatioSource(exprs)
+	}
+	if err != nil {
+		return err
+	}
+
+	tree.nodes = nodes
+	tree.parents = parents
+	tree.ratioIndexXY = int(header[0])
+	tree.ratioIndexZY = int(header[1])
+	tree.ratioSource = ratioSource
+	tree.root = root
+	return nil
+}
@@ -2,6 +2,7 @@ package simple_test
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	htree "github.com/scisci/hambidgetree"
@@ -65,3 +66,110 @@ func TestSerialize(t *testing.T) {
 		}
 	}
 }
+
+func TestSerializeBinary(t *testing.T) {
+	gen, err := randombasic.New3D(golden.RatioSource(), 1, 1, 30, 11)
+	if err != nil {
+		t.Fatalf("failed to create generator %v", err)
+	}
+	rb3, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("failed to create random basic %v", err)
+	}
+
+	data, err := simple.MarshalBinary(rb3)
+	if err != nil {
+		t.Fatalf("failed to marshal %v", err)
+	}
+
+	tree, err := simple.UnmarshalBinary(data)
+	if err != nil {
+		t.Fatalf("failed to unmarshal %v", err)
+	}
+
+	data2, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal 2nd time %v", err)
+	}
+
+	if !bytes.Equal(data, data2) {
+		t.Errorf("encoding/decoding non-symmetric")
+	}
+
+	// Every truncation must be rejected rather than panic
+	for i := 0; i < len(data); i++ {
+		if _, err := simple.UnmarshalBinary(data[:i]); err == nil {
+			t.Errorf("expected error decoding %d of %d bytes", i, len(data))
+		}
+	}
+
+	if _, err := simple.UnmarshalBinary(append(data, 0)); err != simple.ErrInvalidBinary {
+		t.Errorf("expected trailing data to be rejected, got %v", err)
+	}
+
+	bad := append([]byte{}, data...)
+	bad[3] = simple.BinaryVersion + 1
+	if _, err := simple.UnmarshalBinary(bad); err != simple.ErrUnsupportedBinaryVersion {
+		t.Errorf("expected unsupported version, got %v", err)
+	}
+}
+
+func TestSerializeBinaryExact(t *testing.T) {
+	exactSource, err := htree.NewExactRatioSource(golden.Exprs)
+	if err != nil {
+		t.Fatalf("failed to create exact ratio source %v", err)
+	}
+
+	gen, err := randombasic.New3D(exactSource, 1, 1, 20, 3)
+	if err != nil {
+		t.Fatalf("failed to create generator %v", err)
+	}
+	rb3, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("failed to create random basic %v", err)
+	}
+
+	data, err := simple.MarshalBinary(rb3)
+	if err != nil {
+		t.Fatalf("failed to marshal %v", err)
+	}
+
+	tree, err := simple.UnmarshalBinary(data)
+	if err != nil {
+		t.Fatalf("failed to unmarshal %v", err)
+	}
+
+	if _, ok := tree.RatioSource().(htree.ExactRatioSource); !ok {
+		t.Errorf("expected an exact ratio source")
+	}
+}
+
+func TestSerializeBinaryHeader(t *testing.T) {
+	ratioSource, err := htree.NewExprRatioSource([]string{"1", "2"})
+	if err != nil {
+		t.Fatalf("failed to create ratio source %v", err)
+	}
+
+	root := simple.NewNode(0, nil)
+	tree := simple.NewTree(ratioSource, 1, -1, root, simple.NodeLookup{0: root}, simple.ParentLookup{})
+	data, err := simple.MarshalBinary(tree)
+	if err != nil {
+		t.Fatalf("failed to marshal %v", err)
+	}
+
+	if _, err := simple.UnmarshalBinary(data); err != nil {
+		t.Fatalf("failed to unmarshal %v", err)
+	}
+
+	// The header ratio indexes are the 2 varints before the root id and leaf
+	header := len(data) - 4
+	for _, indexes := range [][2]int64{{2, -1}, {-1, -1}, {0, 2}, {0, -2}} {
+		bad := append([]byte{}, data[:header]...)
+		bad = binary.AppendVarint(bad, indexes[0])
+		bad = binary.AppendVarint(bad, indexes[1])
+		bad = append(bad, data[header+2:]...)
+		if _, err := simple.UnmarshalBinary(bad); err != simple.ErrInvalidBinary {
+			t.Errorf("expected ratio indexes %v to be rejected, got %v", indexes, err)
+		}
+	}
+}
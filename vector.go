@@ -17,3 +17,15 @@ func NewVector(x, y, z float64) *Vector {
 func (v *Vector) Add(other *Vector) *Vector {
 	return NewVector(v.x+other.x, v.y+other.y, v.z+other.z)
 }
+
+func (v *Vector) X() float64 {
+	return v.x
+}
+
+func (v *Vector) Y() float64 {
+	return v.y
+}
+
+func (v *Vector) Z() float64 {
+	return v.z
+}
@@ -0,0 +1,36 @@
+package whirling
+
+import (
+	htree "github.com/scisci/hambidgetree"
+)
+
+// The powers of the golden ratio. Each one is split into a square and the
+// previous power, PHI^n = PHI^(n-1) + PHI^(n-2), which gives the whirling
+// squares when repeated.
+var Exprs = []string{
+	"(7-3*SQRT(5))/2", // 0.1459 PHI^-4
+	"SQRT(5)-2",       // 0.2361 PHI^-3
+	"(3-SQRT(5))/2",   // 0.3820 PHI^-2
+	"(SQRT(5)-1)/2",   // 0.6180 PHI^-1
+	"1",               // 1
+	"(SQRT(5)+1)/2",   // 1.6180 PHI
+	"(SQRT(5)+3)/2",   // 2.6180 PHI^2
+	"SQRT(5)+2",       // 4.2361 PHI^3
+	"(3*SQRT(5)+7)/2", // 6.8541 PHI^4
+}
+
+func RatioSource() htree.RatioSource {
+	ratioSource, err := htree.NewExprRatioSource(Exprs)
+	if err != nil {
+		panic(err)
+	}
+	return ratioSource
+}
+
+func ExactRatioSource() htree.ExactRatioSource {
+	ratioSource, err := htree.NewExactRatioSource(Exprs)
+	if err != nil {
+		panic(err)
+	}
+	return ratioSource
+}